@@ -0,0 +1,90 @@
+package main
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	"github.com/GabrielMoody/mikronet-auth-service/internal/dto"
+	"github.com/GabrielMoody/mikronet-auth-service/internal/mailer"
+	"github.com/GabrielMoody/mikronet-auth-service/internal/models"
+	"github.com/GabrielMoody/mikronet-auth-service/internal/sms"
+)
+
+const adminUsage = `usage: app admin create --email EMAIL --name NAME
+
+The password is read from ADMIN_PASSWORD, or from the first line of stdin
+when that is unset.`
+
+// runAdmin handles "app admin ...". It returns the process exit code.
+func runAdmin(args []string) int {
+	if len(args) == 0 || args[0] != "create" {
+		fmt.Fprintln(os.Stderr, adminUsage)
+		return 2
+	}
+
+	fs := flag.NewFlagSet("admin create", flag.ContinueOnError)
+	fs.Usage = func() { fmt.Fprintln(os.Stderr, adminUsage) }
+
+	email := fs.String("email", "", "email address of the new admin")
+	name := fs.String("name", "", "display name of the new admin")
+
+	if err := fs.Parse(args[1:]); err != nil {
+		return 2
+	}
+
+	password, err := readAdminPassword(os.Stdin)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "error while reading password: %v\n", err)
+		return 1
+	}
+
+	db := models.DatabaseInit()
+
+	// Creating an admin sends no mail or SMS, so nothing needs configuring.
+	svc, err := newServices(db, mailer.NewLogMailer(), sms.NewLogSender())
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		return 1
+	}
+
+	res, errService := svc.auth.CreateAdminService(context.Background(), dto.CreateAdminReq{
+		Email:    *email,
+		Name:     *name,
+		Password: password,
+	})
+
+	if errService != nil && errService.ValidationErrors != nil {
+		for field, msg := range errService.ValidationErrors {
+			fmt.Fprintf(os.Stderr, "%s: %s\n", field, msg)
+		}
+		return 1
+	}
+
+	if errService != nil {
+		fmt.Fprintf(os.Stderr, "error while creating admin: %v\n", errService.Err)
+		return 1
+	}
+
+	fmt.Println(res)
+
+	return 0
+}
+
+func readAdminPassword(stdin io.Reader) (string, error) {
+	if password := os.Getenv("ADMIN_PASSWORD"); password != "" {
+		return password, nil
+	}
+
+	line, err := bufio.NewReader(stdin).ReadString('\n')
+	if err != nil && !errors.Is(err, io.EOF) {
+		return "", err
+	}
+
+	return strings.TrimRight(line, "\r\n"), nil
+}
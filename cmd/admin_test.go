@@ -0,0 +1,74 @@
+package main
+
+import (
+	"context"
+	"os"
+	"strings"
+	"testing"
+
+	"github.com/GabrielMoody/mikronet-auth-service/internal/dto"
+	"github.com/GabrielMoody/mikronet-auth-service/internal/mailer"
+	"github.com/GabrielMoody/mikronet-auth-service/internal/sms"
+	"github.com/GabrielMoody/mikronet-auth-service/internal/testdb"
+)
+
+func TestReadAdminPassword(t *testing.T) {
+	tests := []struct {
+		name  string
+		env   string
+		stdin string
+		want  string
+	}{
+		{name: "from env", env: "from-env-123", stdin: "from-stdin-123\n", want: "from-env-123"},
+		{name: "first stdin line", stdin: "from-stdin-123\nsecond line\n", want: "from-stdin-123"},
+		{name: "windows line ending", stdin: "from-stdin-123\r\n", want: "from-stdin-123"},
+		{name: "no trailing newline", stdin: "from-stdin-123", want: "from-stdin-123"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Setenv("ADMIN_PASSWORD", tt.env)
+
+			got, err := readAdminPassword(strings.NewReader(tt.stdin))
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if got != tt.want {
+				t.Errorf("password = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+// The services built for main and the admin command must be able to render
+// mail, which needs the templates under ./views/email.
+func TestNewServicesSendsMail(t *testing.T) {
+	wd, _ := os.Getwd()
+	if err := os.Chdir(".."); err != nil {
+		t.Fatalf("error while changing directory: %v", err)
+	}
+	t.Cleanup(func() { os.Chdir(wd) })
+
+	ctx := context.Background()
+	db := testdb.New(t)
+	m := mailer.NewMemoryMailer()
+
+	svc, err := newServices(db, m, sms.NewMemorySender())
+	if err != nil {
+		t.Fatalf("newServices: %v", err)
+	}
+
+	adminID, errService := svc.auth.CreateAdminService(ctx, dto.CreateAdminReq{Email: "root@example.com", Name: "Root", Password: "password123"})
+	if errService != nil {
+		t.Fatalf("CreateAdminService: %+v", errService)
+	}
+
+	if _, errService := svc.auth.InviteAdminService(ctx, adminID, dto.InviteAdminReq{Email: "new@example.com", Name: "New"}); errService != nil {
+		t.Fatalf("InviteAdminService: %+v", errService)
+	}
+
+	msgs := m.Messages()
+	if len(msgs) != 1 || msgs[0].To != "new@example.com" || msgs[0].Subject == "" || msgs[0].HTML == "" {
+		t.Fatalf("expected a rendered invite, got %+v", msgs)
+	}
+}
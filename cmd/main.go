@@ -1,16 +1,40 @@
 package main
 
 import (
+	"context"
+	"fmt"
+	"log"
+	"net"
+	"os"
+	"os/signal"
+	"syscall"
+	"time"
+
+	"github.com/GabrielMoody/mikronet-auth-service/internal/google"
 	"github.com/GabrielMoody/mikronet-auth-service/internal/handler"
+	"github.com/GabrielMoody/mikronet-auth-service/internal/helper"
+	"github.com/GabrielMoody/mikronet-auth-service/internal/mailer"
+	"github.com/GabrielMoody/mikronet-auth-service/internal/middleware"
 	"github.com/GabrielMoody/mikronet-auth-service/internal/models"
+	"github.com/GabrielMoody/mikronet-auth-service/internal/repository"
+	"github.com/GabrielMoody/mikronet-auth-service/internal/rpc"
+	"github.com/GabrielMoody/mikronet-auth-service/internal/service"
+	"github.com/GabrielMoody/mikronet-auth-service/internal/sms"
 	"github.com/gofiber/fiber/v2"
 	"github.com/gofiber/fiber/v2/middleware/cors"
 	"github.com/gofiber/fiber/v2/middleware/logger"
+	"gorm.io/gorm"
 )
 
 func main() {
+	if len(os.Args) > 1 && os.Args[1] == "admin" {
+		os.Exit(runAdmin(os.Args[2:]))
+	}
+
 	app := fiber.New(fiber.Config{
 		BodyLimit: 1024 * 1024 * 1024,
+		// Behind Kong set this to X-Real-IP so login throttling sees client IPs.
+		ProxyHeader: os.Getenv("PROXY_HEADER"),
 	})
 
 	app.Use(cors.New(cors.Config{
@@ -21,19 +45,102 @@ func main() {
 
 	app.Static("/static", "./static")
 
+	app.Use(middleware.CaptureRequestInfo)
+
 	app.Use(logger.New(logger.Config{
 		TimeFormat: "02-Jan-2006",
 		TimeZone:   "Asia/Singapore",
 	}))
 
+	if err := helper.LoadJWTKeys(); err != nil {
+		panic(fmt.Errorf("error while loading jwt keys %v", err.Error()))
+	}
+
 	db := models.DatabaseInit()
 
+	m, err := mailer.NewFromEnv()
+	if err != nil {
+		log.Fatalf("error while configuring mailer: %v", err)
+	}
+
+	smsSender, err := sms.NewFromEnv()
+	if err != nil {
+		log.Fatalf("error while configuring sms sender: %v", err)
+	}
+
+	svc, err := newServices(db, m, smsSender)
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	// Cancelled on SIGINT/SIGTERM, which also shuts the servers down below.
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	go service.RunAccountPurger(ctx, svc.auth, svc.token, helper.GetEnvDuration("ACCOUNT_PURGE_INTERVAL", time.Hour))
+
 	api := app.Group("/")
+	admin := api.Group("/admin", middleware.RequireAuth(svc.revocationStore), middleware.RequireRole("admin"))
+
+	handler.AuthHandler(api, admin, svc.revocationStore, svc.auth, svc.token, svc.twoFactor)
+	handler.AdminHandler(admin, svc.admin, svc.audit, svc.token, svc.twoFactor)
+	handler.OAuthHandler(api, admin, svc.revocationStore, svc.oauth, svc.serviceAccount)
+
+	lis, err := net.Listen("tcp", helper.GetEnv("GRPC_ADDR", ":8051"))
+	if err != nil {
+		panic(fmt.Errorf("error while listening grpc %v", err.Error()))
+	}
+
+	grpcServer := rpc.NewAuthServer(svc.auth, svc.revocationStore)
 
-	handler.AuthHandler(api, db)
+	go func() {
+		if err := grpcServer.Serve(lis); err != nil {
+			log.Print(err)
+		}
+	}()
 
-	err := app.Listen(":8050")
+	go func() {
+		<-ctx.Done()
+		grpcServer.GracefulStop()
+		app.Shutdown()
+	}()
+
+	err = app.Listen(helper.GetEnv("HTTP_ADDR", ":8050"))
 	if err != nil {
 		return
 	}
 }
+
+// services are built once and shared by the HTTP handlers, the gRPC server,
+// the account purger and the admin command.
+type services struct {
+	revocationStore repository.RevocationStore
+	token           service.TokenService
+	auth            service.AuthService
+	twoFactor       service.TwoFactorService
+	audit           service.AuditService
+	admin           service.AdminService
+	serviceAccount  service.ServiceAccountService
+	oauth           service.OAuthService
+}
+
+func newServices(db *gorm.DB, m mailer.Mailer, smsSender sms.SMSSender) (res services, err error) {
+	templates, err := mailer.LoadTemplates("./views/email")
+	if err != nil {
+		return res, fmt.Errorf("error while loading email templates: %w", err)
+	}
+
+	authRepo := repository.NewAuthRepo(db)
+	loginAttemptStore := repository.NewLoginAttemptStore(db)
+
+	res.revocationStore = repository.NewRevocationStore(db)
+	res.token = service.NewTokenService(repository.NewTokenRepo(db), res.revocationStore)
+	res.audit = service.NewAuditService(repository.NewAuditRepo(db))
+	res.auth = service.NewAuthService(authRepo, loginAttemptStore, m, templates, smsSender, google.NewVerifierFromEnv(), res.token, res.audit)
+	res.twoFactor = service.NewTwoFactorService(repository.NewTwoFactorRepo(db), authRepo, loginAttemptStore, res.revocationStore, res.audit)
+	res.admin = service.NewAdminService(repository.NewAdminRepo(db), res.token, res.audit)
+	res.serviceAccount = service.NewServiceAccountService(repository.NewServiceAccountRepo(db), res.audit)
+	res.oauth = service.NewOAuthService(repository.NewOAuthRepo(db), authRepo, res.token, res.serviceAccount, res.audit)
+
+	return res, nil
+}
@@ -0,0 +1,382 @@
+package controller
+
+import (
+	"github.com/GabrielMoody/mikronet-auth-service/internal/dto"
+	"github.com/GabrielMoody/mikronet-auth-service/internal/middleware"
+	"github.com/GabrielMoody/mikronet-auth-service/internal/service"
+	"github.com/gofiber/fiber/v2"
+)
+
+type AdminController interface {
+	ApproveGov(c *fiber.Ctx) error
+	RejectGov(c *fiber.Ctx) error
+	GetPendingDrivers(c *fiber.Ctx) error
+	GetDriverDocument(c *fiber.Ctx) error
+	ApproveDriver(c *fiber.Ctx) error
+	RejectDriver(c *fiber.Ctx) error
+	BlockAccount(c *fiber.Ctx) error
+	UnblockAccount(c *fiber.Ctx) error
+	GetBlockedAccounts(c *fiber.Ctx) error
+	GetAuthEvents(c *fiber.Ctx) error
+	GetUsers(c *fiber.Ctx) error
+	GetUser(c *fiber.Ctx) error
+	UpdateUser(c *fiber.Ctx) error
+}
+
+type AdminControllerImpl struct {
+	AdminService service.AdminService
+	AuditService service.AuditService
+}
+
+func (a *AdminControllerImpl) ApproveGov(c *fiber.Ctx) error {
+	ctx := c.Context()
+	id := c.Params("id")
+
+	claims := middleware.GetClaims(c)
+
+	_, errService := a.AdminService.ApproveGovService(ctx, id, claims.ID)
+
+	if errService != nil {
+		return c.Status(errService.Code).JSON(fiber.Map{
+			"status": "error",
+			"errors": errService.Err.Error(),
+		})
+	}
+
+	return c.Status(fiber.StatusOK).JSON(fiber.Map{
+		"status":  "success",
+		"message": "akun berhasil disetujui!",
+	})
+}
+
+func (a *AdminControllerImpl) RejectGov(c *fiber.Ctx) error {
+	ctx := c.Context()
+	id := c.Params("id")
+	var req dto.RejectGovReq
+
+	claims := middleware.GetClaims(c)
+
+	if err := c.BodyParser(&req); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"status": "error",
+			"errors": err.Error(),
+		})
+	}
+
+	_, errService := a.AdminService.RejectGovService(ctx, id, claims.ID, req)
+
+	if errService != nil && errService.ValidationErrors != nil {
+		return c.Status(errService.Code).JSON(fiber.Map{
+			"status": "error",
+			"errors": errService.ValidationErrors,
+		})
+	}
+
+	if errService != nil && errService.Err != nil {
+		return c.Status(errService.Code).JSON(fiber.Map{
+			"status": "error",
+			"errors": errService.Err.Error(),
+		})
+	}
+
+	return c.Status(fiber.StatusOK).JSON(fiber.Map{
+		"status":  "success",
+		"message": "pendaftaran akun ditolak",
+	})
+}
+
+func (a *AdminControllerImpl) GetPendingDrivers(c *fiber.Ctx) error {
+	ctx := c.Context()
+
+	res, meta, errService := a.AdminService.GetPendingDriversService(ctx, c.QueryInt("page", 1), c.QueryInt("limit", 10))
+
+	if errService != nil {
+		return c.Status(errService.Code).JSON(fiber.Map{
+			"status": "error",
+			"errors": errService.Err.Error(),
+		})
+	}
+
+	return c.Status(fiber.StatusOK).JSON(fiber.Map{
+		"status": "success",
+		"data":   res,
+		"meta":   meta,
+	})
+}
+
+func (a *AdminControllerImpl) GetDriverDocument(c *fiber.Ctx) error {
+	ctx := c.Context()
+
+	res, errService := a.AdminService.GetDriverDocumentService(ctx, c.Params("id"), c.Params("document"))
+
+	if errService != nil {
+		return c.Status(errService.Code).JSON(fiber.Map{
+			"status": "error",
+			"errors": errService.Err.Error(),
+		})
+	}
+
+	return c.SendFile(res)
+}
+
+func (a *AdminControllerImpl) ApproveDriver(c *fiber.Ctx) error {
+	ctx := c.Context()
+	id := c.Params("id")
+
+	claims := middleware.GetClaims(c)
+
+	_, errService := a.AdminService.ApproveDriverService(ctx, id, claims.ID)
+
+	if errService != nil {
+		return c.Status(errService.Code).JSON(fiber.Map{
+			"status": "error",
+			"errors": errService.Err.Error(),
+		})
+	}
+
+	return c.Status(fiber.StatusOK).JSON(fiber.Map{
+		"status":  "success",
+		"message": "driver berhasil diverifikasi!",
+	})
+}
+
+func (a *AdminControllerImpl) RejectDriver(c *fiber.Ctx) error {
+	ctx := c.Context()
+	id := c.Params("id")
+	var req dto.RejectDriverReq
+
+	claims := middleware.GetClaims(c)
+
+	if err := c.BodyParser(&req); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"status": "error",
+			"errors": err.Error(),
+		})
+	}
+
+	_, errService := a.AdminService.RejectDriverService(ctx, id, claims.ID, req)
+
+	if errService != nil && errService.ValidationErrors != nil {
+		return c.Status(errService.Code).JSON(fiber.Map{
+			"status": "error",
+			"errors": errService.ValidationErrors,
+		})
+	}
+
+	if errService != nil && errService.Err != nil {
+		return c.Status(errService.Code).JSON(fiber.Map{
+			"status": "error",
+			"errors": errService.Err.Error(),
+		})
+	}
+
+	return c.Status(fiber.StatusOK).JSON(fiber.Map{
+		"status":  "success",
+		"message": "pendaftaran driver ditolak",
+	})
+}
+
+func (a *AdminControllerImpl) BlockAccount(c *fiber.Ctx) error {
+	ctx := c.Context()
+	id := c.Params("id")
+	var req dto.BlockAccountReq
+
+	claims := middleware.GetClaims(c)
+
+	if err := c.BodyParser(&req); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"status": "error",
+			"errors": err.Error(),
+		})
+	}
+
+	res, errService := a.AdminService.BlockAccountService(ctx, id, claims.ID, req)
+
+	if errService != nil && errService.ValidationErrors != nil {
+		return c.Status(errService.Code).JSON(fiber.Map{
+			"status": "error",
+			"errors": errService.ValidationErrors,
+		})
+	}
+
+	if errService != nil && errService.Err != nil {
+		return c.Status(errService.Code).JSON(fiber.Map{
+			"status": "error",
+			"errors": errService.Err.Error(),
+		})
+	}
+
+	return c.Status(fiber.StatusOK).JSON(fiber.Map{
+		"status": "success",
+		"data":   res,
+	})
+}
+
+func (a *AdminControllerImpl) UnblockAccount(c *fiber.Ctx) error {
+	ctx := c.Context()
+	id := c.Params("id")
+
+	claims := middleware.GetClaims(c)
+
+	_, errService := a.AdminService.UnblockAccountService(ctx, id, claims.ID)
+
+	if errService != nil {
+		return c.Status(errService.Code).JSON(fiber.Map{
+			"status": "error",
+			"errors": errService.Err.Error(),
+		})
+	}
+
+	return c.Status(fiber.StatusOK).JSON(fiber.Map{
+		"status":  "success",
+		"message": "akun berhasil dibuka blokirnya!",
+	})
+}
+
+func (a *AdminControllerImpl) GetBlockedAccounts(c *fiber.Ctx) error {
+	ctx := c.Context()
+
+	res, meta, errService := a.AdminService.GetBlockedAccountsService(ctx, c.QueryInt("page", 1), c.QueryInt("limit", 10))
+
+	if errService != nil {
+		return c.Status(errService.Code).JSON(fiber.Map{
+			"status": "error",
+			"errors": errService.Err.Error(),
+		})
+	}
+
+	return c.Status(fiber.StatusOK).JSON(fiber.Map{
+		"status": "success",
+		"data":   res,
+		"meta":   meta,
+	})
+}
+
+func (a *AdminControllerImpl) GetAuthEvents(c *fiber.Ctx) error {
+	ctx := c.Context()
+	var query dto.AuthEventQuery
+
+	if err := c.QueryParser(&query); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"status": "error",
+			"errors": err.Error(),
+		})
+	}
+
+	res, meta, errService := a.AuditService.GetAuthEventsService(ctx, query)
+
+	if errService != nil && errService.ValidationErrors != nil {
+		return c.Status(errService.Code).JSON(fiber.Map{
+			"status": "error",
+			"errors": errService.ValidationErrors,
+		})
+	}
+
+	if errService != nil && errService.Err != nil {
+		return c.Status(errService.Code).JSON(fiber.Map{
+			"status": "error",
+			"errors": errService.Err.Error(),
+		})
+	}
+
+	return c.Status(fiber.StatusOK).JSON(fiber.Map{
+		"status": "success",
+		"data":   res,
+		"meta":   meta,
+	})
+}
+
+func (a *AdminControllerImpl) GetUsers(c *fiber.Ctx) error {
+	ctx := c.Context()
+	var query dto.AdminUserQuery
+
+	if err := c.QueryParser(&query); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"status": "error",
+			"errors": err.Error(),
+		})
+	}
+
+	res, meta, errService := a.AdminService.GetUsersService(ctx, query)
+
+	if errService != nil && errService.ValidationErrors != nil {
+		return c.Status(errService.Code).JSON(fiber.Map{
+			"status": "error",
+			"errors": errService.ValidationErrors,
+		})
+	}
+
+	if errService != nil && errService.Err != nil {
+		return c.Status(errService.Code).JSON(fiber.Map{
+			"status": "error",
+			"errors": errService.Err.Error(),
+		})
+	}
+
+	return c.Status(fiber.StatusOK).JSON(fiber.Map{
+		"status": "success",
+		"data":   res,
+		"meta":   meta,
+	})
+}
+
+func (a *AdminControllerImpl) GetUser(c *fiber.Ctx) error {
+	ctx := c.Context()
+
+	res, errService := a.AdminService.GetUserService(ctx, c.Params("id"))
+
+	if errService != nil {
+		return c.Status(errService.Code).JSON(fiber.Map{
+			"status": "error",
+			"errors": errService.Err.Error(),
+		})
+	}
+
+	return c.Status(fiber.StatusOK).JSON(fiber.Map{
+		"status": "success",
+		"data":   res,
+	})
+}
+
+func (a *AdminControllerImpl) UpdateUser(c *fiber.Ctx) error {
+	ctx := c.Context()
+	id := c.Params("id")
+	var data dto.AdminUpdateUserReq
+
+	claims := middleware.GetClaims(c)
+
+	if err := c.BodyParser(&data); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"status": "error",
+			"errors": err.Error(),
+		})
+	}
+
+	res, errService := a.AdminService.UpdateUserService(ctx, id, claims.ID, data)
+
+	if errService != nil && errService.ValidationErrors != nil {
+		return c.Status(errService.Code).JSON(fiber.Map{
+			"status": "error",
+			"errors": errService.ValidationErrors,
+		})
+	}
+
+	if errService != nil && errService.Err != nil {
+		return c.Status(errService.Code).JSON(fiber.Map{
+			"status": "error",
+			"errors": errService.Err.Error(),
+		})
+	}
+
+	return c.Status(fiber.StatusOK).JSON(fiber.Map{
+		"status": "success",
+		"data":   res,
+	})
+}
+
+func NewAdminController(adminService service.AdminService, auditService service.AuditService) AdminController {
+	return &AdminControllerImpl{
+		AdminService: adminService,
+		AuditService: auditService,
+	}
+}
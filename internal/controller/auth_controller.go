@@ -4,29 +4,51 @@ import (
 	"errors"
 	"io"
 	"mime/multipart"
-	"net/http"
-	"os"
-	"time"
+	"strconv"
+	"strings"
 
 	"github.com/GabrielMoody/mikronet-auth-service/internal/dto"
+	"github.com/GabrielMoody/mikronet-auth-service/internal/helper"
 	"github.com/GabrielMoody/mikronet-auth-service/internal/middleware"
 	"github.com/GabrielMoody/mikronet-auth-service/internal/service"
 	"github.com/gofiber/fiber/v2"
-	"github.com/golang-jwt/jwt/v5"
 )
 
 type AuthController interface {
 	CreateUser(c *fiber.Ctx) error
 	CreateDriver(c *fiber.Ctx) error
+	CreateOwner(c *fiber.Ctx) error
+	CreateGov(c *fiber.Ctx) error
+	InviteAdmin(c *fiber.Ctx) error
+	ResendAdminInvite(c *fiber.Ctx) error
 	LoginUser(c *fiber.Ctx) error
+	RequestLoginOTP(c *fiber.Ctx) error
+	VerifyLoginOTP(c *fiber.Ctx) error
+	GoogleLogin(c *fiber.Ctx) error
+	LinkGoogle(c *fiber.Ctx) error
+	UnlinkGoogle(c *fiber.Ctx) error
+	RefreshToken(c *fiber.Ctx) error
+	Logout(c *fiber.Ctx) error
+	LogoutAll(c *fiber.Ctx) error
+	GetSessions(c *fiber.Ctx) error
+	RevokeSession(c *fiber.Ctx) error
 	SendResetPasswordLink(c *fiber.Ctx) error
 	ResetPassword(c *fiber.Ctx) error
+	SendResetPasswordOTP(c *fiber.Ctx) error
+	ResetPasswordWithOTP(c *fiber.Ctx) error
 	ChangePassword(c *fiber.Ctx) error
 	ResetPasswordUI(c *fiber.Ctx) error
+	JWKS(c *fiber.Ctx) error
+	DeleteAccount(c *fiber.Ctx) error
+	CancelDeletion(c *fiber.Ctx) error
+	VerifyEmail(c *fiber.Ctx) error
+	ResendVerification(c *fiber.Ctx) error
 }
 
 type AuthControllerImpl struct {
-	AuthService service.AuthService
+	AuthService      service.AuthService
+	TokenService     service.TokenService
+	TwoFactorService service.TwoFactorService
 }
 
 func readImage(image *multipart.FileHeader) ([]byte, error) {
@@ -44,13 +66,27 @@ func readImage(image *multipart.FileHeader) ([]byte, error) {
 	return fileData, nil
 }
 
-func (a *AuthControllerImpl) ChangePassword(c *fiber.Ctx) error {
-	token := c.Get("Authorization")
-	payload, _ := middleware.GetJWTPayload(token[7:], os.Getenv("JWT_SECRET"))
+// deviceInfo labels the session a login starts. Apps can name the device
+// with X-Device-Name; otherwise it is guessed from the user agent.
+func deviceInfo(c *fiber.Ctx) dto.DeviceInfo {
+	name := strings.TrimSpace(c.Get("X-Device-Name"))
+	if len(name) > 255 {
+		name = name[:255]
+	}
+
+	return dto.DeviceInfo{
+		DeviceName: name,
+		UserAgent:  c.Get(fiber.HeaderUserAgent),
+		IP:         c.IP(),
+	}
+}
 
+func (a *AuthControllerImpl) ChangePassword(c *fiber.Ctx) error {
 	ctx := c.Context()
 	var user dto.ChangePasswordReq
 
+	claims := middleware.GetClaims(c)
+
 	if err := c.BodyParser(&user); err != nil {
 		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
 			"status": "error",
@@ -58,7 +94,7 @@ func (a *AuthControllerImpl) ChangePassword(c *fiber.Ctx) error {
 		})
 	}
 
-	_, errService := a.AuthService.ChangePasswordService(ctx, payload["id"].(string), user)
+	_, errService := a.AuthService.ChangePasswordService(ctx, claims.ID, user)
 
 	if errService != nil && errService.ValidationErrors != nil {
 		return c.Status(errService.Code).JSON(fiber.Map{
@@ -91,6 +127,8 @@ func (a *AuthControllerImpl) CreateUser(c *fiber.Ctx) error {
 		})
 	}
 
+	user.Locale = c.Get(fiber.HeaderAcceptLanguage)
+
 	_, errService := a.AuthService.CreateUserService(ctx, user, "user")
 
 	if errService != nil && errService.ValidationErrors != nil {
@@ -113,16 +151,83 @@ func (a *AuthControllerImpl) CreateUser(c *fiber.Ctx) error {
 	})
 }
 
+func (a *AuthControllerImpl) InviteAdmin(c *fiber.Ctx) error {
+	var data dto.InviteAdminReq
+	ctx := c.Context()
+
+	claims := middleware.GetClaims(c)
+
+	if err := c.BodyParser(&data); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"status": "error",
+			"errors": err.Error(),
+		})
+	}
+
+	data.Locale = c.Get(fiber.HeaderAcceptLanguage)
+
+	res, errService := a.AuthService.InviteAdminService(ctx, claims.ID, data)
+
+	if errService != nil && errService.ValidationErrors != nil {
+		return c.Status(errService.Code).JSON(fiber.Map{
+			"status": "error",
+			"errors": errService.ValidationErrors,
+		})
+	}
+
+	if errService != nil && errService.Err != nil {
+		return c.Status(errService.Code).JSON(fiber.Map{
+			"status": "error",
+			"errors": errService.Err.Error(),
+		})
+	}
+
+	return c.Status(fiber.StatusCreated).JSON(fiber.Map{
+		"status": "success",
+		"data": fiber.Map{
+			"id": res,
+		},
+	})
+}
+
+func (a *AuthControllerImpl) ResendAdminInvite(c *fiber.Ctx) error {
+	ctx := c.Context()
+
+	claims := middleware.GetClaims(c)
+
+	res, errService := a.AuthService.ResendAdminInviteService(ctx, claims.ID, c.Params("id"))
+
+	if errService != nil {
+		return c.Status(errService.Code).JSON(fiber.Map{
+			"status": "error",
+			"errors": errService.Err.Error(),
+		})
+	}
+
+	return c.Status(fiber.StatusOK).JSON(fiber.Map{
+		"status":  "success",
+		"message": res,
+	})
+}
+
 func (a *AuthControllerImpl) CreateDriver(c *fiber.Ctx) error {
 	var driver dto.DriverRegistrationsReq
 	ctx := c.Context()
 	pp, err := c.FormFile("profile_picture")
+
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"status": "error",
+			"errors": "profile_picture is required",
+		})
+	}
+
 	ktp, err := c.FormFile("ktp")
 
-	if err != nil && !errors.Is(err, http.ErrMissingFile) {
+	if err != nil {
 		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
 			"status": "error",
-			"errors": "error reading image",
+			"errors": "ktp is required",
 		})
 	}
 
@@ -149,7 +254,18 @@ func (a *AuthControllerImpl) CreateDriver(c *fiber.Ctx) error {
 		})
 	}
 
-	_, errService := a.AuthService.CreateDriverService(ctx, driver, "driver", fileDataPP, fileDataKtp)
+	var fileDataSim []byte
+	if sim, errSim := c.FormFile("sim_document"); errSim == nil {
+		fileDataSim, err = readImage(sim)
+		if err != nil {
+			return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+				"status": "error",
+				"errors": err.Error(),
+			})
+		}
+	}
+
+	_, errService := a.AuthService.CreateDriverService(ctx, driver, "driver", fileDataPP, fileDataKtp, fileDataSim)
 
 	if errService != nil && errService.ValidationErrors != nil {
 		return c.Status(errService.Code).JSON(fiber.Map{
@@ -171,18 +287,34 @@ func (a *AuthControllerImpl) CreateDriver(c *fiber.Ctx) error {
 	})
 }
 
-func (a *AuthControllerImpl) LoginUser(c *fiber.Ctx) error {
+func (a *AuthControllerImpl) CreateOwner(c *fiber.Ctx) error {
+	var owner dto.OwnerRegistrationsReq
 	ctx := c.Context()
-	var user dto.UserLoginReq
+	pp, err := c.FormFile("profile_picture")
 
-	if err := c.BodyParser(&user); err != nil {
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"status": "error",
+			"errors": "profile_picture is required",
+		})
+	}
+
+	if err := c.BodyParser(&owner); err != nil {
 		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
 			"status": "error",
 			"errors": err.Error(),
 		})
 	}
 
-	res, errService := a.AuthService.LoginUserService(ctx, user)
+	fileDataPP, err := readImage(pp)
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"status": "error",
+			"errors": err.Error(),
+		})
+	}
+
+	_, errService := a.AuthService.CreateOwnerService(ctx, owner, "owner", fileDataPP)
 
 	if errService != nil && errService.ValidationErrors != nil {
 		return c.Status(errService.Code).JSON(fiber.Map{
@@ -198,61 +330,156 @@ func (a *AuthControllerImpl) LoginUser(c *fiber.Ctx) error {
 		})
 	}
 
-	claims := jwt.MapClaims{
-		"id":    res.ID,
-		"email": res.Email,
-		"role":  res.Role,
-		"exp":   time.Now().Add(time.Hour * 24).Unix(),
-		"iss":   os.Getenv("JWT_ISS"),
+	return c.Status(fiber.StatusCreated).JSON(fiber.Map{
+		"status":  "success",
+		"message": "akun berhasil dibuat!",
+	})
+}
+
+func (a *AuthControllerImpl) CreateGov(c *fiber.Ctx) error {
+	var gov dto.GovRegistrationReq
+	ctx := c.Context()
+	pp, err := c.FormFile("profile_picture")
+
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"status": "error",
+			"errors": "profile_picture is required",
+		})
+	}
+
+	if err := c.BodyParser(&gov); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"status": "error",
+			"errors": err.Error(),
+		})
+	}
+
+	fileDataPP, err := readImage(pp)
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"status": "error",
+			"errors": err.Error(),
+		})
 	}
 
-	token := jwt.NewWithClaims(jwt.SigningMethodHS256, claims)
-	t, errToken := token.SignedString([]byte(os.Getenv("JWT_SECRET")))
+	_, errService := a.AuthService.CreateGovService(ctx, gov, "government", fileDataPP)
 
-	if errToken != nil {
-		return c.Status(fiber.StatusForbidden).JSON(fiber.Map{
+	if errService != nil && errService.ValidationErrors != nil {
+		return c.Status(errService.Code).JSON(fiber.Map{
+			"status": "error",
+			"errors": errService.ValidationErrors,
+		})
+	}
+
+	if errService != nil && errService.Err != nil {
+		return c.Status(errService.Code).JSON(fiber.Map{
+			"status": "error",
+			"errors": errService.Err.Error(),
+		})
+	}
+
+	return c.Status(fiber.StatusCreated).JSON(fiber.Map{
+		"status":  "success",
+		"message": "akun berhasil dibuat! silahkan menunggu persetujuan admin",
+	})
+}
+
+// completeLogin is shared by every first-factor login: it either answers with
+// a two-factor challenge or issues the token pair.
+func (a *AuthControllerImpl) completeLogin(c *fiber.Ctx, user dto.UserRegistrationsResp) error {
+	ctx := c.Context()
+
+	challenge, errTwoFactor := a.TwoFactorService.CheckLoginService(ctx, user)
+
+	if errTwoFactor != nil {
+		return c.Status(errTwoFactor.Code).JSON(fiber.Map{
 			"status": "error",
-			"errors": "Invalid token",
+			"errors": errTwoFactor.Err.Error(),
 		})
 	}
 
-	refreshClaims := jwt.MapClaims{
-		"id":  res.ID,
-		"exp": time.Now().Add(time.Hour * 24 * 7).Unix(),
-		"iss": os.Getenv("JWT_ISS"),
+	if challenge.TwoFactorRequired || challenge.SetupRequired {
+		return c.Status(fiber.StatusOK).JSON(fiber.Map{
+			"status": "success",
+			"data":   challenge,
+		})
 	}
 
-	refreshToken := jwt.NewWithClaims(jwt.SigningMethodHS256, refreshClaims)
-	rt, errRefreshToken := refreshToken.SignedString([]byte(os.Getenv("JWT_SECRET")))
+	tokens, errToken := a.TokenService.IssueTokenService(ctx, user, deviceInfo(c))
 
-	if errRefreshToken != nil {
-		return c.Status(fiber.StatusForbidden).JSON(fiber.Map{
+	if errToken != nil {
+		return c.Status(errToken.Code).JSON(fiber.Map{
 			"status": "error",
-			"errors": "Invalid refresh token",
+			"errors": errToken.Err.Error(),
 		})
 	}
 
 	return c.Status(fiber.StatusOK).JSON(fiber.Map{
 		"status": "success",
-		"data": fiber.Map{
-			"access_token":  t,
-			"refresh_token": rt,
-		},
+		"data":   tokens,
 	})
 }
 
-func (a *AuthControllerImpl) SendResetPasswordLink(c *fiber.Ctx) error {
-	var email dto.ForgotPasswordReq
+func (a *AuthControllerImpl) LoginUser(c *fiber.Ctx) error {
 	ctx := c.Context()
+	var user dto.UserLoginReq
 
-	if err := c.BodyParser(&email); err != nil {
+	if err := c.BodyParser(&user); err != nil {
 		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
 			"status": "error",
 			"errors": err.Error(),
 		})
 	}
 
-	res, err := a.AuthService.SendResetPasswordService(ctx, email)
+	res, errService := a.AuthService.LoginUserService(ctx, user, c.IP())
+
+	var tooManyAttempts *helper.TooManyAttemptsError
+	if errService != nil && errors.As(errService.Err, &tooManyAttempts) {
+		c.Set(fiber.HeaderRetryAfter, strconv.Itoa(tooManyAttempts.Seconds()))
+	}
+
+	if errService != nil && errService.ValidationErrors != nil {
+		return c.Status(errService.Code).JSON(fiber.Map{
+			"status": "error",
+			"errors": errService.ValidationErrors,
+		})
+	}
+
+	if errService != nil && errService.Err != nil {
+		return c.Status(errService.Code).JSON(fiber.Map{
+			"status": "error",
+			"errors": errService.Err.Error(),
+		})
+	}
+
+	return a.completeLogin(c, res)
+}
+
+func (a *AuthControllerImpl) RequestLoginOTP(c *fiber.Ctx) error {
+	var data dto.LoginOTPReq
+	ctx := c.Context()
+
+	if err := c.BodyParser(&data); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"status": "error",
+			"errors": err.Error(),
+		})
+	}
+
+	res, err := a.AuthService.SendLoginOTPService(ctx, data)
+
+	var tooManyAttempts *helper.TooManyAttemptsError
+	if err != nil && errors.As(err.Err, &tooManyAttempts) {
+		c.Set(fiber.HeaderRetryAfter, strconv.Itoa(tooManyAttempts.Seconds()))
+	}
+
+	if err != nil && err.ValidationErrors != nil {
+		return c.Status(err.Code).JSON(fiber.Map{
+			"status": "error",
+			"errors": err.ValidationErrors,
+		})
+	}
 
 	if err != nil {
 		return c.Status(err.Code).JSON(fiber.Map{
@@ -267,19 +494,82 @@ func (a *AuthControllerImpl) SendResetPasswordLink(c *fiber.Ctx) error {
 	})
 }
 
-func (a *AuthControllerImpl) ResetPassword(c *fiber.Ctx) error {
-	code := c.Params("code")
-	var rp dto.ResetPasswordReq
+func (a *AuthControllerImpl) VerifyLoginOTP(c *fiber.Ctx) error {
 	ctx := c.Context()
+	var data dto.LoginOTPVerifyReq
 
-	if err := c.BodyParser(&rp); err != nil {
+	if err := c.BodyParser(&data); err != nil {
 		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
 			"status": "error",
 			"errors": err.Error(),
 		})
 	}
 
-	_, errService := a.AuthService.ResetPassword(ctx, rp, code)
+	res, errService := a.AuthService.LoginWithOTPService(ctx, data)
+
+	if errService != nil && errService.ValidationErrors != nil {
+		return c.Status(errService.Code).JSON(fiber.Map{
+			"status": "error",
+			"errors": errService.ValidationErrors,
+		})
+	}
+
+	if errService != nil && errService.Err != nil {
+		return c.Status(errService.Code).JSON(fiber.Map{
+			"status": "error",
+			"errors": errService.Err.Error(),
+		})
+	}
+
+	return a.completeLogin(c, res)
+}
+
+func (a *AuthControllerImpl) GoogleLogin(c *fiber.Ctx) error {
+	ctx := c.Context()
+	var data dto.GoogleLoginReq
+
+	if err := c.BodyParser(&data); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"status": "error",
+			"errors": err.Error(),
+		})
+	}
+
+	data.Locale = c.Get(fiber.HeaderAcceptLanguage)
+
+	res, errService := a.AuthService.GoogleLoginService(ctx, data)
+
+	if errService != nil && errService.ValidationErrors != nil {
+		return c.Status(errService.Code).JSON(fiber.Map{
+			"status": "error",
+			"errors": errService.ValidationErrors,
+		})
+	}
+
+	if errService != nil && errService.Err != nil {
+		return c.Status(errService.Code).JSON(fiber.Map{
+			"status": "error",
+			"errors": errService.Err.Error(),
+		})
+	}
+
+	return a.completeLogin(c, res)
+}
+
+func (a *AuthControllerImpl) LinkGoogle(c *fiber.Ctx) error {
+	ctx := c.Context()
+	var req dto.LinkGoogleReq
+
+	claims := middleware.GetClaims(c)
+
+	if err := c.BodyParser(&req); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"status": "error",
+			"errors": err.Error(),
+		})
+	}
+
+	res, errService := a.AuthService.LinkGoogleService(ctx, claims.ID, req)
 
 	if errService != nil && errService.ValidationErrors != nil {
 		return c.Status(errService.Code).JSON(fiber.Map{
@@ -297,16 +587,435 @@ func (a *AuthControllerImpl) ResetPassword(c *fiber.Ctx) error {
 
 	return c.Status(fiber.StatusOK).JSON(fiber.Map{
 		"status":  "success",
-		"message": "Berhasil melakukan reset password!",
+		"message": res,
 	})
 }
 
-func (a *AuthControllerImpl) ResetPasswordUI(c *fiber.Ctx) error {
-	return c.SendFile("./views/reset_password.html")
+func (a *AuthControllerImpl) UnlinkGoogle(c *fiber.Ctx) error {
+	ctx := c.Context()
+	var req dto.UnlinkGoogleReq
+
+	claims := middleware.GetClaims(c)
+
+	if err := c.BodyParser(&req); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"status": "error",
+			"errors": err.Error(),
+		})
+	}
+
+	res, errService := a.AuthService.UnlinkGoogleService(ctx, claims.ID, req)
+
+	if errService != nil && errService.ValidationErrors != nil {
+		return c.Status(errService.Code).JSON(fiber.Map{
+			"status": "error",
+			"errors": errService.ValidationErrors,
+		})
+	}
+
+	if errService != nil && errService.Err != nil {
+		return c.Status(errService.Code).JSON(fiber.Map{
+			"status": "error",
+			"errors": errService.Err.Error(),
+		})
+	}
+
+	return c.Status(fiber.StatusOK).JSON(fiber.Map{
+		"status":  "success",
+		"message": res,
+	})
+}
+
+func (a *AuthControllerImpl) RefreshToken(c *fiber.Ctx) error {
+	ctx := c.Context()
+	var req dto.RefreshTokenReq
+
+	if err := c.BodyParser(&req); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"status": "error",
+			"errors": err.Error(),
+		})
+	}
+
+	res, errService := a.TokenService.RefreshTokenService(ctx, req, deviceInfo(c))
+
+	if errService != nil && errService.ValidationErrors != nil {
+		return c.Status(errService.Code).JSON(fiber.Map{
+			"status": "error",
+			"errors": errService.ValidationErrors,
+		})
+	}
+
+	if errService != nil && errService.Err != nil {
+		return c.Status(errService.Code).JSON(fiber.Map{
+			"status": "error",
+			"errors": errService.Err.Error(),
+		})
+	}
+
+	return c.Status(fiber.StatusOK).JSON(fiber.Map{
+		"status": "success",
+		"data":   res,
+	})
+}
+
+func (a *AuthControllerImpl) Logout(c *fiber.Ctx) error {
+	ctx := c.Context()
+	var req dto.LogoutReq
+
+	claims := middleware.GetClaims(c)
+
+	if len(c.Body()) > 0 {
+		if err := c.BodyParser(&req); err != nil {
+			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+				"status": "error",
+				"errors": err.Error(),
+			})
+		}
+	}
+
+	res, errService := a.TokenService.LogoutService(ctx, *claims, req)
+
+	if errService != nil {
+		return c.Status(errService.Code).JSON(fiber.Map{
+			"status": "error",
+			"errors": errService.Err.Error(),
+		})
+	}
+
+	return c.Status(fiber.StatusOK).JSON(fiber.Map{
+		"status":  "success",
+		"message": res,
+	})
+}
+
+func (a *AuthControllerImpl) LogoutAll(c *fiber.Ctx) error {
+	ctx := c.Context()
+
+	claims := middleware.GetClaims(c)
+
+	res, errService := a.TokenService.LogoutAllService(ctx, claims.ID)
+
+	if errService != nil {
+		return c.Status(errService.Code).JSON(fiber.Map{
+			"status": "error",
+			"errors": errService.Err.Error(),
+		})
+	}
+
+	return c.Status(fiber.StatusOK).JSON(fiber.Map{
+		"status":  "success",
+		"message": res,
+	})
+}
+
+func (a *AuthControllerImpl) GetSessions(c *fiber.Ctx) error {
+	ctx := c.Context()
+
+	claims := middleware.GetClaims(c)
+
+	res, errService := a.TokenService.GetSessionsService(ctx, claims.ID, claims.SessionID)
+
+	if errService != nil {
+		return c.Status(errService.Code).JSON(fiber.Map{
+			"status": "error",
+			"errors": errService.Err.Error(),
+		})
+	}
+
+	return c.Status(fiber.StatusOK).JSON(fiber.Map{
+		"status": "success",
+		"data":   res,
+	})
+}
+
+func (a *AuthControllerImpl) RevokeSession(c *fiber.Ctx) error {
+	ctx := c.Context()
+
+	claims := middleware.GetClaims(c)
+
+	res, errService := a.TokenService.RevokeSessionService(ctx, claims.ID, c.Params("id"))
+
+	if errService != nil {
+		return c.Status(errService.Code).JSON(fiber.Map{
+			"status": "error",
+			"errors": errService.Err.Error(),
+		})
+	}
+
+	return c.Status(fiber.StatusOK).JSON(fiber.Map{
+		"status":  "success",
+		"message": res,
+	})
+}
+
+func (a *AuthControllerImpl) SendResetPasswordLink(c *fiber.Ctx) error {
+	var email dto.ForgotPasswordReq
+	ctx := c.Context()
+
+	if err := c.BodyParser(&email); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"status": "error",
+			"errors": err.Error(),
+		})
+	}
+
+	email.Locale = c.Get(fiber.HeaderAcceptLanguage)
+
+	res, err := a.AuthService.SendResetPasswordService(ctx, email)
+
+	var tooManyAttempts *helper.TooManyAttemptsError
+	if err != nil && errors.As(err.Err, &tooManyAttempts) {
+		c.Set(fiber.HeaderRetryAfter, strconv.Itoa(tooManyAttempts.Seconds()))
+	}
+
+	if err != nil {
+		return c.Status(err.Code).JSON(fiber.Map{
+			"status": "error",
+			"errors": err.Err.Error(),
+		})
+	}
+
+	return c.Status(fiber.StatusOK).JSON(fiber.Map{
+		"status":  "success",
+		"message": res,
+	})
+}
+
+func (a *AuthControllerImpl) ResetPassword(c *fiber.Ctx) error {
+	code := c.Params("code")
+	var rp dto.ResetPasswordReq
+	ctx := c.Context()
+
+	if err := c.BodyParser(&rp); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"status": "error",
+			"errors": err.Error(),
+		})
+	}
+
+	_, errService := a.AuthService.ResetPassword(ctx, rp, code)
+
+	if errService != nil && errService.ValidationErrors != nil {
+		return c.Status(errService.Code).JSON(fiber.Map{
+			"status": "error",
+			"errors": errService.ValidationErrors,
+		})
+	}
+
+	if errService != nil && errService.Err != nil {
+		return c.Status(errService.Code).JSON(fiber.Map{
+			"status": "error",
+			"errors": errService.Err.Error(),
+		})
+	}
+
+	return c.Status(fiber.StatusOK).JSON(fiber.Map{
+		"status":  "success",
+		"message": "Berhasil melakukan reset password!",
+	})
+}
+
+func (a *AuthControllerImpl) SendResetPasswordOTP(c *fiber.Ctx) error {
+	var data dto.ForgotPasswordOTPReq
+	ctx := c.Context()
+
+	if err := c.BodyParser(&data); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"status": "error",
+			"errors": err.Error(),
+		})
+	}
+
+	res, err := a.AuthService.SendResetOTPService(ctx, data)
+
+	var tooManyAttempts *helper.TooManyAttemptsError
+	if err != nil && errors.As(err.Err, &tooManyAttempts) {
+		c.Set(fiber.HeaderRetryAfter, strconv.Itoa(tooManyAttempts.Seconds()))
+	}
+
+	if err != nil && err.ValidationErrors != nil {
+		return c.Status(err.Code).JSON(fiber.Map{
+			"status": "error",
+			"errors": err.ValidationErrors,
+		})
+	}
+
+	if err != nil {
+		return c.Status(err.Code).JSON(fiber.Map{
+			"status": "error",
+			"errors": err.Err.Error(),
+		})
+	}
+
+	return c.Status(fiber.StatusOK).JSON(fiber.Map{
+		"status":  "success",
+		"message": res,
+	})
+}
+
+func (a *AuthControllerImpl) ResetPasswordWithOTP(c *fiber.Ctx) error {
+	var data dto.ResetPasswordOTPReq
+	ctx := c.Context()
+
+	if err := c.BodyParser(&data); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"status": "error",
+			"errors": err.Error(),
+		})
+	}
+
+	_, errService := a.AuthService.ResetPasswordWithOTPService(ctx, data)
+
+	if errService != nil && errService.ValidationErrors != nil {
+		return c.Status(errService.Code).JSON(fiber.Map{
+			"status": "error",
+			"errors": errService.ValidationErrors,
+		})
+	}
+
+	if errService != nil && errService.Err != nil {
+		return c.Status(errService.Code).JSON(fiber.Map{
+			"status": "error",
+			"errors": errService.Err.Error(),
+		})
+	}
+
+	return c.Status(fiber.StatusOK).JSON(fiber.Map{
+		"status":  "success",
+		"message": "Berhasil melakukan reset password!",
+	})
+}
+
+func (a *AuthControllerImpl) ResetPasswordUI(c *fiber.Ctx) error {
+	return c.SendFile("./views/reset_password.html")
+}
+
+func (a *AuthControllerImpl) DeleteAccount(c *fiber.Ctx) error {
+	ctx := c.Context()
+	var req dto.DeleteAccountReq
+
+	claims := middleware.GetClaims(c)
+
+	if err := c.BodyParser(&req); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"status": "error",
+			"errors": err.Error(),
+		})
+	}
+
+	res, errService := a.AuthService.DeleteAccountService(ctx, claims.ID, req)
+
+	if errService != nil && errService.ValidationErrors != nil {
+		return c.Status(errService.Code).JSON(fiber.Map{
+			"status": "error",
+			"errors": errService.ValidationErrors,
+		})
+	}
+
+	if errService != nil && errService.Err != nil {
+		return c.Status(errService.Code).JSON(fiber.Map{
+			"status": "error",
+			"errors": errService.Err.Error(),
+		})
+	}
+
+	return c.Status(fiber.StatusAccepted).JSON(fiber.Map{
+		"status":  "success",
+		"message": "Akun anda dijadwalkan untuk dihapus. Login dan batalkan sebelum tanggal penghapusan jika berubah pikiran.",
+		"data": fiber.Map{
+			"purge_at": res,
+		},
+	})
+}
+
+func (a *AuthControllerImpl) CancelDeletion(c *fiber.Ctx) error {
+	ctx := c.Context()
+
+	claims := middleware.GetClaims(c)
+
+	res, errService := a.AuthService.CancelDeletionService(ctx, claims.ID)
+
+	if errService != nil {
+		return c.Status(errService.Code).JSON(fiber.Map{
+			"status": "error",
+			"errors": errService.Err.Error(),
+		})
+	}
+
+	return c.Status(fiber.StatusOK).JSON(fiber.Map{
+		"status":  "success",
+		"message": res,
+	})
+}
+
+func (a *AuthControllerImpl) VerifyEmail(c *fiber.Ctx) error {
+	ctx := c.Context()
+
+	res, errService := a.AuthService.VerifyEmailService(ctx, c.Params("token"))
+
+	if errService != nil {
+		return c.Status(errService.Code).JSON(fiber.Map{
+			"status": "error",
+			"errors": errService.Err.Error(),
+		})
+	}
+
+	return c.Status(fiber.StatusOK).JSON(fiber.Map{
+		"status":  "success",
+		"message": res,
+	})
+}
+
+func (a *AuthControllerImpl) ResendVerification(c *fiber.Ctx) error {
+	ctx := c.Context()
+	var req dto.ResendVerificationReq
+
+	if err := c.BodyParser(&req); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"status": "error",
+			"errors": err.Error(),
+		})
+	}
+
+	req.Locale = c.Get(fiber.HeaderAcceptLanguage)
+
+	res, errService := a.AuthService.ResendVerificationService(ctx, req)
+
+	var tooManyAttempts *helper.TooManyAttemptsError
+	if errService != nil && errors.As(errService.Err, &tooManyAttempts) {
+		c.Set(fiber.HeaderRetryAfter, strconv.Itoa(tooManyAttempts.Seconds()))
+	}
+
+	if errService != nil && errService.ValidationErrors != nil {
+		return c.Status(errService.Code).JSON(fiber.Map{
+			"status": "error",
+			"errors": errService.ValidationErrors,
+		})
+	}
+
+	if errService != nil && errService.Err != nil {
+		return c.Status(errService.Code).JSON(fiber.Map{
+			"status": "error",
+			"errors": errService.Err.Error(),
+		})
+	}
+
+	return c.Status(fiber.StatusOK).JSON(fiber.Map{
+		"status":  "success",
+		"message": res,
+	})
+}
+
+func (a *AuthControllerImpl) JWKS(c *fiber.Ctx) error {
+	c.Set(fiber.HeaderCacheControl, "public, max-age=300")
+	return c.Status(fiber.StatusOK).JSON(helper.JWKS())
 }
 
-func NewAuthController(authService service.AuthService) AuthController {
+func NewAuthController(authService service.AuthService, tokenService service.TokenService, twoFactorService service.TwoFactorService) AuthController {
 	return &AuthControllerImpl{
-		AuthService: authService,
+		AuthService:      authService,
+		TokenService:     tokenService,
+		TwoFactorService: twoFactorService,
 	}
 }
@@ -0,0 +1,186 @@
+package controller
+
+import (
+	"bytes"
+	"context"
+	"mime/multipart"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/GabrielMoody/mikronet-auth-service/internal/dto"
+	"github.com/GabrielMoody/mikronet-auth-service/internal/helper"
+	"github.com/GabrielMoody/mikronet-auth-service/internal/service"
+	"github.com/gofiber/fiber/v2"
+)
+
+// fakeAuthService records the registration it receives; any other method
+// panics through the nil embedded interface.
+type fakeAuthService struct {
+	service.AuthService
+	gov    *dto.GovRegistrationReq
+	driver *dto.DriverRegistrationsReq
+	pp     []byte
+	sim    []byte
+}
+
+func (f *fakeAuthService) CreateDriverService(c context.Context, data dto.DriverRegistrationsReq, role string, pp []byte, ktp []byte, sim []byte) (res string, err *helper.ErrorStruct) {
+	f.driver = &data
+	f.pp = pp
+	f.sim = sim
+
+	return "driver-1", nil
+}
+
+func (f *fakeAuthService) CreateGovService(c context.Context, data dto.GovRegistrationReq, role string, pp []byte) (res string, err *helper.ErrorStruct) {
+	f.gov = &data
+	f.pp = pp
+
+	return "gov-1", nil
+}
+
+func multipartBody(t *testing.T, fields map[string]string, files map[string][]byte) (*bytes.Buffer, string) {
+	t.Helper()
+
+	body := &bytes.Buffer{}
+	w := multipart.NewWriter(body)
+
+	for k, v := range fields {
+		w.WriteField(k, v)
+	}
+
+	for k, v := range files {
+		part, err := w.CreateFormFile(k, k+".jpg")
+		if err != nil {
+			t.Fatalf("error while writing %s: %v", k, err)
+		}
+		part.Write(v)
+	}
+
+	w.Close()
+
+	return body, w.FormDataContentType()
+}
+
+func TestCreateGov(t *testing.T) {
+	fields := map[string]string{
+		"name":                  "Officer",
+		"email":                 "officer@example.com",
+		"phone_number":          "081234567890",
+		"nip":                   "199001012015031001",
+		"password":              "password123",
+		"password_confirmation": "password123",
+	}
+
+	tests := []struct {
+		name     string
+		files    map[string][]byte
+		wantCode int
+	}{
+		{name: "with profile picture", files: map[string][]byte{"profile_picture": []byte("image")}, wantCode: fiber.StatusCreated},
+		{name: "without profile picture", wantCode: fiber.StatusBadRequest},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			fake := &fakeAuthService{}
+			app := fiber.New()
+			app.Post("/register/gov", NewAuthController(fake, nil, nil).CreateGov)
+
+			body, contentType := multipartBody(t, fields, tt.files)
+			req := httptest.NewRequest("POST", "/register/gov", body)
+			req.Header.Set("Content-Type", contentType)
+
+			resp, err := app.Test(req)
+			if err != nil {
+				t.Fatalf("request failed: %v", err)
+			}
+
+			if resp.StatusCode != tt.wantCode {
+				t.Fatalf("status = %d, want %d", resp.StatusCode, tt.wantCode)
+			}
+
+			if tt.wantCode != fiber.StatusCreated {
+				if fake.gov != nil {
+					t.Fatal("service must not be called without a profile picture")
+				}
+				return
+			}
+
+			if fake.gov == nil || fake.gov.NIP != fields["nip"] || string(fake.pp) != "image" {
+				t.Fatalf("service got %+v with picture %q", fake.gov, fake.pp)
+			}
+		})
+	}
+}
+
+func TestCreateDriver(t *testing.T) {
+	fields := map[string]string{
+		"name":                  "Driver",
+		"email":                 "driver@example.com",
+		"sim":                   "1234-5678-901234",
+		"password":              "password123",
+		"password_confirmation": "password123",
+	}
+
+	tests := []struct {
+		name     string
+		files    map[string][]byte
+		wantCode int
+		wantSIM  string
+	}{
+		{
+			name:     "with sim document",
+			files:    map[string][]byte{"profile_picture": []byte("image"), "ktp": []byte("ktp"), "sim_document": []byte("scan")},
+			wantCode: fiber.StatusCreated,
+			wantSIM:  "scan",
+		},
+		{
+			name:     "without sim document",
+			files:    map[string][]byte{"profile_picture": []byte("image"), "ktp": []byte("ktp")},
+			wantCode: fiber.StatusCreated,
+		},
+		{
+			name:     "without profile picture",
+			files:    map[string][]byte{"ktp": []byte("ktp")},
+			wantCode: fiber.StatusBadRequest,
+		},
+		{
+			name:     "without ktp",
+			files:    map[string][]byte{"profile_picture": []byte("image")},
+			wantCode: fiber.StatusBadRequest,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			fake := &fakeAuthService{}
+			app := fiber.New()
+			app.Post("/register/driver", NewAuthController(fake, nil, nil).CreateDriver)
+
+			body, contentType := multipartBody(t, fields, tt.files)
+			req := httptest.NewRequest("POST", "/register/driver", body)
+			req.Header.Set("Content-Type", contentType)
+
+			resp, err := app.Test(req)
+			if err != nil {
+				t.Fatalf("request failed: %v", err)
+			}
+
+			if resp.StatusCode != tt.wantCode {
+				t.Fatalf("status = %d, want %d", resp.StatusCode, tt.wantCode)
+			}
+
+			if tt.wantCode != fiber.StatusCreated {
+				if fake.driver != nil {
+					t.Fatal("service must not be called without both images")
+				}
+				return
+			}
+
+			// The SIM number and the SIM scan arrive under separate names.
+			if fake.driver == nil || fake.driver.SIM != fields["sim"] || string(fake.sim) != tt.wantSIM {
+				t.Fatalf("service got %+v with sim document %q, want %q", fake.driver, fake.sim, tt.wantSIM)
+			}
+		})
+	}
+}
@@ -0,0 +1,280 @@
+package controller
+
+import (
+	"encoding/base64"
+	"errors"
+	"net/url"
+	"strings"
+
+	"github.com/GabrielMoody/mikronet-auth-service/internal/dto"
+	"github.com/GabrielMoody/mikronet-auth-service/internal/helper"
+	"github.com/GabrielMoody/mikronet-auth-service/internal/middleware"
+	"github.com/GabrielMoody/mikronet-auth-service/internal/service"
+	"github.com/gofiber/fiber/v2"
+)
+
+type OAuthController interface {
+	AuthorizeUI(c *fiber.Ctx) error
+	Authorize(c *fiber.Ctx) error
+	Token(c *fiber.Ctx) error
+	UserInfo(c *fiber.Ctx) error
+	Discovery(c *fiber.Ctx) error
+	CreateClient(c *fiber.Ctx) error
+	GetClients(c *fiber.Ctx) error
+	DeleteClient(c *fiber.Ctx) error
+}
+
+type OAuthControllerImpl struct {
+	OAuthService service.OAuthService
+}
+
+// oauthErrorCode maps service errors to the error codes of RFC 6749.
+func oauthErrorCode(err error) string {
+	switch {
+	case errors.Is(err, helper.ErrInvalidClient):
+		return "invalid_client"
+	case errors.Is(err, helper.ErrInvalidGrant):
+		return "invalid_grant"
+	case errors.Is(err, helper.ErrInvalidScope):
+		return "invalid_scope"
+	case errors.Is(err, helper.ErrUnsupportedGrantType):
+		return "unsupported_grant_type"
+	case errors.Is(err, helper.ErrInvalidRequest), errors.Is(err, helper.ErrBadRequest):
+		return "invalid_request"
+	default:
+		return "server_error"
+	}
+}
+
+func oauthError(c *fiber.Ctx, errService *helper.ErrorStruct) error {
+	description := "invalid request"
+	if errService.Err != nil {
+		description = errService.Err.Error()
+	}
+
+	return c.Status(errService.Code).JSON(fiber.Map{
+		"error":             oauthErrorCode(errService.Err),
+		"error_description": description,
+	})
+}
+
+// clientCredentials reads client_secret_basic first and falls back to the
+// form fields used by client_secret_post and public clients.
+func clientCredentials(c *fiber.Ctx, data *dto.OAuthTokenReq) {
+	auth := c.Get(fiber.HeaderAuthorization)
+
+	if !strings.HasPrefix(auth, "Basic ") {
+		return
+	}
+
+	raw, err := base64.StdEncoding.DecodeString(auth[6:])
+	if err != nil {
+		return
+	}
+
+	id, secret, ok := strings.Cut(string(raw), ":")
+	if !ok {
+		return
+	}
+
+	// RFC 6749 form-encodes both values before joining them.
+	if v, err := url.QueryUnescape(id); err == nil {
+		data.ClientID = v
+	}
+	if v, err := url.QueryUnescape(secret); err == nil {
+		data.ClientSecret = v
+	}
+}
+
+func (a *OAuthControllerImpl) AuthorizeUI(c *fiber.Ctx) error {
+	ctx := c.Context()
+	var data dto.AuthorizeReq
+
+	if err := c.QueryParser(&data); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"status": "error",
+			"errors": err.Error(),
+		})
+	}
+
+	redirectURI, errService := a.OAuthService.ValidateAuthorizeService(ctx, data)
+
+	if errService != nil && redirectURI == "" {
+		return c.Status(errService.Code).JSON(fiber.Map{
+			"status": "error",
+			"errors": errService.Err.Error(),
+		})
+	}
+
+	if errService != nil {
+		q := url.Values{}
+		q.Set("error", oauthErrorCode(errService.Err))
+		q.Set("error_description", errService.Err.Error())
+		if data.State != "" {
+			q.Set("state", data.State)
+		}
+
+		sep := "?"
+		if strings.Contains(redirectURI, "?") {
+			sep = "&"
+		}
+
+		return c.Redirect(redirectURI+sep+q.Encode(), fiber.StatusFound)
+	}
+
+	return c.SendFile("./views/authorize.html")
+}
+
+func (a *OAuthControllerImpl) Authorize(c *fiber.Ctx) error {
+	ctx := c.Context()
+	var data dto.AuthorizeReq
+
+	claims := middleware.GetClaims(c)
+
+	if err := c.BodyParser(&data); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"status": "error",
+			"errors": err.Error(),
+		})
+	}
+
+	res, errService := a.OAuthService.AuthorizeService(ctx, claims.ID, data)
+
+	if errService != nil {
+		return c.Status(errService.Code).JSON(fiber.Map{
+			"status": "error",
+			"errors": errService.Err.Error(),
+		})
+	}
+
+	return c.Status(fiber.StatusOK).JSON(fiber.Map{
+		"status": "success",
+		"data": fiber.Map{
+			"redirect_to": res,
+		},
+	})
+}
+
+func (a *OAuthControllerImpl) Token(c *fiber.Ctx) error {
+	ctx := c.Context()
+	var data dto.OAuthTokenReq
+
+	if err := c.BodyParser(&data); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error":             "invalid_request",
+			"error_description": err.Error(),
+		})
+	}
+
+	clientCredentials(c, &data)
+
+	c.Set(fiber.HeaderCacheControl, "no-store")
+
+	res, errService := a.OAuthService.ExchangeTokenService(ctx, data, deviceInfo(c))
+
+	if errService != nil {
+		if errService.Code == fiber.StatusUnauthorized {
+			c.Set(fiber.HeaderWWWAuthenticate, `Basic realm="oauth"`)
+		}
+		return oauthError(c, errService)
+	}
+
+	return c.Status(fiber.StatusOK).JSON(res)
+}
+
+func (a *OAuthControllerImpl) UserInfo(c *fiber.Ctx) error {
+	ctx := c.Context()
+
+	claims := middleware.GetClaims(c)
+
+	res, errService := a.OAuthService.UserInfoService(ctx, claims.ID)
+
+	if errService != nil {
+		return oauthError(c, errService)
+	}
+
+	return c.Status(fiber.StatusOK).JSON(res)
+}
+
+func (a *OAuthControllerImpl) Discovery(c *fiber.Ctx) error {
+	return c.Status(fiber.StatusOK).JSON(a.OAuthService.DiscoveryService())
+}
+
+func (a *OAuthControllerImpl) CreateClient(c *fiber.Ctx) error {
+	ctx := c.Context()
+	var data dto.OAuthClientReq
+
+	claims := middleware.GetClaims(c)
+
+	if err := c.BodyParser(&data); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"status": "error",
+			"errors": err.Error(),
+		})
+	}
+
+	res, errService := a.OAuthService.CreateClientService(ctx, data, claims.ID)
+
+	if errService != nil && errService.ValidationErrors != nil {
+		return c.Status(errService.Code).JSON(fiber.Map{
+			"status": "error",
+			"errors": errService.ValidationErrors,
+		})
+	}
+
+	if errService != nil && errService.Err != nil {
+		return c.Status(errService.Code).JSON(fiber.Map{
+			"status": "error",
+			"errors": errService.Err.Error(),
+		})
+	}
+
+	return c.Status(fiber.StatusCreated).JSON(fiber.Map{
+		"status": "success",
+		"data":   res,
+	})
+}
+
+func (a *OAuthControllerImpl) GetClients(c *fiber.Ctx) error {
+	ctx := c.Context()
+
+	res, errService := a.OAuthService.GetClientsService(ctx)
+
+	if errService != nil {
+		return c.Status(errService.Code).JSON(fiber.Map{
+			"status": "error",
+			"errors": errService.Err.Error(),
+		})
+	}
+
+	return c.Status(fiber.StatusOK).JSON(fiber.Map{
+		"status": "success",
+		"data":   res,
+	})
+}
+
+func (a *OAuthControllerImpl) DeleteClient(c *fiber.Ctx) error {
+	ctx := c.Context()
+
+	claims := middleware.GetClaims(c)
+
+	res, errService := a.OAuthService.DeleteClientService(ctx, c.Params("id"), claims.ID)
+
+	if errService != nil {
+		return c.Status(errService.Code).JSON(fiber.Map{
+			"status": "error",
+			"errors": errService.Err.Error(),
+		})
+	}
+
+	return c.Status(fiber.StatusOK).JSON(fiber.Map{
+		"status":  "success",
+		"message": res,
+	})
+}
+
+func NewOAuthController(oauthService service.OAuthService) OAuthController {
+	return &OAuthControllerImpl{
+		OAuthService: oauthService,
+	}
+}
@@ -0,0 +1,118 @@
+package controller
+
+import (
+	"github.com/GabrielMoody/mikronet-auth-service/internal/dto"
+	"github.com/GabrielMoody/mikronet-auth-service/internal/middleware"
+	"github.com/GabrielMoody/mikronet-auth-service/internal/service"
+	"github.com/gofiber/fiber/v2"
+)
+
+type ServiceAccountController interface {
+	CreateServiceAccount(c *fiber.Ctx) error
+	GetServiceAccounts(c *fiber.Ctx) error
+	RotateSecret(c *fiber.Ctx) error
+	DeleteServiceAccount(c *fiber.Ctx) error
+}
+
+type ServiceAccountControllerImpl struct {
+	ServiceAccountService service.ServiceAccountService
+}
+
+func (a *ServiceAccountControllerImpl) CreateServiceAccount(c *fiber.Ctx) error {
+	ctx := c.Context()
+	var data dto.ServiceAccountReq
+
+	claims := middleware.GetClaims(c)
+
+	if err := c.BodyParser(&data); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"status": "error",
+			"errors": err.Error(),
+		})
+	}
+
+	res, errService := a.ServiceAccountService.CreateServiceAccountService(ctx, data, claims.ID)
+
+	if errService != nil && errService.ValidationErrors != nil {
+		return c.Status(errService.Code).JSON(fiber.Map{
+			"status": "error",
+			"errors": errService.ValidationErrors,
+		})
+	}
+
+	if errService != nil && errService.Err != nil {
+		return c.Status(errService.Code).JSON(fiber.Map{
+			"status": "error",
+			"errors": errService.Err.Error(),
+		})
+	}
+
+	return c.Status(fiber.StatusCreated).JSON(fiber.Map{
+		"status": "success",
+		"data":   res,
+	})
+}
+
+func (a *ServiceAccountControllerImpl) GetServiceAccounts(c *fiber.Ctx) error {
+	ctx := c.Context()
+
+	res, errService := a.ServiceAccountService.GetServiceAccountsService(ctx)
+
+	if errService != nil {
+		return c.Status(errService.Code).JSON(fiber.Map{
+			"status": "error",
+			"errors": errService.Err.Error(),
+		})
+	}
+
+	return c.Status(fiber.StatusOK).JSON(fiber.Map{
+		"status": "success",
+		"data":   res,
+	})
+}
+
+func (a *ServiceAccountControllerImpl) RotateSecret(c *fiber.Ctx) error {
+	ctx := c.Context()
+
+	claims := middleware.GetClaims(c)
+
+	res, errService := a.ServiceAccountService.RotateSecretService(ctx, c.Params("id"), claims.ID)
+
+	if errService != nil {
+		return c.Status(errService.Code).JSON(fiber.Map{
+			"status": "error",
+			"errors": errService.Err.Error(),
+		})
+	}
+
+	return c.Status(fiber.StatusOK).JSON(fiber.Map{
+		"status": "success",
+		"data":   res,
+	})
+}
+
+func (a *ServiceAccountControllerImpl) DeleteServiceAccount(c *fiber.Ctx) error {
+	ctx := c.Context()
+
+	claims := middleware.GetClaims(c)
+
+	res, errService := a.ServiceAccountService.DeleteServiceAccountService(ctx, c.Params("id"), claims.ID)
+
+	if errService != nil {
+		return c.Status(errService.Code).JSON(fiber.Map{
+			"status": "error",
+			"errors": errService.Err.Error(),
+		})
+	}
+
+	return c.Status(fiber.StatusOK).JSON(fiber.Map{
+		"status":  "success",
+		"message": res,
+	})
+}
+
+func NewServiceAccountController(serviceAccountService service.ServiceAccountService) ServiceAccountController {
+	return &ServiceAccountControllerImpl{
+		ServiceAccountService: serviceAccountService,
+	}
+}
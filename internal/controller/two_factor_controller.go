@@ -0,0 +1,187 @@
+package controller
+
+import (
+	"errors"
+	"strconv"
+
+	"github.com/GabrielMoody/mikronet-auth-service/internal/dto"
+	"github.com/GabrielMoody/mikronet-auth-service/internal/helper"
+	"github.com/GabrielMoody/mikronet-auth-service/internal/middleware"
+	"github.com/GabrielMoody/mikronet-auth-service/internal/service"
+	"github.com/gofiber/fiber/v2"
+)
+
+type TwoFactorController interface {
+	Enroll(c *fiber.Ctx) error
+	Confirm(c *fiber.Ctx) error
+	VerifyLogin(c *fiber.Ctx) error
+	SetPolicy(c *fiber.Ctx) error
+	GetPolicies(c *fiber.Ctx) error
+}
+
+type TwoFactorControllerImpl struct {
+	TwoFactorService service.TwoFactorService
+	TokenService     service.TokenService
+}
+
+func (a *TwoFactorControllerImpl) Enroll(c *fiber.Ctx) error {
+	ctx := c.Context()
+
+	claims := middleware.GetClaims(c)
+
+	res, errService := a.TwoFactorService.EnrollService(ctx, claims.ID, claims.Email)
+
+	if errService != nil {
+		return c.Status(errService.Code).JSON(fiber.Map{
+			"status": "error",
+			"errors": errService.Err.Error(),
+		})
+	}
+
+	return c.Status(fiber.StatusOK).JSON(fiber.Map{
+		"status": "success",
+		"data":   res,
+	})
+}
+
+func (a *TwoFactorControllerImpl) Confirm(c *fiber.Ctx) error {
+	ctx := c.Context()
+	var data dto.TwoFactorConfirmReq
+
+	claims := middleware.GetClaims(c)
+
+	if err := c.BodyParser(&data); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"status": "error",
+			"errors": err.Error(),
+		})
+	}
+
+	res, errService := a.TwoFactorService.ConfirmService(ctx, claims.ID, data)
+
+	if errService != nil && errService.ValidationErrors != nil {
+		return c.Status(errService.Code).JSON(fiber.Map{
+			"status": "error",
+			"errors": errService.ValidationErrors,
+		})
+	}
+
+	if errService != nil && errService.Err != nil {
+		return c.Status(errService.Code).JSON(fiber.Map{
+			"status": "error",
+			"errors": errService.Err.Error(),
+		})
+	}
+
+	return c.Status(fiber.StatusOK).JSON(fiber.Map{
+		"status": "success",
+		"data":   res,
+	})
+}
+
+func (a *TwoFactorControllerImpl) VerifyLogin(c *fiber.Ctx) error {
+	ctx := c.Context()
+	var data dto.TwoFactorLoginReq
+
+	if err := c.BodyParser(&data); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"status": "error",
+			"errors": err.Error(),
+		})
+	}
+
+	res, errService := a.TwoFactorService.VerifyLoginService(ctx, data)
+
+	var tooManyAttempts *helper.TooManyAttemptsError
+	if errService != nil && errors.As(errService.Err, &tooManyAttempts) {
+		c.Set(fiber.HeaderRetryAfter, strconv.Itoa(tooManyAttempts.Seconds()))
+	}
+
+	if errService != nil && errService.ValidationErrors != nil {
+		return c.Status(errService.Code).JSON(fiber.Map{
+			"status": "error",
+			"errors": errService.ValidationErrors,
+		})
+	}
+
+	if errService != nil && errService.Err != nil {
+		return c.Status(errService.Code).JSON(fiber.Map{
+			"status": "error",
+			"errors": errService.Err.Error(),
+		})
+	}
+
+	tokens, errToken := a.TokenService.IssueTokenService(ctx, res, deviceInfo(c))
+
+	if errToken != nil {
+		return c.Status(errToken.Code).JSON(fiber.Map{
+			"status": "error",
+			"errors": errToken.Err.Error(),
+		})
+	}
+
+	return c.Status(fiber.StatusOK).JSON(fiber.Map{
+		"status": "success",
+		"data":   tokens,
+	})
+}
+
+func (a *TwoFactorControllerImpl) SetPolicy(c *fiber.Ctx) error {
+	ctx := c.Context()
+	var data dto.TwoFactorPolicyReq
+
+	claims := middleware.GetClaims(c)
+
+	if err := c.BodyParser(&data); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"status": "error",
+			"errors": err.Error(),
+		})
+	}
+
+	res, errService := a.TwoFactorService.SetPolicyService(ctx, c.Params("role"), data, claims.ID)
+
+	if errService != nil && errService.ValidationErrors != nil {
+		return c.Status(errService.Code).JSON(fiber.Map{
+			"status": "error",
+			"errors": errService.ValidationErrors,
+		})
+	}
+
+	if errService != nil && errService.Err != nil {
+		return c.Status(errService.Code).JSON(fiber.Map{
+			"status": "error",
+			"errors": errService.Err.Error(),
+		})
+	}
+
+	return c.Status(fiber.StatusOK).JSON(fiber.Map{
+		"status": "success",
+		"data":   res,
+	})
+}
+
+func (a *TwoFactorControllerImpl) GetPolicies(c *fiber.Ctx) error {
+	ctx := c.Context()
+
+	res, errService := a.TwoFactorService.GetPoliciesService(ctx)
+
+	if errService != nil {
+		return c.Status(errService.Code).JSON(fiber.Map{
+			"status": "error",
+			"errors": errService.Err.Error(),
+		})
+	}
+
+	return c.Status(fiber.StatusOK).JSON(fiber.Map{
+		"status": "success",
+		"data":   res,
+	})
+}
+
+func NewTwoFactorController(twoFactorService service.TwoFactorService, tokenService service.TokenService) TwoFactorController {
+	return &TwoFactorControllerImpl{
+		TwoFactorService: twoFactorService,
+		TokenService:     tokenService,
+	}
+}
@@ -1,5 +1,7 @@
 package dto
 
+import "time"
+
 type (
 	ChangePasswordReq struct {
 		OldPassword        string `json:"old_password" validate:"required"`
@@ -11,15 +13,18 @@ type (
 		Email                string `json:"email" validate:"required,email" form:"email"`
 		Password             string `json:"password" form:"password" validate:"required,min=8"`
 		PasswordConfirmation string `json:"password_confirmation" form:"password_confirmation" validate:"required,eqfield=Password"`
+		PhoneNumber          string `json:"phone_number" form:"phone_number" validate:"omitempty,phone"`
 		Name                 string `json:"name"`
 		DateOfBirth          string `json:"date_of_birth"`
 		Age                  int    `json:"age"`
+		Locale               string `json:"-" form:"-"`
 	}
 
 	DriverRegistrationsReq struct {
-		Name                 string `json:"name" form:"name" validate:"required"`
-		Email                string `json:"email" form:"email" validate:"required,email"`
-		PhoneNumber          string `json:"phone_number" form:"phone_number"`
+		Name        string `json:"name" form:"name" validate:"required"`
+		Email       string `json:"email" form:"email" validate:"required,email"`
+		PhoneNumber string `json:"phone_number" form:"phone_number" validate:"omitempty,phone"`
+		// The SIM number; a scan of the SIM is uploaded as sim_document.
 		SIM                  string `json:"sim" form:"sim"`
 		LicenseNumber        string `json:"license_number" form:"license_number"`
 		ProfilePicture       string `json:"profile_picture" form:"profile_picture"`
@@ -29,23 +34,22 @@ type (
 	}
 
 	OwnerRegistrationsReq struct {
-		Name                 string `json:"name"`
-		Email                string `json:"email" validate:"required,email"`
-		PhoneNumber          string `json:"phone_number"`
-		NIK                  string `json:"nik"`
+		Name                 string `json:"name" form:"name" validate:"required"`
+		Email                string `json:"email" form:"email" validate:"required,email"`
+		PhoneNumber          string `json:"phone_number" form:"phone_number" validate:"omitempty,phone"`
+		NIK                  string `json:"nik" form:"nik" validate:"required,nik"`
 		ProfilePicture       string `json:"profile_picture" form:"profile_picture"`
-		Password             string `json:"password" validate:"required,min=8"`
-		PasswordConfirmation string `json:"password_confirmation" validate:"required,eqfield=Password"`
+		Password             string `json:"password" form:"password" validate:"required,min=8"`
+		PasswordConfirmation string `json:"password_confirmation" form:"password_confirmation" validate:"required,eqfield=Password"`
 	}
 
 	GovRegistrationReq struct {
 		Name                 string `json:"name" form:"name" validate:"required"`
 		Email                string `json:"email" form:"email" validate:"required,email"`
-		PhoneNumber          string `json:"phone_number" form:"phone_number" validate:"required"`
-		NIP                  string `json:"nip" form:"nip" validate:"required"`
-		Password             string `json:"password" form:"password" validate:"required"`
+		PhoneNumber          string `json:"phone_number" form:"phone_number" validate:"required,phone"`
+		NIP                  string `json:"nip" form:"nip" validate:"required,nip"`
+		Password             string `json:"password" form:"password" validate:"required,min=8"`
 		PasswordConfirmation string `json:"password_confirmation" form:"password_confirmation" validate:"required,eqfield=Password"`
-		ProfilePicture       string `json:"profile_picture" form:"profile_picture" validate:"required"`
 	}
 
 	UserRegistrationsResp struct {
@@ -57,17 +61,311 @@ type (
 		Role        string `json:"role"`
 	}
 
+	UserStatusResp struct {
+		ID        string    `json:"id"`
+		Email     string    `json:"email"`
+		Role      string    `json:"role"`
+		Blocked   bool      `json:"blocked"`
+		Verified  bool      `json:"verified"`
+		CreatedAt time.Time `json:"created_at"`
+	}
+
+	PaginationResp struct {
+		Page  int   `json:"page"`
+		Limit int   `json:"limit"`
+		Total int64 `json:"total"`
+	}
+
+	DriverVerificationResp struct {
+		ID            string    `json:"id"`
+		Email         string    `json:"email"`
+		Name          string    `json:"name"`
+		PhoneNumber   string    `json:"phone_number"`
+		LicenseNumber string    `json:"license_number"`
+		SIMNumber     string    `json:"sim_number"`
+		CreatedAt     time.Time `json:"created_at"`
+	}
+
+	RejectDriverReq struct {
+		Reason string `json:"reason" validate:"required"`
+	}
+
+	RejectGovReq struct {
+		Reason string `json:"reason" validate:"required"`
+	}
+
+	BlockAccountReq struct {
+		Reason    string     `json:"reason" validate:"required"`
+		ExpiresAt *time.Time `json:"expires_at"`
+	}
+
+	BlockedAccountResp struct {
+		UserID    string     `json:"user_id"`
+		Email     string     `json:"email"`
+		Reason    string     `json:"reason"`
+		BlockedBy string     `json:"blocked_by"`
+		ExpiresAt *time.Time `json:"expires_at"`
+		CreatedAt time.Time  `json:"created_at"`
+	}
+
 	UserLoginReq struct {
 		Email    string `json:"email" validate:"required,email"`
 		Password string `json:"password" validate:"required"`
 	}
 
+	LoginOTPReq struct {
+		PhoneNumber string `json:"phone_number" validate:"required,phone"`
+	}
+
+	LoginOTPVerifyReq struct {
+		PhoneNumber string `json:"phone_number" validate:"required,phone"`
+		OTP         string `json:"otp" validate:"required,len=6,numeric"`
+	}
+
+	GoogleLoginReq struct {
+		IDToken string `json:"id_token" validate:"required"`
+		Locale  string `json:"-"`
+	}
+
+	LinkGoogleReq struct {
+		IDToken  string `json:"id_token" validate:"required"`
+		Password string `json:"password" validate:"required"`
+	}
+
+	UnlinkGoogleReq struct {
+		Password string `json:"password" validate:"required"`
+	}
+
+	TwoFactorLoginResp struct {
+		TwoFactorRequired bool   `json:"two_factor_required"`
+		ChallengeToken    string `json:"challenge_token,omitempty"`
+		SetupRequired     bool   `json:"two_factor_setup_required"`
+		SetupToken        string `json:"setup_token,omitempty"`
+	}
+
+	TwoFactorLoginReq struct {
+		ChallengeToken string `json:"challenge_token" validate:"required"`
+		Code           string `json:"code" validate:"required"`
+	}
+
+	TwoFactorEnrollResp struct {
+		Secret     string `json:"secret"`
+		OtpauthURI string `json:"otpauth_uri"`
+	}
+
+	TwoFactorConfirmReq struct {
+		Code string `json:"code" validate:"required,len=6,numeric"`
+	}
+
+	RecoveryCodesResp struct {
+		RecoveryCodes []string `json:"recovery_codes"`
+	}
+
+	TwoFactorPolicyReq struct {
+		Required *bool `json:"required" validate:"required"`
+	}
+
+	TwoFactorPolicyResp struct {
+		Role      string    `json:"role"`
+		Required  bool      `json:"required"`
+		UpdatedBy string    `json:"updated_by"`
+		UpdatedAt time.Time `json:"updated_at"`
+	}
+
+	// Accounts created through Google sign-in have no password and confirm
+	// with an ID token of the linked Google account instead.
+	DeleteAccountReq struct {
+		Password string `json:"password" validate:"required_without=IDToken"`
+		IDToken  string `json:"id_token" validate:"required_without=Password"`
+	}
+
+	ResendVerificationReq struct {
+		Email  string `json:"email" validate:"required,email"`
+		Locale string `json:"-"`
+	}
+
 	ForgotPasswordReq struct {
-		Email string `json:"email" validate:"required,email"`
+		Email  string `json:"email" validate:"required,email"`
+		Locale string `json:"-"`
+	}
+
+	RefreshTokenReq struct {
+		RefreshToken string `json:"refresh_token" validate:"required"`
+	}
+
+	LogoutReq struct {
+		RefreshToken string `json:"refresh_token"`
+	}
+
+	TokenResp struct {
+		AccessToken  string `json:"access_token"`
+		RefreshToken string `json:"refresh_token"`
+	}
+
+	// DeviceInfo describes where a login came from; it is read from the
+	// request headers, not the body.
+	DeviceInfo struct {
+		DeviceName string
+		UserAgent  string
+		IP         string
+		// OAuth client acting for the device and the scope it was granted;
+		// empty for the first-party apps.
+		ClientID string
+		Scope    string
+	}
+
+	SessionResp struct {
+		ID         string    `json:"id"`
+		DeviceName string    `json:"device_name"`
+		UserAgent  string    `json:"user_agent"`
+		IP         string    `json:"ip"`
+		LastSeenAt time.Time `json:"last_seen_at"`
+		CreatedAt  time.Time `json:"created_at"`
+		Current    bool      `json:"current"`
 	}
 
 	ResetPasswordReq struct {
 		Password             string `json:"password" validate:"required,min=8"`
 		PasswordConfirmation string `json:"password_confirmation" validate:"required,eqfield=Password"`
 	}
+
+	ForgotPasswordOTPReq struct {
+		PhoneNumber string `json:"phone_number" validate:"required,phone"`
+	}
+
+	ResetPasswordOTPReq struct {
+		PhoneNumber          string `json:"phone_number" validate:"required,phone"`
+		OTP                  string `json:"otp" validate:"required,len=6,numeric"`
+		Password             string `json:"password" validate:"required,min=8"`
+		PasswordConfirmation string `json:"password_confirmation" validate:"required,eqfield=Password"`
+	}
+
+	CreateAdminReq struct {
+		Email    string `json:"email" validate:"required,email"`
+		Name     string `json:"name" validate:"required,max=255"`
+		Password string `json:"password" validate:"required,min=8"`
+	}
+
+	InviteAdminReq struct {
+		Email  string `json:"email" validate:"required,email"`
+		Name   string `json:"name" validate:"required,max=255"`
+		Locale string `json:"-"`
+	}
+
+	// AdminUserQuery filters the admin user list. Search matches email, phone
+	// number and name; CreatedFrom and CreatedTo are RFC 3339 times.
+	AdminUserQuery struct {
+		Search      string `query:"search"`
+		Role        string `query:"role" validate:"omitempty,oneof=admin user driver owner government"`
+		CreatedFrom string `query:"created_from" validate:"omitempty,datetime=2006-01-02T15:04:05Z07:00"`
+		CreatedTo   string `query:"created_to" validate:"omitempty,datetime=2006-01-02T15:04:05Z07:00"`
+		// A leading "-" sorts descending.
+		Sort  string `query:"sort" validate:"omitempty,oneof=created_at -created_at email -email"`
+		Page  int    `query:"page"`
+		Limit int    `query:"limit"`
+	}
+
+	AdminUserResp struct {
+		ID          string    `json:"id"`
+		Email       string    `json:"email"`
+		PhoneNumber string    `json:"phone_number"`
+		Role        string    `json:"role"`
+		Name        string    `json:"name"`
+		CreatedAt   time.Time `json:"created_at"`
+	}
+
+	// AdminUserDetailResp carries the details of the user's current role only.
+	AdminUserDetailResp struct {
+		ID            string               `json:"id"`
+		Email         string               `json:"email"`
+		PhoneNumber   string               `json:"phone_number"`
+		Role          string               `json:"role"`
+		Locale        string               `json:"locale"`
+		EmailVerified bool                 `json:"email_verified"`
+		Blocked       bool                 `json:"blocked"`
+		CreatedAt     time.Time            `json:"created_at"`
+		UpdatedAt     time.Time            `json:"updated_at"`
+		Driver        *DriverDetailResp    `json:"driver,omitempty"`
+		Passenger     *PassengerDetailResp `json:"passenger,omitempty"`
+		Admin         *AdminDetailResp     `json:"admin,omitempty"`
+		Owner         *OwnerDetailResp     `json:"owner,omitempty"`
+		Government    *GovDetailResp       `json:"government,omitempty"`
+	}
+
+	DriverDetailResp struct {
+		Name           string `json:"name"`
+		PhoneNumber    string `json:"phone_number"`
+		LicenseNumber  string `json:"license_number"`
+		SIMNumber      string `json:"sim_number"`
+		RouteID        *uint  `json:"route_id"`
+		Status         string `json:"status"`
+		Verified       bool   `json:"verified"`
+		AvailableSeats int    `json:"available_seats"`
+	}
+
+	PassengerDetailResp struct {
+		Name        string    `json:"name"`
+		DateOfBirth time.Time `json:"date_of_birth"`
+		Age         int       `json:"age"`
+	}
+
+	AdminDetailResp struct {
+		Name string `json:"name"`
+	}
+
+	OwnerDetailResp struct {
+		Name        string `json:"name"`
+		PhoneNumber string `json:"phone_number"`
+		NIK         string `json:"nik"`
+	}
+
+	GovDetailResp struct {
+		Name        string     `json:"name"`
+		PhoneNumber string     `json:"phone_number"`
+		NIP         string     `json:"nip"`
+		Verified    bool       `json:"verified"`
+		VerifiedAt  *time.Time `json:"verified_at"`
+	}
+
+	// AdminUpdateUserReq changes only the fields that are set. Role specific
+	// fields apply to the role the user has after the update.
+	AdminUpdateUserReq struct {
+		Role          *string `json:"role" validate:"omitnil,oneof=admin user driver owner government"`
+		Name          *string `json:"name" validate:"omitnil,min=1,max=255"`
+		PhoneNumber   *string `json:"phone_number" validate:"omitnil,phone"`
+		LicenseNumber *string `json:"license_number" validate:"omitnil,max=255"`
+		NIK           *string `json:"nik" validate:"omitnil,nik"`
+		NIP           *string `json:"nip" validate:"omitnil,nip"`
+	}
+
+	// AuthEventQuery filters the audit log. From and To are RFC 3339 times;
+	// Cursor is the NextCursor of the previous page.
+	AuthEventQuery struct {
+		Type     string `query:"type"`
+		Outcome  string `query:"outcome" validate:"omitempty,oneof=success failure"`
+		ActorID  string `query:"actor_id"`
+		TargetID string `query:"target_id"`
+		From     string `query:"from" validate:"omitempty,datetime=2006-01-02T15:04:05Z07:00"`
+		To       string `query:"to" validate:"omitempty,datetime=2006-01-02T15:04:05Z07:00"`
+		Cursor   uint64 `query:"cursor"`
+		Limit    int    `query:"limit"`
+	}
+
+	AuthEventResp struct {
+		ID         uint64    `json:"id"`
+		Type       string    `json:"type"`
+		Outcome    string    `json:"outcome"`
+		ActorID    string    `json:"actor_id"`
+		TargetID   string    `json:"target_id"`
+		Identifier string    `json:"identifier"`
+		IP         string    `json:"ip"`
+		UserAgent  string    `json:"user_agent"`
+		Reason     string    `json:"reason"`
+		CreatedAt  time.Time `json:"created_at"`
+	}
+
+	CursorResp struct {
+		Limit      int    `json:"limit"`
+		NextCursor uint64 `json:"next_cursor,omitempty"`
+	}
 )
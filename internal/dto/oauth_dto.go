@@ -0,0 +1,95 @@
+package dto
+
+import "time"
+
+type (
+	OAuthClientReq struct {
+		Name         string   `json:"name" validate:"required"`
+		RedirectURIs []string `json:"redirect_uris" validate:"required,min=1,dive,url"`
+		Scopes       []string `json:"scopes" validate:"dive,oneof=openid profile email phone"`
+		Public       bool     `json:"public"`
+	}
+
+	OAuthClientResp struct {
+		ClientID     string    `json:"client_id"`
+		ClientSecret string    `json:"client_secret,omitempty"`
+		Name         string    `json:"name"`
+		RedirectURIs []string  `json:"redirect_uris"`
+		Scopes       []string  `json:"scopes"`
+		Public       bool      `json:"public"`
+		CreatedAt    time.Time `json:"created_at"`
+	}
+
+	AuthorizeReq struct {
+		ResponseType        string `json:"response_type" query:"response_type"`
+		ClientID            string `json:"client_id" query:"client_id"`
+		RedirectURI         string `json:"redirect_uri" query:"redirect_uri"`
+		Scope               string `json:"scope" query:"scope"`
+		State               string `json:"state" query:"state"`
+		Nonce               string `json:"nonce" query:"nonce"`
+		CodeChallenge       string `json:"code_challenge" query:"code_challenge"`
+		CodeChallengeMethod string `json:"code_challenge_method" query:"code_challenge_method"`
+	}
+
+	// OAuthTokenReq is form encoded, as RFC 6749 requires for the token endpoint.
+	OAuthTokenReq struct {
+		GrantType    string `form:"grant_type"`
+		Code         string `form:"code"`
+		RedirectURI  string `form:"redirect_uri"`
+		CodeVerifier string `form:"code_verifier"`
+		RefreshToken string `form:"refresh_token"`
+		ClientID     string `form:"client_id"`
+		ClientSecret string `form:"client_secret"`
+		Scope        string `form:"scope"`
+	}
+
+	OAuthTokenResp struct {
+		AccessToken  string `json:"access_token"`
+		TokenType    string `json:"token_type"`
+		ExpiresIn    int    `json:"expires_in"`
+		RefreshToken string `json:"refresh_token,omitempty"`
+		IDToken      string `json:"id_token,omitempty"`
+		Scope        string `json:"scope,omitempty"`
+	}
+
+	UserInfoResp struct {
+		Sub           string `json:"sub"`
+		Email         string `json:"email,omitempty"`
+		EmailVerified bool   `json:"email_verified"`
+		PhoneNumber   string `json:"phone_number,omitempty"`
+		Role          string `json:"role"`
+	}
+
+	OIDCDiscoveryResp struct {
+		Issuer                            string   `json:"issuer"`
+		AuthorizationEndpoint             string   `json:"authorization_endpoint"`
+		TokenEndpoint                     string   `json:"token_endpoint"`
+		UserinfoEndpoint                  string   `json:"userinfo_endpoint"`
+		JWKSURI                           string   `json:"jwks_uri"`
+		ResponseTypesSupported            []string `json:"response_types_supported"`
+		GrantTypesSupported               []string `json:"grant_types_supported"`
+		SubjectTypesSupported             []string `json:"subject_types_supported"`
+		IDTokenSigningAlgValuesSupported  []string `json:"id_token_signing_alg_values_supported"`
+		ScopesSupported                   []string `json:"scopes_supported"`
+		TokenEndpointAuthMethodsSupported []string `json:"token_endpoint_auth_methods_supported"`
+		CodeChallengeMethodsSupported     []string `json:"code_challenge_methods_supported"`
+		ClaimsSupported                   []string `json:"claims_supported"`
+	}
+)
+
+type (
+	ServiceAccountReq struct {
+		Name   string   `json:"name" validate:"required"`
+		Scopes []string `json:"scopes" validate:"required,min=1,dive,scope"`
+	}
+
+	ServiceAccountResp struct {
+		ClientID        string    `json:"client_id"`
+		ClientSecret    string    `json:"client_secret,omitempty"`
+		Name            string    `json:"name"`
+		Scopes          []string  `json:"scopes"`
+		CreatedBy       string    `json:"created_by"`
+		SecretRotatedAt time.Time `json:"secret_rotated_at"`
+		CreatedAt       time.Time `json:"created_at"`
+	}
+)
@@ -0,0 +1,224 @@
+package google
+
+import (
+	"context"
+	"crypto"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"os"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/GabrielMoody/mikronet-auth-service/internal/helper"
+	"github.com/golang-jwt/jwt/v5"
+)
+
+const defaultJWKSURL = "https://www.googleapis.com/oauth2/v3/certs"
+
+// refetchInterval limits how often the keys are fetched, failed or not, so
+// bogus tokens or an outage at Google cannot hammer the endpoint.
+const refetchInterval = time.Minute
+
+// Google uses both forms of its issuer in ID tokens.
+var issuers = []string{"accounts.google.com", "https://accounts.google.com"}
+
+type Claims struct {
+	Subject       string
+	Email         string
+	EmailVerified bool
+	Name          string
+	Locale        string
+}
+
+// Verifier checks a Google ID token and returns the identity inside it.
+type Verifier interface {
+	Verify(c context.Context, idToken string) (Claims, error)
+}
+
+// JWKSVerifier validates ID tokens against the keys published at a JWKS URL.
+// Keys are cached and refetched when a token names an unknown kid, which is
+// how Google rotates them.
+type JWKSVerifier struct {
+	url       string
+	clientIDs []string
+	client    *http.Client
+	cacheTTL  time.Duration
+
+	mu          sync.Mutex
+	keys        map[string]crypto.PublicKey
+	fetchedAt   time.Time
+	attemptedAt time.Time
+	// Closed when the fetch in flight, if any, is done.
+	fetching chan struct{}
+}
+
+// NewVerifierFromEnv reads the accepted OAuth client IDs from
+// GOOGLE_CLIENT_IDS (comma separated). GOOGLE_JWKS_URL can point at a local
+// stub instead of Google.
+func NewVerifierFromEnv() Verifier {
+	url := os.Getenv("GOOGLE_JWKS_URL")
+	if url == "" {
+		url = defaultJWKSURL
+	}
+
+	var clientIDs []string
+	for _, id := range strings.Split(os.Getenv("GOOGLE_CLIENT_IDS"), ",") {
+		if id = strings.TrimSpace(id); id != "" {
+			clientIDs = append(clientIDs, id)
+		}
+	}
+
+	return NewJWKSVerifier(url, clientIDs)
+}
+
+func NewJWKSVerifier(url string, clientIDs []string) *JWKSVerifier {
+	return &JWKSVerifier{
+		url:       url,
+		clientIDs: clientIDs,
+		client:    &http.Client{Timeout: 10 * time.Second},
+		cacheTTL:  helper.GetEnvDuration("GOOGLE_JWKS_CACHE_TTL", time.Hour),
+	}
+}
+
+func (v *JWKSVerifier) Verify(c context.Context, idToken string) (Claims, error) {
+	var res Claims
+
+	if len(v.clientIDs) == 0 {
+		return res, fmt.Errorf("%w: google sign-in is not configured", helper.ErrInvalidToken)
+	}
+
+	claims := jwt.MapClaims{}
+
+	_, err := jwt.ParseWithClaims(idToken, claims, func(token *jwt.Token) (interface{}, error) {
+		kid, _ := token.Header["kid"].(string)
+		return v.key(c, kid)
+	}, jwt.WithValidMethods([]string{jwt.SigningMethodRS256.Alg()}), jwt.WithExpirationRequired())
+
+	if err != nil {
+		return res, fmt.Errorf("%w: %v", helper.ErrInvalidToken, err)
+	}
+
+	iss, _ := claims.GetIssuer()
+	if !slices.Contains(issuers, iss) {
+		return res, fmt.Errorf("%w: unexpected issuer %q", helper.ErrInvalidToken, iss)
+	}
+
+	aud, _ := claims.GetAudience()
+	if !slices.ContainsFunc(aud, func(a string) bool { return slices.Contains(v.clientIDs, a) }) {
+		return res, fmt.Errorf("%w: unexpected audience", helper.ErrInvalidToken)
+	}
+
+	res.Subject, _ = claims["sub"].(string)
+	res.Email, _ = claims["email"].(string)
+	res.Name, _ = claims["name"].(string)
+	res.Locale, _ = claims["locale"].(string)
+
+	switch verified := claims["email_verified"].(type) {
+	case bool:
+		res.EmailVerified = verified
+	case string:
+		res.EmailVerified = verified == "true"
+	}
+
+	if res.Subject == "" {
+		return res, fmt.Errorf("%w: missing subject", helper.ErrInvalidToken)
+	}
+
+	return res, nil
+}
+
+// key looks kid up in the cached keys. One caller at a time fetches them
+// when they are stale or kid is unknown, which usually means Google rotated
+// them; the fetch runs outside mu and callers missing kid wait for it.
+func (v *JWKSVerifier) key(c context.Context, kid string) (crypto.PublicKey, error) {
+	v.mu.Lock()
+
+	key, ok := v.keys[kid]
+	stale := time.Since(v.fetchedAt) > v.cacheTTL
+
+	switch {
+	case (stale || !ok) && v.fetching == nil && time.Since(v.attemptedAt) > refetchInterval:
+		// Recorded before fetching so a failed fetch counts too.
+		v.attemptedAt = time.Now()
+		done := make(chan struct{})
+		v.fetching = done
+		v.mu.Unlock()
+
+		// Others may be waiting on this fetch, so it must not end with c.
+		keys, err := v.fetch(context.WithoutCancel(c))
+
+		v.mu.Lock()
+		if err == nil {
+			v.keys = keys
+			v.fetchedAt = time.Now()
+		}
+		v.fetching = nil
+		close(done)
+
+		if newKey, found := v.keys[kid]; found {
+			key, ok = newKey, true
+		}
+		v.mu.Unlock()
+
+		if !ok && err != nil {
+			return nil, err
+		}
+	case !ok && v.fetching != nil:
+		done := v.fetching
+		v.mu.Unlock()
+
+		select {
+		case <-done:
+		case <-c.Done():
+			return nil, c.Err()
+		}
+
+		v.mu.Lock()
+		key, ok = v.keys[kid]
+		v.mu.Unlock()
+	default:
+		v.mu.Unlock()
+	}
+
+	if !ok {
+		return nil, fmt.Errorf("unknown kid: %q", kid)
+	}
+
+	return key, nil
+}
+
+func (v *JWKSVerifier) fetch(c context.Context) (map[string]crypto.PublicKey, error) {
+	req, err := http.NewRequestWithContext(c, http.MethodGet, v.url, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	resp, err := v.client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("fetching %s: %s", v.url, resp.Status)
+	}
+
+	var set helper.JWKSet
+	if err := json.NewDecoder(resp.Body).Decode(&set); err != nil {
+		return nil, err
+	}
+
+	keys := make(map[string]crypto.PublicKey, len(set.Keys))
+	for _, k := range set.Keys {
+		pub, err := k.PublicKey()
+		if err != nil {
+			continue
+		}
+		keys[k.Kid] = pub
+	}
+
+	return keys, nil
+}
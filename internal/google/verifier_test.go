@@ -0,0 +1,86 @@
+package google
+
+import (
+	"context"
+	"crypto/ed25519"
+	"encoding/base64"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/GabrielMoody/mikronet-auth-service/internal/helper"
+)
+
+func TestKeyFetchLimits(t *testing.T) {
+	ctx := context.Background()
+
+	pub, _, err := ed25519.GenerateKey(nil)
+	if err != nil {
+		t.Fatalf("error while generating key: %v", err)
+	}
+
+	var hits atomic.Int64
+	var failing atomic.Bool
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		hits.Add(1)
+		if failing.Load() {
+			http.Error(w, "unavailable", http.StatusServiceUnavailable)
+			return
+		}
+		json.NewEncoder(w).Encode(helper.JWKSet{Keys: []helper.JWK{
+			{Kty: "OKP", Kid: "kid-1", Crv: "Ed25519", X: base64.RawURLEncoding.EncodeToString(pub)},
+		}})
+	}))
+	t.Cleanup(srv.Close)
+
+	v := NewJWKSVerifier(srv.URL, []string{"client-1"})
+
+	// Parallel lookups share one fetch.
+	var wg sync.WaitGroup
+	for i := 0; i < 20; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if _, err := v.key(ctx, "kid-1"); err != nil {
+				t.Errorf("unexpected error: %v", err)
+			}
+		}()
+	}
+	wg.Wait()
+
+	if got := hits.Load(); got != 1 {
+		t.Fatalf("%d fetches for parallel lookups, want 1", got)
+	}
+
+	// An unknown kid right after a fetch does not trigger another.
+	if _, err := v.key(ctx, "kid-2"); err == nil {
+		t.Fatalf("expected an error for an unknown kid")
+	}
+	if got := hits.Load(); got != 1 {
+		t.Fatalf("%d fetches after an unknown kid, want 1", got)
+	}
+
+	// A failed fetch counts against the limit as well.
+	failing.Store(true)
+	v.mu.Lock()
+	v.attemptedAt = time.Now().Add(-2 * refetchInterval)
+	v.mu.Unlock()
+
+	for i := 0; i < 3; i++ {
+		if _, err := v.key(ctx, "kid-2"); err == nil {
+			t.Fatalf("expected an error while the endpoint is down")
+		}
+	}
+	if got := hits.Load(); got != 2 {
+		t.Fatalf("%d fetches while the endpoint is down, want 2", got)
+	}
+
+	// The cached keys keep working meanwhile.
+	if _, err := v.key(ctx, "kid-1"); err != nil {
+		t.Errorf("cached key lost after a failed fetch: %v", err)
+	}
+}
@@ -0,0 +1,30 @@
+package handler
+
+import (
+	"github.com/GabrielMoody/mikronet-auth-service/internal/controller"
+	"github.com/GabrielMoody/mikronet-auth-service/internal/service"
+	"github.com/gofiber/fiber/v2"
+)
+
+// AdminHandler mounts its routes on admin, the one /admin group main guards
+// with RequireAuth and RequireRole("admin").
+func AdminHandler(admin fiber.Router, adminService service.AdminService, auditService service.AuditService, tokenService service.TokenService, twoFactorService service.TwoFactorService) {
+	adminController := controller.NewAdminController(adminService, auditService)
+	twoFactorController := controller.NewTwoFactorController(twoFactorService, tokenService)
+
+	admin.Put("/gov/:id/approve", adminController.ApproveGov)
+	admin.Put("/gov/:id/reject", adminController.RejectGov)
+	admin.Get("/drivers/pending", adminController.GetPendingDrivers)
+	admin.Get("/drivers/:id/documents/:document", adminController.GetDriverDocument)
+	admin.Put("/drivers/:id/approve", adminController.ApproveDriver)
+	admin.Put("/drivers/:id/reject", adminController.RejectDriver)
+	admin.Get("/blocked", adminController.GetBlockedAccounts)
+	admin.Get("/users", adminController.GetUsers)
+	admin.Get("/users/:id", adminController.GetUser)
+	admin.Patch("/users/:id", adminController.UpdateUser)
+	admin.Post("/users/:id/block", adminController.BlockAccount)
+	admin.Delete("/users/:id/block", adminController.UnblockAccount)
+	admin.Get("/auth-events", adminController.GetAuthEvents)
+	admin.Get("/2fa-policies", twoFactorController.GetPolicies)
+	admin.Put("/2fa-policies/:role", twoFactorController.SetPolicy)
+}
@@ -2,24 +2,57 @@ package handler
 
 import (
 	"github.com/GabrielMoody/mikronet-auth-service/internal/controller"
+	"github.com/GabrielMoody/mikronet-auth-service/internal/helper"
+	"github.com/GabrielMoody/mikronet-auth-service/internal/middleware"
 	"github.com/GabrielMoody/mikronet-auth-service/internal/repository"
 	"github.com/GabrielMoody/mikronet-auth-service/internal/service"
 	"github.com/gofiber/fiber/v2"
-	"gorm.io/gorm"
 )
 
-func AuthHandler(r fiber.Router, db *gorm.DB) {
-	repo := repository.NewAuthRepo(db)
-	authService := service.NewAuthService(repo)
-	authController := controller.NewAuthController(authService)
+// AuthHandler takes the services main builds once and shares with the gRPC
+// server and the account purger. Admin-only routes go on admin.
+func AuthHandler(r fiber.Router, admin fiber.Router, revocationStore repository.RevocationStore, authService service.AuthService, tokenService service.TokenService, twoFactorService service.TwoFactorService) {
+	authController := controller.NewAuthController(authService, tokenService, twoFactorService)
+	twoFactorController := controller.NewTwoFactorController(twoFactorService, tokenService)
+
+	requireAuth := middleware.RequireAuth(revocationStore)
+
+	// Enrollment also accepts the setup token handed out when 2FA is required
+	// but not enrolled yet.
+	twoFactorAuth := middleware.RequireTokenType(revocationStore, helper.TokenTypeAccess, helper.TokenTypeTwoFactorSetup)
 
 	authHandler := r.Group("/")
 
 	authHandler.Post("/register/user", authController.CreateUser)
 	authHandler.Post("/register/driver", authController.CreateDriver)
+	authHandler.Post("/register/owner", authController.CreateOwner)
+	authHandler.Post("/register/gov", authController.CreateGov)
 	authHandler.Post("/login", authController.LoginUser)
+	authHandler.Post("/login/otp/request", authController.RequestLoginOTP)
+	authHandler.Post("/login/otp/verify", authController.VerifyLoginOTP)
+	authHandler.Post("/login/google", authController.GoogleLogin)
+	authHandler.Post("/login/2fa", twoFactorController.VerifyLogin)
+	authHandler.Post("/2fa/enroll", twoFactorAuth, twoFactorController.Enroll)
+	authHandler.Post("/2fa/confirm", twoFactorAuth, twoFactorController.Confirm)
+	authHandler.Get("/verify-email/:token", authController.VerifyEmail)
+	authHandler.Post("/verify-email/resend", authController.ResendVerification)
+	authHandler.Post("/refresh", authController.RefreshToken)
+	authHandler.Post("/logout", requireAuth, middleware.RequireRole(), authController.Logout)
+	authHandler.Post("/logout-all", requireAuth, middleware.RequireRole(), authController.LogoutAll)
+	authHandler.Get("/sessions", requireAuth, middleware.RequireRole(), authController.GetSessions)
+	authHandler.Delete("/sessions/:id", requireAuth, middleware.RequireRole(), authController.RevokeSession)
 	authHandler.Post("/reset-password", authController.SendResetPasswordLink)
+	authHandler.Post("/reset-password/otp", authController.SendResetPasswordOTP)
+	authHandler.Put("/reset-password/otp", authController.ResetPasswordWithOTP)
 	authHandler.Put("/reset-password/:code", authController.ResetPassword)
-	authHandler.Put("/change-password", authController.ChangePassword)
+	authHandler.Put("/change-password", requireAuth, middleware.RequireRole(), authController.ChangePassword)
 	authHandler.Get("/reset-password/:code", authController.ResetPasswordUI)
+	authHandler.Get("/.well-known/jwks.json", authController.JWKS)
+	authHandler.Delete("/account", requireAuth, middleware.RequireRole(), authController.DeleteAccount)
+	authHandler.Post("/account/cancel-deletion", requireAuth, middleware.RequireRole(), authController.CancelDeletion)
+	authHandler.Post("/account/google", requireAuth, middleware.RequireRole(), authController.LinkGoogle)
+	authHandler.Delete("/account/google", requireAuth, middleware.RequireRole(), authController.UnlinkGoogle)
+
+	admin.Post("/admins", authController.InviteAdmin)
+	admin.Post("/admins/:id/resend-invite", authController.ResendAdminInvite)
 }
@@ -0,0 +1,37 @@
+package handler
+
+import (
+	"github.com/GabrielMoody/mikronet-auth-service/internal/controller"
+	"github.com/GabrielMoody/mikronet-auth-service/internal/middleware"
+	"github.com/GabrielMoody/mikronet-auth-service/internal/repository"
+	"github.com/GabrielMoody/mikronet-auth-service/internal/service"
+	"github.com/gofiber/fiber/v2"
+)
+
+// OAuthHandler mounts the client and service-account management routes on
+// admin, the one /admin group main guards.
+func OAuthHandler(r fiber.Router, admin fiber.Router, revocationStore repository.RevocationStore, oauthService service.OAuthService, serviceAccountService service.ServiceAccountService) {
+	oauthController := controller.NewOAuthController(oauthService)
+	serviceAccountController := controller.NewServiceAccountController(serviceAccountService)
+
+	r.Get("/.well-known/openid-configuration", oauthController.Discovery)
+
+	requireAuth := middleware.RequireAuth(revocationStore)
+	requireClientAuth := middleware.RequireClientAuth(revocationStore)
+
+	oauthHandler := r.Group("/oauth")
+
+	oauthHandler.Get("/authorize", oauthController.AuthorizeUI)
+	oauthHandler.Post("/authorize", requireAuth, middleware.RequireRole(), oauthController.Authorize)
+	oauthHandler.Post("/token", oauthController.Token)
+	oauthHandler.Get("/userinfo", requireClientAuth, middleware.RequireClientScope("openid"), oauthController.UserInfo)
+	oauthHandler.Post("/userinfo", requireClientAuth, middleware.RequireClientScope("openid"), oauthController.UserInfo)
+
+	admin.Post("/oauth/clients", oauthController.CreateClient)
+	admin.Get("/oauth/clients", oauthController.GetClients)
+	admin.Delete("/oauth/clients/:id", oauthController.DeleteClient)
+	admin.Post("/service-accounts", serviceAccountController.CreateServiceAccount)
+	admin.Get("/service-accounts", serviceAccountController.GetServiceAccounts)
+	admin.Post("/service-accounts/:id/rotate-secret", serviceAccountController.RotateSecret)
+	admin.Delete("/service-accounts/:id", serviceAccountController.DeleteServiceAccount)
+}
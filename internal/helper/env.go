@@ -0,0 +1,36 @@
+package helper
+
+import (
+	"os"
+	"strconv"
+	"time"
+)
+
+// GetEnv returns the variable or def when it is unset.
+func GetEnv(key, def string) string {
+	if v := os.Getenv(key); v != "" {
+		return v
+	}
+
+	return def
+}
+
+// GetEnvDuration reads a time.ParseDuration value such as "720h" from the
+// environment, falling back to def when the variable is unset or malformed.
+func GetEnvDuration(key string, def time.Duration) time.Duration {
+	d, err := time.ParseDuration(os.Getenv(key))
+	if err != nil || d <= 0 {
+		return def
+	}
+
+	return d
+}
+
+func GetEnvInt(key string, def int) int {
+	i, err := strconv.Atoi(os.Getenv(key))
+	if err != nil || i <= 0 {
+		return def
+	}
+
+	return i
+}
@@ -3,6 +3,8 @@ package helper
 import (
 	"errors"
 	"fmt"
+	"math"
+	"time"
 
 	"github.com/go-playground/validator/v10"
 )
@@ -16,8 +18,42 @@ var (
 	ErrBlockedAccount    = fmt.Errorf("akun anda telah diblokir")
 	ErrExpired           = fmt.Errorf("link reset password telah expired/invalid. silahkan melakukan reset password kembali")
 	ErrNotVerified       = fmt.Errorf("akun anda belum diverifikasi")
+	ErrRejected          = fmt.Errorf("pendaftaran akun anda ditolak")
+	ErrEmailNotVerified  = fmt.Errorf("email anda belum diverifikasi, silahkan cek email anda")
+	ErrInvalidToken      = fmt.Errorf("token tidak valid")
+	ErrTokenReused       = fmt.Errorf("refresh token telah digunakan, silahkan login kembali")
+	ErrInvalidOTP        = fmt.Errorf("kode OTP salah")
+	ErrOTPExpired        = fmt.Errorf("kode OTP telah expired/invalid. silahkan minta kode baru")
+	ErrInvalidPhone      = fmt.Errorf("nomor telepon tidak valid")
+	ErrTwoFactorEnabled  = fmt.Errorf("autentikasi dua faktor sudah aktif")
+	ErrAccountExists     = fmt.Errorf("email sudah terdaftar, silahkan login dengan password lalu hubungkan akun google anda")
+	ErrStatusUnchanged   = fmt.Errorf("status verifikasi tidak berubah")
+
+	// OAuth2 errors; the controller maps them to the RFC 6749 error codes.
+	ErrInvalidRequest       = fmt.Errorf("invalid request")
+	ErrInvalidClient        = fmt.Errorf("client authentication failed")
+	ErrInvalidGrant         = fmt.Errorf("authorization code or refresh token is invalid, expired or already used")
+	ErrInvalidScope         = fmt.Errorf("requested scope is not allowed for this client")
+	ErrUnsupportedGrantType = fmt.Errorf("grant type is not supported")
 )
 
+// TooManyAttemptsError is returned while an account or client is locked out
+// after repeated failed attempts, and by the reset, OTP and verification
+// rate limits, so its message does not name the action.
+type TooManyAttemptsError struct {
+	RetryAfter time.Duration
+}
+
+func (e *TooManyAttemptsError) Error() string {
+	return fmt.Sprintf("terlalu banyak percobaan, silahkan coba lagi dalam %d detik", e.Seconds())
+}
+
+// Seconds rounds RetryAfter up so a Retry-After header never tells the client
+// to come back too early.
+func (e *TooManyAttemptsError) Seconds() int {
+	return int(math.Ceil(e.RetryAfter.Seconds()))
+}
+
 type ErrorStruct struct {
 	Err              error
 	Code             int
@@ -29,6 +65,8 @@ var errorMessages = map[string]string{
 	"email":    "must be a valid email address",
 	"min":      "must be greater than %s characters",
 	"eqfield":  "must be the same with %s",
+	"len":      "must be exactly %s characters",
+	"numeric":  "must be numeric",
 }
 
 func translateError(err validator.FieldError) string {
@@ -59,7 +97,14 @@ func CheckError(err error) *ErrorStruct {
 		return nil
 	}
 
+	var tooManyAttempts *TooManyAttemptsError
+
 	switch {
+	case errors.As(err, &tooManyAttempts):
+		return &ErrorStruct{
+			Err:  err,
+			Code: 429,
+		}
 	case errors.Is(err, ErrNotFound):
 		return &ErrorStruct{
 			Err:  err,
@@ -70,6 +115,21 @@ func CheckError(err error) *ErrorStruct {
 			Err:  err,
 			Code: 409,
 		}
+	case errors.Is(err, ErrAccountExists):
+		return &ErrorStruct{
+			Err:  err,
+			Code: 409,
+		}
+	case errors.Is(err, ErrStatusUnchanged):
+		return &ErrorStruct{
+			Err:  err,
+			Code: 409,
+		}
+	case errors.Is(err, ErrTwoFactorEnabled):
+		return &ErrorStruct{
+			Err:  err,
+			Code: 409,
+		}
 	case errors.Is(err, ErrDatabase):
 		return &ErrorStruct{
 			Err:  err,
@@ -80,6 +140,11 @@ func CheckError(err error) *ErrorStruct {
 			Err:  err,
 			Code: 400,
 		}
+	case errors.Is(err, ErrInvalidPhone):
+		return &ErrorStruct{
+			Err:  err,
+			Code: 400,
+		}
 	case errors.Is(err, ErrPasswordIncorrect):
 		return &ErrorStruct{
 			Err:  err,
@@ -95,6 +160,46 @@ func CheckError(err error) *ErrorStruct {
 			Err:  err,
 			Code: 403,
 		}
+	case errors.Is(err, ErrEmailNotVerified):
+		return &ErrorStruct{
+			Err:  err,
+			Code: 403,
+		}
+	case errors.Is(err, ErrRejected):
+		return &ErrorStruct{
+			Err:  err,
+			Code: 403,
+		}
+	case errors.Is(err, ErrInvalidToken):
+		return &ErrorStruct{
+			Err:  err,
+			Code: 401,
+		}
+	case errors.Is(err, ErrTokenReused):
+		return &ErrorStruct{
+			Err:  err,
+			Code: 401,
+		}
+	case errors.Is(err, ErrInvalidOTP):
+		return &ErrorStruct{
+			Err:  err,
+			Code: 401,
+		}
+	case errors.Is(err, ErrOTPExpired):
+		return &ErrorStruct{
+			Err:  err,
+			Code: 410,
+		}
+	case errors.Is(err, ErrInvalidRequest), errors.Is(err, ErrInvalidGrant), errors.Is(err, ErrInvalidScope), errors.Is(err, ErrUnsupportedGrantType):
+		return &ErrorStruct{
+			Err:  err,
+			Code: 400,
+		}
+	case errors.Is(err, ErrInvalidClient):
+		return &ErrorStruct{
+			Err:  err,
+			Code: 401,
+		}
 	default:
 		return &ErrorStruct{
 			Err:  err,
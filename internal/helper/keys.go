@@ -0,0 +1,291 @@
+package helper
+
+import (
+	"crypto"
+	"crypto/ed25519"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/pem"
+	"fmt"
+	"math/big"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/golang-jwt/jwt/v5"
+)
+
+// KeySet holds the key used to sign new tokens together with every public key
+// that is still accepted. Rotating keys means adding a new key file, pointing
+// JWT_SIGNING_KID at it and keeping the old file (or just its public half)
+// around until the last token signed with it has expired.
+type KeySet struct {
+	signingKID string
+	signingKey crypto.Signer
+	method     jwt.SigningMethod
+	publicKeys map[string]crypto.PublicKey
+	// allowHS256 keeps tokens signed with JWT_SECRET valid while migrating
+	// off the shared secret. Anyone holding the secret can mint tokens, so it
+	// is off unless JWT_ALLOW_HS256=true.
+	allowHS256 bool
+}
+
+type JWK struct {
+	Kty string `json:"kty"`
+	Kid string `json:"kid"`
+	Use string `json:"use"`
+	Alg string `json:"alg"`
+	N   string `json:"n,omitempty"`
+	E   string `json:"e,omitempty"`
+	Crv string `json:"crv,omitempty"`
+	X   string `json:"x,omitempty"`
+}
+
+type JWKSet struct {
+	Keys []JWK `json:"keys"`
+}
+
+// PublicKey decodes an RSA or Ed25519 JWK, e.g. one fetched from another
+// issuer's JWKS endpoint.
+func (k JWK) PublicKey() (crypto.PublicKey, error) {
+	switch k.Kty {
+	case "RSA":
+		n, err := base64.RawURLEncoding.DecodeString(k.N)
+		if err != nil {
+			return nil, err
+		}
+
+		e, err := base64.RawURLEncoding.DecodeString(k.E)
+		if err != nil {
+			return nil, err
+		}
+
+		return &rsa.PublicKey{N: new(big.Int).SetBytes(n), E: int(new(big.Int).SetBytes(e).Int64())}, nil
+	case "OKP":
+		x, err := base64.RawURLEncoding.DecodeString(k.X)
+		if err != nil {
+			return nil, err
+		}
+
+		if k.Crv != "Ed25519" || len(x) != ed25519.PublicKeySize {
+			return nil, fmt.Errorf("unsupported curve %q", k.Crv)
+		}
+
+		return ed25519.PublicKey(x), nil
+	default:
+		return nil, fmt.Errorf("unsupported key type %q", k.Kty)
+	}
+}
+
+var jwtKeys *KeySet
+
+// LoadJWTKeys reads every *.pem file in JWT_KEYS_DIR. The file name without
+// the .pem (or .pub.pem) suffix becomes the kid. When JWT_KEYS_DIR is unset
+// tokens keep being signed with HS256 and JWT_SECRET, which then must be set;
+// an empty secret would let anyone forge tokens.
+func LoadJWTKeys() error {
+	dir := os.Getenv("JWT_KEYS_DIR")
+	if dir == "" {
+		jwtKeys = nil
+
+		if os.Getenv("JWT_SECRET") == "" {
+			return fmt.Errorf("JWT_SECRET is required when JWT_KEYS_DIR is not set")
+		}
+
+		return nil
+	}
+
+	ks, err := LoadKeySet(dir, os.Getenv("JWT_SIGNING_KID"))
+	if err != nil {
+		return err
+	}
+
+	ks.allowHS256 = os.Getenv("JWT_ALLOW_HS256") == "true"
+
+	jwtKeys = ks
+	return nil
+}
+
+// LoadKeySet fails unless signingKID names a private key in dir, so a missing
+// JWT_SIGNING_KID cannot silently fall back to HS256.
+func LoadKeySet(dir, signingKID string) (*KeySet, error) {
+	if signingKID == "" {
+		return nil, fmt.Errorf("JWT_SIGNING_KID is required when JWT_KEYS_DIR is set")
+	}
+
+	files, err := filepath.Glob(filepath.Join(dir, "*.pem"))
+	if err != nil {
+		return nil, err
+	}
+
+	ks := &KeySet{
+		publicKeys: make(map[string]crypto.PublicKey),
+	}
+
+	for _, file := range files {
+		kid := strings.TrimSuffix(strings.TrimSuffix(filepath.Base(file), ".pem"), ".pub")
+
+		raw, err := os.ReadFile(file)
+		if err != nil {
+			return nil, err
+		}
+
+		private, public, err := parseKey(raw)
+		if err != nil {
+			return nil, fmt.Errorf("key %s: %w", kid, err)
+		}
+
+		ks.publicKeys[kid] = public
+
+		if kid == signingKID && private != nil {
+			ks.signingKID = kid
+			ks.signingKey = private
+		}
+	}
+
+	if ks.signingKey == nil {
+		return nil, fmt.Errorf("private key for kid %q not found in %s", signingKID, dir)
+	}
+
+	switch ks.signingKey.(type) {
+	case *rsa.PrivateKey:
+		ks.method = jwt.SigningMethodRS256
+	case ed25519.PrivateKey:
+		ks.method = jwt.SigningMethodEdDSA
+	}
+
+	return ks, nil
+}
+
+func parseKey(raw []byte) (crypto.Signer, crypto.PublicKey, error) {
+	block, _ := pem.Decode(raw)
+	if block == nil {
+		return nil, nil, fmt.Errorf("no PEM data")
+	}
+
+	switch block.Type {
+	case "RSA PRIVATE KEY":
+		key, err := x509.ParsePKCS1PrivateKey(block.Bytes)
+		if err != nil {
+			return nil, nil, err
+		}
+		return key, key.Public(), nil
+	case "PRIVATE KEY":
+		key, err := x509.ParsePKCS8PrivateKey(block.Bytes)
+		if err != nil {
+			return nil, nil, err
+		}
+		switch k := key.(type) {
+		case *rsa.PrivateKey:
+			return k, k.Public(), nil
+		case ed25519.PrivateKey:
+			return k, k.Public(), nil
+		}
+		return nil, nil, fmt.Errorf("unsupported private key type %T", key)
+	case "RSA PUBLIC KEY":
+		key, err := x509.ParsePKCS1PublicKey(block.Bytes)
+		if err != nil {
+			return nil, nil, err
+		}
+		return nil, key, nil
+	case "PUBLIC KEY":
+		key, err := x509.ParsePKIXPublicKey(block.Bytes)
+		if err != nil {
+			return nil, nil, err
+		}
+		switch key.(type) {
+		case *rsa.PublicKey, ed25519.PublicKey:
+			return nil, key, nil
+		}
+		return nil, nil, fmt.Errorf("unsupported public key type %T", key)
+	default:
+		return nil, nil, fmt.Errorf("unsupported PEM block %q", block.Type)
+	}
+}
+
+// JWTKeyFunc resolves the verification key for a token. RS256 and EdDSA tokens
+// are looked up by kid. HS256 tokens are checked against JWT_SECRET, and once
+// JWT_KEYS_DIR is loaded only while JWT_ALLOW_HS256=true.
+func JWTKeyFunc() jwt.Keyfunc {
+	return func(token *jwt.Token) (interface{}, error) {
+		if _, ok := token.Method.(*jwt.SigningMethodHMAC); ok {
+			secretKey := os.Getenv("JWT_SECRET")
+			if secretKey == "" || (jwtKeys != nil && !jwtKeys.allowHS256) {
+				return nil, fmt.Errorf("unexpected signing method: %v", token.Header["alg"])
+			}
+			return []byte(secretKey), nil
+		}
+
+		if jwtKeys == nil {
+			return nil, fmt.Errorf("unexpected signing method: %v", token.Header["alg"])
+		}
+
+		kid, _ := token.Header["kid"].(string)
+		key, ok := jwtKeys.publicKeys[kid]
+		if !ok {
+			return nil, fmt.Errorf("unknown kid: %q", kid)
+		}
+
+		switch token.Method.(type) {
+		case *jwt.SigningMethodRSA:
+			if _, ok := key.(*rsa.PublicKey); ok {
+				return key, nil
+			}
+		case *jwt.SigningMethodEd25519:
+			if _, ok := key.(ed25519.PublicKey); ok {
+				return key, nil
+			}
+		}
+
+		return nil, fmt.Errorf("unexpected signing method: %v", token.Header["alg"])
+	}
+}
+
+// SigningAlg is the alg of newly signed tokens, as advertised in discovery.
+func SigningAlg() string {
+	if jwtKeys == nil {
+		return jwt.SigningMethodHS256.Alg()
+	}
+
+	return jwtKeys.method.Alg()
+}
+
+func JWKS() JWKSet {
+	set := JWKSet{Keys: []JWK{}}
+	if jwtKeys == nil {
+		return set
+	}
+
+	kids := make([]string, 0, len(jwtKeys.publicKeys))
+	for kid := range jwtKeys.publicKeys {
+		kids = append(kids, kid)
+	}
+	sort.Strings(kids)
+
+	for _, kid := range kids {
+		switch k := jwtKeys.publicKeys[kid].(type) {
+		case *rsa.PublicKey:
+			set.Keys = append(set.Keys, JWK{
+				Kty: "RSA",
+				Kid: kid,
+				Use: "sig",
+				Alg: jwt.SigningMethodRS256.Alg(),
+				N:   base64.RawURLEncoding.EncodeToString(k.N.Bytes()),
+				E:   base64.RawURLEncoding.EncodeToString(big.NewInt(int64(k.E)).Bytes()),
+			})
+		case ed25519.PublicKey:
+			set.Keys = append(set.Keys, JWK{
+				Kty: "OKP",
+				Kid: kid,
+				Use: "sig",
+				Alg: jwt.SigningMethodEdDSA.Alg(),
+				Crv: "Ed25519",
+				X:   base64.RawURLEncoding.EncodeToString(k),
+			})
+		}
+	}
+
+	return set
+}
@@ -0,0 +1,276 @@
+package helper
+
+import (
+	"crypto"
+	"crypto/ed25519"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/pem"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+)
+
+// writeKey stores key as a PKCS #8 PEM file named <kid>.pem in dir.
+func writeKey(t *testing.T, dir, kid string, key crypto.Signer) {
+	t.Helper()
+
+	der, err := x509.MarshalPKCS8PrivateKey(key)
+	if err != nil {
+		t.Fatalf("error while marshalling %s: %v", kid, err)
+	}
+
+	raw := pem.EncodeToMemory(&pem.Block{Type: "PRIVATE KEY", Bytes: der})
+	if err := os.WriteFile(filepath.Join(dir, kid+".pem"), raw, 0o600); err != nil {
+		t.Fatalf("error while writing %s: %v", kid, err)
+	}
+}
+
+// loadTestKeys loads dir as JWT_KEYS_DIR signing with kid and restores the
+// HS256 default when the test ends.
+func loadTestKeys(t *testing.T, dir, kid string) {
+	t.Helper()
+
+	t.Setenv("JWT_KEYS_DIR", dir)
+	t.Setenv("JWT_SIGNING_KID", kid)
+	t.Cleanup(func() { jwtKeys = nil })
+
+	if err := LoadJWTKeys(); err != nil {
+		t.Fatalf("error while loading keys: %v", err)
+	}
+}
+
+func newTestKeys(t *testing.T) (dir string, rsaKey *rsa.PrivateKey, edKey ed25519.PrivateKey) {
+	t.Helper()
+
+	rsaKey, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatalf("error while generating rsa key: %v", err)
+	}
+
+	_, edKey, err = ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		t.Fatalf("error while generating ed25519 key: %v", err)
+	}
+
+	dir = t.TempDir()
+	writeKey(t, dir, "rsa-1", rsaKey)
+	writeKey(t, dir, "ed-1", edKey)
+
+	return dir, rsaKey, edKey
+}
+
+func testClaims() jwt.MapClaims {
+	return jwt.MapClaims{
+		"id":  "user-1",
+		"typ": TokenTypeAccess,
+		"exp": time.Now().Add(time.Minute).Unix(),
+	}
+}
+
+func TestSignAndParseJWT(t *testing.T) {
+	dir, _, _ := newTestKeys(t)
+
+	tests := []struct {
+		kid     string
+		wantAlg string
+	}{
+		{"rsa-1", "RS256"},
+		{"ed-1", "EdDSA"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.wantAlg, func(t *testing.T) {
+			loadTestKeys(t, dir, tt.kid)
+
+			if got := SigningAlg(); got != tt.wantAlg {
+				t.Errorf("SigningAlg = %s, want %s", got, tt.wantAlg)
+			}
+
+			signed, err := SignJWT(testClaims())
+			if err != nil {
+				t.Fatalf("error while signing: %v", err)
+			}
+
+			token, _, err := jwt.NewParser().ParseUnverified(signed, jwt.MapClaims{})
+			if err != nil {
+				t.Fatalf("error while decoding header: %v", err)
+			}
+			if token.Header["kid"] != tt.kid || token.Header["alg"] != tt.wantAlg {
+				t.Errorf("header = %v, want kid %s and alg %s", token.Header, tt.kid, tt.wantAlg)
+			}
+
+			claims, err := ParseJWT(signed)
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if claims["id"] != "user-1" {
+				t.Errorf("id = %v, want user-1", claims["id"])
+			}
+		})
+	}
+}
+
+func TestParseJWTRejectsUnknownKeys(t *testing.T) {
+	dir, rsaKey, _ := newTestKeys(t)
+	loadTestKeys(t, dir, "rsa-1")
+	t.Setenv("JWT_SECRET", "test-secret")
+
+	otherKey, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatalf("error while generating rsa key: %v", err)
+	}
+
+	sign := func(method jwt.SigningMethod, kid string, key interface{}) string {
+		token := jwt.NewWithClaims(method, testClaims())
+		if kid != "" {
+			token.Header["kid"] = kid
+		}
+		signed, err := token.SignedString(key)
+		if err != nil {
+			t.Fatalf("error while signing: %v", err)
+		}
+		return signed
+	}
+
+	tests := []struct {
+		name    string
+		token   string
+		wantErr string
+	}{
+		{"unknown kid", sign(jwt.SigningMethodRS256, "rsa-2", otherKey), "unknown kid"},
+		{"missing kid", sign(jwt.SigningMethodRS256, "", rsaKey), "unknown kid"},
+		// An RS256 header pointing at the Ed25519 key must not pick a verifier
+		// for the wrong algorithm.
+		{"alg does not match the key", sign(jwt.SigningMethodRS256, "ed-1", rsaKey), "unexpected signing method"},
+		{"signed by another key under a known kid", sign(jwt.SigningMethodRS256, "rsa-1", otherKey), "verification error"},
+		{"hs256 with the old shared secret", sign(jwt.SigningMethodHS256, "", []byte("test-secret")), "unexpected signing method"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := ParseJWT(tt.token)
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Errorf("ParseJWT error = %v, want %q", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestParseJWTAllowHS256(t *testing.T) {
+	dir, _, _ := newTestKeys(t)
+	t.Setenv("JWT_SECRET", "test-secret")
+	t.Setenv("JWT_ALLOW_HS256", "true")
+	loadTestKeys(t, dir, "rsa-1")
+
+	signed, err := jwt.NewWithClaims(jwt.SigningMethodHS256, testClaims()).SignedString([]byte("test-secret"))
+	if err != nil {
+		t.Fatalf("error while signing: %v", err)
+	}
+
+	if _, err := ParseJWT(signed); err != nil {
+		t.Errorf("unexpected error: %v", err)
+	}
+
+	// New tokens are still signed with the key set.
+	if got := SigningAlg(); got != "RS256" {
+		t.Errorf("SigningAlg = %s, want RS256", got)
+	}
+}
+
+func TestLoadJWTKeys(t *testing.T) {
+	dir, _, edKey := newTestKeys(t)
+
+	pub, err := x509.MarshalPKIXPublicKey(edKey.Public())
+	if err != nil {
+		t.Fatalf("error while marshalling public key: %v", err)
+	}
+	raw := pem.EncodeToMemory(&pem.Block{Type: "PUBLIC KEY", Bytes: pub})
+	if err := os.WriteFile(filepath.Join(dir, "ed-0.pub.pem"), raw, 0o600); err != nil {
+		t.Fatalf("error while writing public key: %v", err)
+	}
+
+	tests := []struct {
+		name    string
+		kid     string
+		wantErr string
+	}{
+		{"no signing kid", "", "JWT_SIGNING_KID is required"},
+		{"unknown signing kid", "rsa-9", "not found"},
+		{"public key only", "ed-0", "not found"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Setenv("JWT_KEYS_DIR", dir)
+			t.Setenv("JWT_SIGNING_KID", tt.kid)
+			t.Cleanup(func() { jwtKeys = nil })
+
+			err := LoadJWTKeys()
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Errorf("LoadJWTKeys error = %v, want %q", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestLoadJWTKeysRequiresSecret(t *testing.T) {
+	t.Setenv("JWT_KEYS_DIR", "")
+	t.Setenv("JWT_SECRET", "")
+
+	if err := LoadJWTKeys(); err == nil || !strings.Contains(err.Error(), "JWT_SECRET is required") {
+		t.Errorf("LoadJWTKeys error = %v, want JWT_SECRET is required", err)
+	}
+
+	if _, err := SignJWT(jwt.MapClaims{"id": "user-1"}); err == nil {
+		t.Error("SignJWT must refuse to sign with an empty secret")
+	}
+}
+
+func TestJWKS(t *testing.T) {
+	dir, rsaKey, edKey := newTestKeys(t)
+	loadTestKeys(t, dir, "rsa-1")
+
+	set := JWKS()
+	if len(set.Keys) != 2 {
+		t.Fatalf("JWKS has %d keys, want 2", len(set.Keys))
+	}
+
+	// Keys are sorted by kid so the document is stable across restarts.
+	tests := []struct {
+		kid  string
+		kty  string
+		alg  string
+		want crypto.PublicKey
+	}{
+		{"ed-1", "OKP", "EdDSA", edKey.Public()},
+		{"rsa-1", "RSA", "RS256", rsaKey.Public()},
+	}
+
+	for i, tt := range tests {
+		got := set.Keys[i]
+		if got.Kid != tt.kid || got.Kty != tt.kty || got.Alg != tt.alg || got.Use != "sig" {
+			t.Errorf("key %d = %+v, want kid %s, kty %s, alg %s", i, got, tt.kid, tt.kty, tt.alg)
+			continue
+		}
+
+		pub, err := got.PublicKey()
+		if err != nil {
+			t.Fatalf("%s: error while decoding JWK: %v", tt.kid, err)
+		}
+
+		if !tt.want.(interface{ Equal(crypto.PublicKey) bool }).Equal(pub) {
+			t.Errorf("%s: decoded public key does not match", tt.kid)
+		}
+	}
+
+	jwtKeys = nil
+	if got := JWKS(); len(got.Keys) != 0 {
+		t.Errorf("JWKS without keys = %v, want empty", got.Keys)
+	}
+}
@@ -0,0 +1,21 @@
+package helper
+
+import (
+	"crypto/rand"
+	"math/big"
+)
+
+// GenerateOTP returns a numeric code of the given length, zero padded.
+func GenerateOTP(digits int) (string, error) {
+	b := make([]byte, digits)
+
+	for i := range b {
+		n, err := rand.Int(rand.Reader, big.NewInt(10))
+		if err != nil {
+			return "", err
+		}
+		b[i] = byte('0' + n.Int64())
+	}
+
+	return string(b), nil
+}
@@ -0,0 +1,35 @@
+package helper
+
+import (
+	"strings"
+
+	"github.com/go-playground/validator/v10"
+)
+
+var phoneSeparators = strings.NewReplacer(" ", "", "-", "", ".", "", "(", "", ")", "")
+
+// NormalizePhone turns the usual ways of writing an Indonesian mobile number
+// (0812..., 62812..., +62 812-...) into E.164, e.g. +62812xxxxxxx.
+func NormalizePhone(phone string) (string, error) {
+	p := phoneSeparators.Replace(strings.TrimSpace(phone))
+
+	switch {
+	case strings.HasPrefix(p, "+62"):
+		p = p[3:]
+	case strings.HasPrefix(p, "62"):
+		p = p[2:]
+	case strings.HasPrefix(p, "0"):
+		p = p[1:]
+	}
+
+	if len(p) < 9 || len(p) > 12 || p[0] != '8' || !isDigits(p) {
+		return "", ErrInvalidPhone
+	}
+
+	return "+62" + p, nil
+}
+
+func validatePhone(fl validator.FieldLevel) bool {
+	_, err := NormalizePhone(fl.Field().String())
+	return err == nil
+}
@@ -0,0 +1,22 @@
+package helper
+
+import "context"
+
+type requestInfoKey struct{}
+
+// RequestInfoKey is the fiber.Ctx.Locals key the request info is stored
+// under, which makes it readable from c.Context() in the services.
+var RequestInfoKey = requestInfoKey{}
+
+// RequestInfo describes the client behind a request, for the audit log.
+type RequestInfo struct {
+	IP        string
+	UserAgent string
+}
+
+// RequestInfoFrom returns the zero RequestInfo outside HTTP requests, such as
+// gRPC calls and background jobs.
+func RequestInfoFrom(ctx context.Context) RequestInfo {
+	info, _ := ctx.Value(RequestInfoKey).(RequestInfo)
+	return info
+}
@@ -0,0 +1,125 @@
+package helper
+
+import (
+	"strings"
+	"testing"
+	"time"
+)
+
+// rfc6238Secret is the SHA-1 key of the RFC 6238 test vectors,
+// "12345678901234567890", in base32.
+const rfc6238Secret = "GEZDGNBVGY3TQOJQGEZDGNBVGY3TQOJQ"
+
+func TestTOTPCode(t *testing.T) {
+	// The RFC lists eight digit codes; these are their last six digits.
+	tests := []struct {
+		unix int64
+		want string
+	}{
+		{59, "287082"},
+		{1111111109, "081804"},
+		{1111111111, "050471"},
+		{1234567890, "005924"},
+		{2000000000, "279037"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.want, func(t *testing.T) {
+			got, err := TOTPCode(rfc6238Secret, TOTPStep(time.Unix(tt.unix, 0)))
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if got != tt.want {
+				t.Errorf("TOTPCode at %d = %s, want %s", tt.unix, got, tt.want)
+			}
+		})
+	}
+
+	// Secrets are accepted in lower case as some apps display them that way.
+	if got, _ := TOTPCode(strings.ToLower(rfc6238Secret), TOTPStep(time.Unix(59, 0))); got != "287082" {
+		t.Errorf("lower case secret gave %s", got)
+	}
+
+	if _, err := TOTPCode("not base32!", 1); err == nil {
+		t.Error("expected an error for an invalid secret")
+	}
+}
+
+func TestValidateTOTP(t *testing.T) {
+	now := time.Unix(1111111111, 0)
+	step := TOTPStep(now)
+
+	codeAt := func(s int64) string {
+		code, _ := TOTPCode(rfc6238Secret, s)
+		return code
+	}
+
+	tests := []struct {
+		name     string
+		code     string
+		wantStep int64
+		wantOK   bool
+	}{
+		{"current step", codeAt(step), step, true},
+		{"previous step", codeAt(step - 1), step - 1, true},
+		{"next step", codeAt(step + 1), step + 1, true},
+		{"two steps old", codeAt(step - 2), 0, false},
+		{"two steps ahead", codeAt(step + 2), 0, false},
+		{"wrong code", "000000", 0, false},
+		{"empty code", "", 0, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			gotStep, ok := ValidateTOTP(rfc6238Secret, tt.code, now)
+			if ok != tt.wantOK || gotStep != tt.wantStep {
+				t.Errorf("ValidateTOTP = %d, %v, want %d, %v", gotStep, ok, tt.wantStep, tt.wantOK)
+			}
+		})
+	}
+}
+
+func TestGenerateTOTPSecret(t *testing.T) {
+	secret, err := GenerateTOTPSecret()
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	// 160 bits, the key size RFC 4226 recommends.
+	if len(secret) != 32 {
+		t.Errorf("secret %q has %d characters, want 32", secret, len(secret))
+	}
+
+	if _, err := TOTPCode(secret, 1); err != nil {
+		t.Errorf("generated secret does not decode: %v", err)
+	}
+}
+
+func TestNormalizeRecoveryCode(t *testing.T) {
+	tests := []struct {
+		in   string
+		want string
+	}{
+		{"k3x9q-7mfa2", "k3x9q-7mfa2"},
+		{"K3X9Q-7MFA2", "k3x9q-7mfa2"},
+		{"k3x9q7mfa2", "k3x9q-7mfa2"},
+		{" k3x9q-7mfa2 ", "k3x9q-7mfa2"},
+		{"short", "short"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.in, func(t *testing.T) {
+			if got := NormalizeRecoveryCode(tt.in); got != tt.want {
+				t.Errorf("NormalizeRecoveryCode(%q) = %q, want %q", tt.in, got, tt.want)
+			}
+		})
+	}
+
+	code, err := GenerateRecoveryCode()
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if NormalizeRecoveryCode(code) != code {
+		t.Errorf("generated code %q is not in normal form", code)
+	}
+}
@@ -0,0 +1,92 @@
+package helper
+
+import (
+	"regexp"
+	"strconv"
+
+	"github.com/go-playground/validator/v10"
+)
+
+func init() {
+	Validate.RegisterValidation("nik", validateNIK)
+	Validate.RegisterValidation("nip", validateNIP)
+	Validate.RegisterValidation("phone", validatePhone)
+	Validate.RegisterValidation("scope", validateScope)
+	errorMessages["nik"] = "must be a valid 16 digit NIK"
+	errorMessages["nip"] = "must be a valid 18 digit NIP"
+	errorMessages["phone"] = "must be a valid Indonesian phone number"
+	errorMessages["scope"] = "must be lowercase letters, digits, '.', '_', '-' or ':'"
+	errorMessages["required_without"] = "is required when %s is empty"
+}
+
+// Scopes look like "payouts:write"; spaces are excluded because a scope
+// claim is a space separated list.
+var scopePattern = regexp.MustCompile(`^[a-z0-9._:-]{1,64}$`)
+
+func validateScope(fl validator.FieldLevel) bool {
+	return scopePattern.MatchString(fl.Field().String())
+}
+
+func isDigits(s string) bool {
+	for _, r := range s {
+		if r < '0' || r > '9' {
+			return false
+		}
+	}
+
+	return true
+}
+
+// validateNIK checks the layout of an Indonesian NIK: 16 digits made of a
+// 6 digit region code, the holder's birth date as DDMMYY (day + 40 for women)
+// and a 4 digit serial number.
+func validateNIK(fl validator.FieldLevel) bool {
+	nik := fl.Field().String()
+
+	if len(nik) != 16 || !isDigits(nik) {
+		return false
+	}
+
+	province, _ := strconv.Atoi(nik[0:2])
+	day, _ := strconv.Atoi(nik[6:8])
+	month, _ := strconv.Atoi(nik[8:10])
+
+	if province < 11 || province > 94 {
+		return false
+	}
+
+	if day > 40 {
+		day -= 40
+	}
+
+	if day < 1 || day > 31 || month < 1 || month > 12 {
+		return false
+	}
+
+	return nik[12:] != "0000"
+}
+
+// validateNIP checks the layout of a civil servant NIP: 18 digits made of the
+// birth date as YYYYMMDD, the appointment month as YYYYMM, a gender digit
+// (1 or 2) and a 3 digit serial number.
+func validateNIP(fl validator.FieldLevel) bool {
+	nip := fl.Field().String()
+
+	if len(nip) != 18 || !isDigits(nip) {
+		return false
+	}
+
+	birthMonth, _ := strconv.Atoi(nip[4:6])
+	birthDay, _ := strconv.Atoi(nip[6:8])
+	appointedMonth, _ := strconv.Atoi(nip[12:14])
+
+	if birthMonth < 1 || birthMonth > 12 || birthDay < 1 || birthDay > 31 {
+		return false
+	}
+
+	if appointedMonth < 1 || appointedMonth > 12 {
+		return false
+	}
+
+	return nip[14] == '1' || nip[14] == '2'
+}
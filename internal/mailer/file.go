@@ -0,0 +1,49 @@
+package mailer
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// FileMailer writes every message as an .eml file so the mail flows can be
+// exercised offline and opened in any mail client.
+type FileMailer struct {
+	dir string
+}
+
+func (m *FileMailer) Send(c context.Context, msg Message) error {
+	name := fmt.Sprintf("%s_%s_%s.eml",
+		time.Now().Format("20060102_150405"),
+		strings.NewReplacer("@", "_at_", "/", "_").Replace(msg.To),
+		uuid.NewString()[:8],
+	)
+
+	f, err := os.Create(filepath.Join(m.dir, name))
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	_, err = toGomail(msg).WriteTo(f)
+	return err
+}
+
+func NewFileMailer(dir string) (Mailer, error) {
+	if dir == "" {
+		dir = "./mail"
+	}
+
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return nil, err
+	}
+
+	return &FileMailer{
+		dir: dir,
+	}, nil
+}
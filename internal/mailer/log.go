@@ -0,0 +1,48 @@
+package mailer
+
+import (
+	"context"
+	"log"
+	"sync"
+)
+
+type LogMailer struct{}
+
+func (m *LogMailer) Send(c context.Context, msg Message) error {
+	body := msg.Text
+	if body == "" {
+		body = msg.HTML
+	}
+
+	log.Printf("mail to=%s subject=%q\n%s", msg.To, msg.Subject, body)
+	return nil
+}
+
+func NewLogMailer() Mailer {
+	return &LogMailer{}
+}
+
+// MemoryMailer keeps sent messages so tests can assert on them.
+type MemoryMailer struct {
+	mu       sync.Mutex
+	messages []Message
+}
+
+func (m *MemoryMailer) Send(c context.Context, msg Message) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	m.messages = append(m.messages, msg)
+	return nil
+}
+
+func (m *MemoryMailer) Messages() []Message {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	return append([]Message(nil), m.messages...)
+}
+
+func NewMemoryMailer() *MemoryMailer {
+	return &MemoryMailer{}
+}
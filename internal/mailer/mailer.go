@@ -0,0 +1,68 @@
+package mailer
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"os"
+
+	"gopkg.in/gomail.v2"
+)
+
+type Message struct {
+	To      string
+	Subject string
+	Text    string
+	HTML    string
+}
+
+type Mailer interface {
+	Send(c context.Context, msg Message) error
+}
+
+// NewFromEnv picks the backend from MAIL_DRIVER: "smtp", "file" to drop .eml
+// files into MAIL_DIR, or "log" to only log messages. Unset means "log", so a
+// deployment without SMTP settings still starts.
+func NewFromEnv() (Mailer, error) {
+	switch os.Getenv("MAIL_DRIVER") {
+	case "":
+		log.Print("MAIL_DRIVER is not set, emails are only logged")
+		return NewLogMailer(), nil
+	case "smtp":
+		return NewSMTPMailer()
+	case "file":
+		return NewFileMailer(os.Getenv("MAIL_DIR"))
+	case "log":
+		return NewLogMailer(), nil
+	default:
+		return nil, fmt.Errorf("unknown MAIL_DRIVER %q", os.Getenv("MAIL_DRIVER"))
+	}
+}
+
+func from() string {
+	if f := os.Getenv("MAIL_FROM"); f != "" {
+		return f
+	}
+
+	return "Mikronet <no-reply@mikronet.id>"
+}
+
+func toGomail(msg Message) *gomail.Message {
+	m := gomail.NewMessage()
+	m.SetHeader("From", from())
+	m.SetHeader("To", msg.To)
+	m.SetHeader("Subject", msg.Subject)
+
+	if cc := os.Getenv("MAIL_CC"); cc != "" {
+		m.SetHeader("Cc", cc)
+	}
+
+	if msg.Text != "" {
+		m.SetBody("text/plain", msg.Text)
+		m.AddAlternative("text/html", msg.HTML)
+	} else {
+		m.SetBody("text/html", msg.HTML)
+	}
+
+	return m
+}
@@ -0,0 +1,48 @@
+package mailer
+
+import (
+	"fmt"
+	"testing"
+)
+
+func TestNewFromEnv(t *testing.T) {
+	tests := []struct {
+		name    string
+		env     map[string]string
+		want    string
+		wantErr bool
+	}{
+		{name: "unset defaults to log", env: map[string]string{"MAIL_DRIVER": ""}, want: "*mailer.LogMailer"},
+		{name: "log", env: map[string]string{"MAIL_DRIVER": "log"}, want: "*mailer.LogMailer"},
+		{name: "file", env: map[string]string{"MAIL_DRIVER": "file", "MAIL_DIR": t.TempDir()}, want: "*mailer.FileMailer"},
+		{name: "smtp", env: map[string]string{"MAIL_DRIVER": "smtp", "SMTP_HOST": "smtp.example.com"}, want: "*mailer.SMTPMailer"},
+		{name: "smtp without host", env: map[string]string{"MAIL_DRIVER": "smtp", "SMTP_HOST": ""}, wantErr: true},
+		{name: "smtp with bad port", env: map[string]string{"MAIL_DRIVER": "smtp", "SMTP_HOST": "smtp.example.com", "SMTP_PORT": "abc"}, wantErr: true},
+		{name: "unknown driver", env: map[string]string{"MAIL_DRIVER": "carrier-pigeon"}, wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			for k, v := range tt.env {
+				t.Setenv(k, v)
+			}
+
+			got, err := NewFromEnv()
+
+			if tt.wantErr {
+				if err == nil {
+					t.Fatalf("expected an error, got %T", got)
+				}
+				return
+			}
+
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+
+			if typ := fmt.Sprintf("%T", got); typ != tt.want {
+				t.Errorf("got %s, want %s", typ, tt.want)
+			}
+		})
+	}
+}
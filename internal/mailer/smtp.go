@@ -0,0 +1,38 @@
+package mailer
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"strconv"
+
+	"gopkg.in/gomail.v2"
+)
+
+type SMTPMailer struct {
+	dialer *gomail.Dialer
+}
+
+func (m *SMTPMailer) Send(c context.Context, msg Message) error {
+	return m.dialer.DialAndSend(toGomail(msg))
+}
+
+// NewSMTPMailer reads SMTP_HOST, SMTP_PORT, SMTP_USERNAME and SMTP_PASSWORD.
+func NewSMTPMailer() (Mailer, error) {
+	host := os.Getenv("SMTP_HOST")
+	if host == "" {
+		return nil, fmt.Errorf("SMTP_HOST is not set")
+	}
+
+	port := 587
+	if p := os.Getenv("SMTP_PORT"); p != "" {
+		var err error
+		if port, err = strconv.Atoi(p); err != nil {
+			return nil, fmt.Errorf("invalid SMTP_PORT %q", p)
+		}
+	}
+
+	return &SMTPMailer{
+		dialer: gomail.NewDialer(host, port, os.Getenv("SMTP_USERNAME"), os.Getenv("SMTP_PASSWORD")),
+	}, nil
+}
@@ -0,0 +1,113 @@
+package mailer
+
+import (
+	"bytes"
+	"fmt"
+	htmltemplate "html/template"
+	"path/filepath"
+	"strings"
+	texttemplate "text/template"
+)
+
+const DefaultLocale = "id"
+
+var supportedLocales = []string{"id", "en"}
+
+// Templates renders transactional emails from <name>.<locale>.html and
+// <name>.<locale>.txt files. The text template must define a "subject" block.
+type Templates struct {
+	html map[string]*htmltemplate.Template
+	text map[string]*texttemplate.Template
+}
+
+func LoadTemplates(dir string) (*Templates, error) {
+	t := &Templates{
+		html: make(map[string]*htmltemplate.Template),
+		text: make(map[string]*texttemplate.Template),
+	}
+
+	files, err := filepath.Glob(filepath.Join(dir, "*.*.html"))
+	if err != nil {
+		return nil, err
+	}
+
+	for _, file := range files {
+		key := strings.TrimSuffix(filepath.Base(file), ".html")
+
+		h, err := htmltemplate.ParseFiles(file)
+		if err != nil {
+			return nil, err
+		}
+
+		txt, err := texttemplate.ParseFiles(strings.TrimSuffix(file, ".html") + ".txt")
+		if err != nil {
+			return nil, err
+		}
+
+		if txt.Lookup("subject") == nil {
+			return nil, fmt.Errorf("template %s has no subject block", key)
+		}
+
+		t.html[key] = h
+		t.text[key] = txt
+	}
+
+	return t, nil
+}
+
+// Render builds a multipart message for name in the given locale, falling
+// back to DefaultLocale when that translation does not exist.
+func (t *Templates) Render(name, locale, to string, data any) (Message, error) {
+	key := name + "." + locale
+	if _, ok := t.html[key]; !ok {
+		key = name + "." + DefaultLocale
+	}
+
+	h, ok := t.html[key]
+	if !ok {
+		return Message{}, fmt.Errorf("email template %s not found", name)
+	}
+
+	txt := t.text[key]
+
+	var subject, text, html bytes.Buffer
+
+	if err := txt.ExecuteTemplate(&subject, "subject", data); err != nil {
+		return Message{}, err
+	}
+
+	if err := txt.Execute(&text, data); err != nil {
+		return Message{}, err
+	}
+
+	if err := h.Execute(&html, data); err != nil {
+		return Message{}, err
+	}
+
+	return Message{
+		To:      to,
+		Subject: strings.TrimSpace(subject.String()),
+		Text:    text.String(),
+		HTML:    html.String(),
+	}, nil
+}
+
+// ResolveLocale returns the first supported language out of the candidates,
+// each of which may be a plain tag such as "en" or a full Accept-Language
+// header such as "en-US,en;q=0.9,id;q=0.8".
+func ResolveLocale(candidates ...string) string {
+	for _, candidate := range candidates {
+		for _, part := range strings.Split(candidate, ",") {
+			tag := strings.TrimSpace(strings.SplitN(part, ";", 2)[0])
+			tag = strings.ToLower(strings.SplitN(tag, "-", 2)[0])
+
+			for _, locale := range supportedLocales {
+				if tag == locale {
+					return locale
+				}
+			}
+		}
+	}
+
+	return DefaultLocale
+}
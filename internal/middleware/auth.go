@@ -0,0 +1,171 @@
+package middleware
+
+import (
+	"errors"
+	"slices"
+	"strings"
+
+	"github.com/GabrielMoody/mikronet-auth-service/internal/helper"
+	"github.com/GabrielMoody/mikronet-auth-service/internal/repository"
+	"github.com/gofiber/fiber/v2"
+)
+
+const claimsKey = "claims"
+
+// GetClaims returns the claims stored by RequireAuth, or nil on routes that
+// do not use it.
+func GetClaims(c *fiber.Ctx) *helper.Claims {
+	claims, _ := c.Locals(claimsKey).(*helper.Claims)
+	return claims
+}
+
+func unauthorized(c *fiber.Ctx) error {
+	c.Set(fiber.HeaderWWWAuthenticate, `Bearer error="invalid_token"`)
+
+	return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{
+		"status": "error",
+		"errors": helper.ErrInvalidToken.Error(),
+	})
+}
+
+func forbidden(c *fiber.Ctx) error {
+	return c.Status(fiber.StatusForbidden).JSON(fiber.Map{
+		"status": "error",
+		"errors": "Forbidden access",
+	})
+}
+
+// RequireAuth accepts first-party user access tokens and service-account
+// tokens that store has not revoked. Chain RequireRole or RequireScope after
+// it to say which of the two a route takes.
+func RequireAuth(store repository.RevocationStore) fiber.Handler {
+	return RequireTokenType(store, helper.TokenTypeAccess, helper.TokenTypeService)
+}
+
+// RequireTokenType is RequireAuth for the few routes that take a token other
+// than an access token, such as the 2FA setup token.
+func RequireTokenType(store repository.RevocationStore, types ...string) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		return authenticate(c, store, false, types...)
+	}
+}
+
+// RequireClientAuth accepts user access tokens issued to an OAuth client,
+// which only the OAuth endpoints take. Chain RequireClientScope after it.
+func RequireClientAuth(store repository.RevocationStore) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		return authenticate(c, store, true, helper.TokenTypeAccess)
+	}
+}
+
+// authenticate takes a token of one of types. User tokens issued to an OAuth
+// client pass only when clientToken is set, and first-party ones only when it
+// is not, so partner apps never reach the first-party API.
+func authenticate(c *fiber.Ctx, store repository.RevocationStore, clientToken bool, types ...string) error {
+	token, ok := strings.CutPrefix(c.Get(fiber.HeaderAuthorization), "Bearer ")
+
+	if !ok || token == "" {
+		return unauthorized(c)
+	}
+
+	payload, err := GetJWTPayload(store, token)
+
+	if errors.Is(err, helper.ErrDatabase) {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"status": "error",
+			"errors": err.Error(),
+		})
+	}
+
+	if err != nil {
+		return unauthorized(c)
+	}
+
+	claims := helper.NewClaims(payload)
+
+	if !slices.Contains(types, claims.Type) {
+		return unauthorized(c)
+	}
+
+	if claims.ID == "" && claims.ClientID == "" {
+		return unauthorized(c)
+	}
+
+	if claims.Type == helper.TokenTypeAccess && claims.IssuedToClient() != clientToken {
+		return unauthorized(c)
+	}
+
+	c.Locals(claimsKey, &claims)
+
+	return c.Next()
+}
+
+// RequireRole lets through signed-in users with one of roles. Without roles
+// any user passes; service-account tokens never do.
+func RequireRole(roles ...string) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		claims := GetClaims(c)
+
+		if claims == nil {
+			return unauthorized(c)
+		}
+
+		if claims.Type != helper.TokenTypeAccess || claims.ID == "" || claims.IssuedToClient() {
+			return forbidden(c)
+		}
+
+		if len(roles) > 0 && !slices.Contains(roles, claims.Role) {
+			return forbidden(c)
+		}
+
+		return c.Next()
+	}
+}
+
+// RequireScope lets through service-account tokens that were granted every
+// one of scopes.
+func RequireScope(scopes ...string) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		claims := GetClaims(c)
+
+		if claims == nil {
+			return unauthorized(c)
+		}
+
+		if claims.Type != helper.TokenTypeService {
+			return forbidden(c)
+		}
+
+		for _, scope := range scopes {
+			if !slices.Contains(claims.Scopes, scope) {
+				return forbidden(c)
+			}
+		}
+
+		return c.Next()
+	}
+}
+
+// RequireClientScope lets through user tokens issued to an OAuth client that
+// was granted every one of scopes.
+func RequireClientScope(scopes ...string) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		claims := GetClaims(c)
+
+		if claims == nil {
+			return unauthorized(c)
+		}
+
+		if !claims.IssuedToClient() {
+			return forbidden(c)
+		}
+
+		for _, scope := range scopes {
+			if !slices.Contains(claims.Scopes, scope) {
+				return forbidden(c)
+			}
+		}
+
+		return c.Next()
+	}
+}
@@ -1,59 +1,30 @@
 package middleware
 
 import (
-	"fmt"
-	"github.com/gofiber/fiber/v2"
+	"context"
+
+	"github.com/GabrielMoody/mikronet-auth-service/internal/helper"
+	"github.com/GabrielMoody/mikronet-auth-service/internal/repository"
 	"github.com/golang-jwt/jwt/v5"
-	"os"
 )
 
-func GetJWTPayload(tokenString, secretKey string) (jwt.MapClaims, error) {
-	// Parse the token
-	token, err := jwt.Parse(tokenString, func(token *jwt.Token) (interface{}, error) {
-		// Validate the algorithm
-		if _, ok := token.Method.(*jwt.SigningMethodHMAC); !ok {
-			return nil, fmt.Errorf("unexpected signing method: %v", token.Header["alg"])
-		}
-		return []byte(secretKey), nil
-	})
-
+// GetJWTPayload is helper.ParseJWT plus the revocation check against store.
+func GetJWTPayload(store repository.RevocationStore, tokenString string) (jwt.MapClaims, error) {
+	payload, err := helper.ParseJWT(tokenString)
 	if err != nil {
 		return nil, err
 	}
 
-	// Extract the claims
-	if claims, ok := token.Claims.(jwt.MapClaims); ok && token.Valid {
-		return claims, nil
-	} else {
-		return nil, fmt.Errorf("invalid JWT claims")
-	}
-}
-
-func ValidateAdminRole(c *fiber.Ctx) error {
-	token := c.Get("Authorization")
-
-	if token == "" {
-		return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{
-			"status":  "Error",
-			"message": "Unauthorized",
-		})
-	}
-
-	claims, err := GetJWTPayload(token[7:], os.Getenv("JWT_SECRET"))
+	claims := helper.NewClaims(payload)
 
+	revoked, err := store.IsRevoked(context.Background(), claims.RevocationIDs(), claims.ID, claims.IssuedAt)
 	if err != nil {
-		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
-			"status":  "Error",
-			"message": err.Error(),
-		})
+		return nil, err
 	}
 
-	if claims["role"] != "admin" {
-		return c.Status(fiber.StatusForbidden).JSON(fiber.Map{
-			"status":  "Error",
-			"message": "Forbidden access",
-		})
+	if revoked {
+		return nil, helper.ErrInvalidToken
 	}
 
-	return c.Next()
+	return payload, nil
 }
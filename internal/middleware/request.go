@@ -0,0 +1,17 @@
+package middleware
+
+import (
+	"github.com/GabrielMoody/mikronet-auth-service/internal/helper"
+	"github.com/gofiber/fiber/v2"
+)
+
+// CaptureRequestInfo stores the client's IP and user agent so services, which
+// only get c.Context(), can read them with helper.RequestInfoFrom.
+func CaptureRequestInfo(c *fiber.Ctx) error {
+	c.Locals(helper.RequestInfoKey, helper.RequestInfo{
+		IP:        c.IP(),
+		UserAgent: c.Get(fiber.HeaderUserAgent),
+	})
+
+	return c.Next()
+}
@@ -0,0 +1,52 @@
+package models
+
+import "time"
+
+const (
+	EventLogin                  = "login"
+	EventLoginOTP               = "login_otp"
+	EventLoginGoogle            = "login_google"
+	EventPasswordChange         = "password_change"
+	EventPasswordResetRequest   = "password_reset_request"
+	EventPasswordReset          = "password_reset"
+	EventAccountBlock           = "account_block"
+	EventAccountUnblock         = "account_unblock"
+	EventGovApprove             = "gov_approve"
+	EventGovReject              = "gov_reject"
+	EventDriverApprove          = "driver_approve"
+	EventDriverReject           = "driver_reject"
+	EventEmailVerify            = "email_verify"
+	EventAccountDeletionRequest = "account_deletion_request"
+	EventAccountDeletionCancel  = "account_deletion_cancel"
+	EventAccountPurge           = "account_purge"
+	EventAccountUpdate          = "account_update"
+	EventAdminCreate            = "admin_create"
+	EventAdminInvite            = "admin_invite"
+	EventAdminInviteResend      = "admin_invite_resend"
+	EventOAuthClientCreate      = "oauth_client_create"
+	EventOAuthClientDelete      = "oauth_client_delete"
+	EventServiceAccountCreate   = "service_account_create"
+	EventServiceAccountRotate   = "service_account_rotate"
+	EventServiceAccountDelete   = "service_account_delete"
+	EventTwoFactorPolicyUpdate  = "two_factor_policy_update"
+
+	OutcomeSuccess = "success"
+	OutcomeFailure = "failure"
+)
+
+// AuthEvent is one row of the append-only audit log. It has no foreign keys
+// so the history of purged accounts is kept.
+type AuthEvent struct {
+	ID      uint64 `gorm:"primaryKey;autoIncrement"`
+	Type    string `gorm:"type:varchar(64);index"`
+	Outcome string `gorm:"type:enum('success','failure')"`
+	// Empty when nobody is signed in, e.g. a failed login or the purger.
+	ActorID  string `gorm:"type:varchar(255);index"`
+	TargetID string `gorm:"type:varchar(255);index"`
+	// Email or phone number the request named, for events on unknown users.
+	Identifier string    `gorm:"type:varchar(255)"`
+	IP         string    `gorm:"type:varchar(64)"`
+	UserAgent  string    `gorm:"type:varchar(512)"`
+	Reason     string    `gorm:"type:varchar(255)"`
+	CreatedAt  time.Time `gorm:"index"`
+}
@@ -0,0 +1,17 @@
+package models
+
+import "time"
+
+const ProviderGoogle = "google"
+
+// LinkedIdentity connects a user to an account at an external identity
+// provider, keyed by the provider's stable subject ID rather than the email.
+type LinkedIdentity struct {
+	ID        int    `gorm:"primaryKey"`
+	UserID    string `gorm:"type:varchar(255);uniqueIndex:idx_user_provider"`
+	User      User   `gorm:"foreignKey:UserID;references:ID;constraint:OnDelete:CASCADE"`
+	Provider  string `gorm:"type:varchar(32);uniqueIndex:idx_provider_subject;uniqueIndex:idx_user_provider"`
+	Subject   string `gorm:"type:varchar(255);uniqueIndex:idx_provider_subject"`
+	Email     string `gorm:"type:varchar(255)"`
+	CreatedAt time.Time
+}
@@ -0,0 +1,10 @@
+package models
+
+import "time"
+
+type LoginAttempt struct {
+	Key           string `gorm:"primaryKey;type:varchar(255)"`
+	Failures      int
+	LockedUntil   *time.Time
+	LastFailureAt time.Time
+}
@@ -0,0 +1,123 @@
+package models
+
+import (
+	"errors"
+	"time"
+
+	"github.com/GabrielMoody/mikronet-auth-service/internal/helper"
+	"gorm.io/gorm"
+)
+
+// DataMigration records a one-off backfill that has been applied, so it is
+// not repeated on the next start.
+type DataMigration struct {
+	Name      string `gorm:"primaryKey;type:varchar(255)"`
+	AppliedAt time.Time
+}
+
+// Tables lists every table this service owns. AutoMigrate adds the tables
+// they reference, such as routes, on its own.
+func Tables() []any {
+	return []any{
+		&User{}, &DriverDetails{}, &PassengerDetails{}, &Admin{}, &OwnerDetails{}, &GovDetails{},
+		&DriverVerification{}, &ResetPassword{}, &ResetPasswordOTP{}, &LoginOTP{}, &EmailVerification{},
+		&BlockedAccount{}, &AccountDeletion{}, &RefreshToken{}, &RevokedToken{}, &UserTokenCutoff{},
+		&Session{}, &LoginAttempt{}, &TwoFactor{}, &RecoveryCode{}, &TwoFactorPolicy{}, &LinkedIdentity{},
+		&OAuthClient{}, &AuthorizationCode{}, &ServiceAccount{}, &AuthEvent{}, &DataMigration{},
+	}
+}
+
+// Migrate creates missing tables and columns and backfills data moved between
+// columns. It never drops anything, so it is safe to run on every start.
+func Migrate(db *gorm.DB) error {
+	if err := db.AutoMigrate(Tables()...); err != nil {
+		return err
+	}
+
+	if err := runOnce(db, "backfill_sim_numbers", backfillSIMNumbers); err != nil {
+		return err
+	}
+
+	return runOnce(db, "backfill_phone_numbers", backfillPhoneNumbers)
+}
+
+// runOnce applies fn and records it under name in one transaction, unless a
+// previous start has done so already.
+func runOnce(db *gorm.DB, name string, fn func(tx *gorm.DB) error) error {
+	return db.Transaction(func(tx *gorm.DB) error {
+		var applied DataMigration
+		err := tx.First(&applied, "name = ?", name).Error
+		if err == nil {
+			return nil
+		}
+		if !errors.Is(err, gorm.ErrRecordNotFound) {
+			return err
+		}
+
+		if err := fn(tx); err != nil {
+			return err
+		}
+
+		return tx.Create(&DataMigration{Name: name, AppliedAt: time.Now()}).Error
+	})
+}
+
+// backfillSIMNumbers moves the SIM numbers that older registrations stored in
+// the SIM document column into sim_number, leaving sim for upload paths only.
+func backfillSIMNumbers(db *gorm.DB) error {
+	return db.Model(&DriverDetails{}).
+		Where("sim <> '' AND sim NOT LIKE ?", "uploads/%").
+		Updates(map[string]interface{}{
+			"sim_number": gorm.Expr("sim"),
+			"sim":        "",
+		}).Error
+}
+
+// backfillPhoneNumbers rewrites the numbers older registrations stored as
+// typed (0812...) into E.164 and copies them to users.phone_number, which is
+// what phone logins and OTP resets look up.
+func backfillPhoneNumbers(db *gorm.DB) error {
+	for _, table := range []string{"driver_details", "owner_details", "gov_details"} {
+		var rows []struct {
+			ID          string
+			PhoneNumber string
+		}
+
+		if err := db.Table(table).
+			Select(table+".id, "+table+".phone_number").
+			Joins("JOIN users ON users.id = "+table+".id").
+			Where(table+".phone_number <> '' AND ("+table+".phone_number NOT LIKE ? OR users.phone_number IS NULL)", "+%").
+			Scan(&rows).Error; err != nil {
+			return err
+		}
+
+		for _, row := range rows {
+			phone, err := helper.NormalizePhone(row.PhoneNumber)
+			if err != nil {
+				// Not an Indonesian mobile number; left for an admin to fix.
+				continue
+			}
+
+			if err := db.Table(table).Where("id = ?", row.ID).UpdateColumn("phone_number", phone).Error; err != nil {
+				return err
+			}
+
+			// users.phone_number is unique, so a number shared by two
+			// accounts stays with the one that has it already.
+			var taken int64
+			if err := db.Model(&User{}).Where("phone_number = ?", phone).Count(&taken).Error; err != nil {
+				return err
+			}
+
+			if taken > 0 {
+				continue
+			}
+
+			if err := db.Model(&User{}).Where("id = ? AND phone_number IS NULL", row.ID).UpdateColumn("phone_number", phone).Error; err != nil {
+				return err
+			}
+		}
+	}
+
+	return nil
+}
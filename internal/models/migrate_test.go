@@ -0,0 +1,169 @@
+package models_test
+
+import (
+	"testing"
+
+	"github.com/GabrielMoody/mikronet-auth-service/internal/models"
+	"github.com/GabrielMoody/mikronet-auth-service/internal/testdb"
+	"gorm.io/gorm"
+)
+
+func TestMigrate(t *testing.T) {
+	db := testdb.New(t)
+
+	for _, table := range models.Tables() {
+		if !db.Migrator().HasTable(table) {
+			t.Errorf("table for %T was not created", table)
+		}
+	}
+
+	// Running again on an up to date schema must be a no-op.
+	if err := models.Migrate(db); err != nil {
+		t.Fatalf("second migration failed: %v", err)
+	}
+}
+
+// forgetDataMigration makes db look like it predates the named backfill;
+// testdb has applied every backfill to the empty database already.
+func forgetDataMigration(t *testing.T, db *gorm.DB, name string) {
+	t.Helper()
+
+	if err := db.Delete(&models.DataMigration{Name: name}).Error; err != nil {
+		t.Fatalf("error while forgetting %s: %v", name, err)
+	}
+}
+
+func TestMigrateBackfillsSIMNumbers(t *testing.T) {
+	db := testdb.New(t)
+
+	for _, d := range []models.DriverDetails{
+		{ID: "legacy", SIM: "1234-5678-901234"},
+		{ID: "uploaded", SIMNumber: "9876", SIM: "uploads/uploaded_20240101_000000_sim"},
+		{ID: "none"},
+	} {
+		user := models.User{ID: d.ID, Email: d.ID + "@example.com", Role: "driver", DriverDetail: d}
+		if err := db.Create(&user).Error; err != nil {
+			t.Fatalf("error while creating driver %s: %v", d.ID, err)
+		}
+	}
+
+	forgetDataMigration(t, db, "backfill_sim_numbers")
+
+	if err := models.Migrate(db); err != nil {
+		t.Fatalf("migration failed: %v", err)
+	}
+
+	want := map[string][2]string{
+		"legacy":   {"1234-5678-901234", ""},
+		"uploaded": {"9876", "uploads/uploaded_20240101_000000_sim"},
+		"none":     {"", ""},
+	}
+
+	for id, w := range want {
+		var d models.DriverDetails
+		if err := db.First(&d, "id = ?", id).Error; err != nil {
+			t.Fatalf("error while reading %s: %v", id, err)
+		}
+
+		if d.SIMNumber != w[0] || d.SIM != w[1] {
+			t.Errorf("%s: sim_number = %q, sim = %q, want %q, %q", id, d.SIMNumber, d.SIM, w[0], w[1])
+		}
+	}
+
+	// The backfill is recorded and not repeated, so later rows are left alone.
+	late := models.User{ID: "late", Email: "late@example.com", Role: "driver", DriverDetail: models.DriverDetails{ID: "late", SIM: "5555"}}
+	if err := db.Create(&late).Error; err != nil {
+		t.Fatalf("error while creating driver: %v", err)
+	}
+
+	if err := models.Migrate(db); err != nil {
+		t.Fatalf("second migration failed: %v", err)
+	}
+
+	var d models.DriverDetails
+	if err := db.First(&d, "id = ?", "late").Error; err != nil {
+		t.Fatalf("error while reading late: %v", err)
+	}
+	if d.SIM != "5555" || d.SIMNumber != "" {
+		t.Errorf("backfill ran again: sim_number = %q, sim = %q", d.SIMNumber, d.SIM)
+	}
+}
+
+func TestMigrateBackfillsPhoneNumbers(t *testing.T) {
+	db := testdb.New(t)
+
+	taken := "+6283333333333"
+
+	for _, u := range []models.User{
+		{ID: "legacy", Role: "driver", DriverDetail: models.DriverDetails{ID: "legacy", PhoneNumber: "0812-3456-7890"}},
+		{ID: "owner", Role: "owner", OwnerDetail: models.OwnerDetails{ID: "owner", NIK: "7171014501900001", PhoneNumber: "6281111111111"}},
+		{ID: "current", Role: "driver", PhoneNumber: &taken, DriverDetail: models.DriverDetails{ID: "current", PhoneNumber: taken}},
+		{ID: "duplicate", Role: "driver", DriverDetail: models.DriverDetails{ID: "duplicate", PhoneNumber: "083333333333"}},
+		{ID: "foreign", Role: "driver", DriverDetail: models.DriverDetails{ID: "foreign", PhoneNumber: "+1 555 0100"}},
+	} {
+		u.Email = u.ID + "@example.com"
+		if err := db.Create(&u).Error; err != nil {
+			t.Fatalf("error while creating %s: %v", u.ID, err)
+		}
+	}
+
+	forgetDataMigration(t, db, "backfill_phone_numbers")
+
+	if err := models.Migrate(db); err != nil {
+		t.Fatalf("migration failed: %v", err)
+	}
+
+	tests := []struct {
+		id         string
+		wantDetail string
+		wantUser   string
+	}{
+		{"legacy", "+6281234567890", "+6281234567890"},
+		{"owner", "+6281111111111", "+6281111111111"},
+		{"current", taken, taken},
+		// The number belongs to another account already.
+		{"duplicate", taken, ""},
+		{"foreign", "+1 555 0100", ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.id, func(t *testing.T) {
+			var u models.User
+			if err := db.Preload("DriverDetail").Preload("OwnerDetail").First(&u, "id = ?", tt.id).Error; err != nil {
+				t.Fatalf("error while reading %s: %v", tt.id, err)
+			}
+
+			detail := u.DriverDetail.PhoneNumber
+			if u.Role == "owner" {
+				detail = u.OwnerDetail.PhoneNumber
+			}
+
+			user := ""
+			if u.PhoneNumber != nil {
+				user = *u.PhoneNumber
+			}
+
+			if detail != tt.wantDetail || user != tt.wantUser {
+				t.Errorf("detail phone = %q, users.phone_number = %q, want %q, %q", detail, user, tt.wantDetail, tt.wantUser)
+			}
+		})
+	}
+
+	// The backfill is recorded and not repeated, so later rows are left alone.
+	late := models.User{ID: "late", Email: "late@example.com", Role: "driver", DriverDetail: models.DriverDetails{ID: "late", PhoneNumber: "081299990000"}}
+	if err := db.Create(&late).Error; err != nil {
+		t.Fatalf("error while creating driver: %v", err)
+	}
+
+	if err := models.Migrate(db); err != nil {
+		t.Fatalf("second migration failed: %v", err)
+	}
+
+	var d models.DriverDetails
+	if err := db.First(&d, "id = ?", "late").Error; err != nil {
+		t.Fatalf("error while reading late: %v", err)
+	}
+	if d.PhoneNumber != "081299990000" {
+		t.Errorf("backfill ran again: phone_number = %q", d.PhoneNumber)
+	}
+}
@@ -18,11 +18,9 @@ func DatabaseInit() *gorm.DB {
 		panic(fmt.Errorf("error while connecting database %v", err.Error()))
 	}
 
-	// err = db.AutoMigrate(&User{}, &ResetPassword{})
-
-	// if err != nil {
-	// 	panic(fmt.Errorf("error while migrating database"))
-	// }
+	if err := Migrate(db); err != nil {
+		panic(fmt.Errorf("error while migrating database %v", err.Error()))
+	}
 
 	log.Print("Connection Succeed")
 
@@ -0,0 +1,34 @@
+package models
+
+import "time"
+
+// OAuthClient is an application allowed to sign users in through the
+// authorization-code flow. Public clients (SPAs, mobile apps) have no secret
+// and rely on PKCE alone.
+type OAuthClient struct {
+	ID   string `gorm:"primaryKey;type:varchar(64)"`
+	Name string `gorm:"type:varchar(255)"`
+	// SHA-256 of the client secret, empty for public clients.
+	SecretHash string `gorm:"type:varchar(255)"`
+	// Space separated, as in the OAuth2 scope syntax.
+	RedirectURIs string `gorm:"type:text"`
+	Scopes       string `gorm:"type:varchar(255)"`
+	CreatedBy    string `gorm:"type:varchar(255)"`
+	CreatedAt    time.Time
+}
+
+type AuthorizationCode struct {
+	// SHA-256 of the code handed to the client.
+	ID            string      `gorm:"primaryKey;type:varchar(255)"`
+	ClientID      string      `gorm:"index;type:varchar(64)"`
+	Client        OAuthClient `gorm:"foreignKey:ClientID;references:ID;constraint:OnDelete:CASCADE"`
+	UserID        string      `gorm:"index;type:varchar(255)"`
+	User          User        `gorm:"foreignKey:UserID;references:ID;constraint:OnDelete:CASCADE"`
+	RedirectURI   string      `gorm:"type:text"`
+	Scope         string      `gorm:"type:varchar(255)"`
+	Nonce         string      `gorm:"type:varchar(255)"`
+	CodeChallenge string      `gorm:"type:varchar(255)"`
+	ExpiresAt     time.Time
+	UsedAt        *time.Time
+	CreatedAt     time.Time
+}
@@ -0,0 +1,17 @@
+package models
+
+import "time"
+
+// ServiceAccount is a backend client (payout job, route sync, ...) that
+// authenticates with the client-credentials grant instead of a user login.
+type ServiceAccount struct {
+	ID   string `gorm:"primaryKey;type:varchar(64)"`
+	Name string `gorm:"type:varchar(255)"`
+	// SHA-256 of the client secret.
+	SecretHash string `gorm:"type:varchar(255)"`
+	// Space separated, as in the OAuth2 scope syntax.
+	Scopes          string `gorm:"type:text"`
+	CreatedBy       string `gorm:"type:varchar(255)"`
+	SecretRotatedAt time.Time
+	CreatedAt       time.Time
+}
@@ -0,0 +1,44 @@
+package models
+
+import "time"
+
+type RefreshToken struct {
+	ID        string `gorm:"primaryKey;type:varchar(255)"`
+	FamilyID  string `gorm:"index;type:varchar(255)"`
+	UserID    string `gorm:"index;type:varchar(255)"`
+	User      User   `gorm:"foreignKey:UserID;references:ID;constraint:OnDelete:CASCADE"`
+	ExpiresAt time.Time
+	RotatedAt *time.Time
+	RevokedAt *time.Time
+	CreatedAt time.Time
+}
+
+type RevokedToken struct {
+	ID        string `gorm:"primaryKey;type:varchar(255)"`
+	ExpiresAt time.Time
+	CreatedAt time.Time
+}
+
+type UserTokenCutoff struct {
+	UserID        string `gorm:"primaryKey;type:varchar(255)"`
+	User          User   `gorm:"foreignKey:UserID;references:ID;constraint:OnDelete:CASCADE"`
+	RevokedBefore time.Time
+}
+
+// Session is one login on one device. Its ID is the FamilyID shared by every
+// refresh token rotated from that login, and the sid claim of its access
+// tokens.
+type Session struct {
+	ID         string `gorm:"primaryKey;type:varchar(255)"`
+	UserID     string `gorm:"index;type:varchar(255)"`
+	User       User   `gorm:"foreignKey:UserID;references:ID;constraint:OnDelete:CASCADE"`
+	DeviceName string `gorm:"type:varchar(255)"`
+	UserAgent  string `gorm:"type:text"`
+	IP         string `gorm:"type:varchar(64)"`
+	// OAuth client the session was issued to; empty for the first-party apps.
+	ClientID string `gorm:"index;type:varchar(255)"`
+	// Scope granted to ClientID, carried by every access token of the session.
+	Scope      string `gorm:"type:varchar(255)"`
+	LastSeenAt time.Time
+	CreatedAt  time.Time
+}
@@ -0,0 +1,34 @@
+package models
+
+import "time"
+
+// TwoFactor stays disabled until the user confirms a first code from the
+// authenticator app. The secret is needed to compute codes, so unlike reset
+// codes it cannot be stored hashed.
+type TwoFactor struct {
+	ID           int    `gorm:"primaryKey"`
+	UserID       string `gorm:"unique;type:varchar(255)"`
+	User         User   `gorm:"foreignKey:UserID;references:ID;constraint:OnDelete:CASCADE"`
+	Secret       string `gorm:"type:varchar(64)"`
+	Enabled      bool   `gorm:"default:false"`
+	LastUsedStep int64
+	EnabledAt    *time.Time
+	CreatedAt    time.Time
+}
+
+type RecoveryCode struct {
+	ID     int    `gorm:"primaryKey"`
+	UserID string `gorm:"index;type:varchar(255)"`
+	User   User   `gorm:"foreignKey:UserID;references:ID;constraint:OnDelete:CASCADE"`
+	// SHA-256 of the recovery code.
+	Code      string `gorm:"index;type:varchar(255)"`
+	UsedAt    *time.Time
+	CreatedAt time.Time
+}
+
+type TwoFactorPolicy struct {
+	Role      string `gorm:"primaryKey;type:enum('admin','user','driver','owner','government')"`
+	Required  bool   `gorm:"default:false"`
+	UpdatedBy string `gorm:"type:varchar(255)"`
+	UpdatedAt time.Time
+}
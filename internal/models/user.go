@@ -5,15 +5,23 @@ import (
 )
 
 type User struct {
-	ID              string `gorm:"primaryKey;type:varchar(255)"`
-	Email           string `gorm:"unique;type:varchar(255)"`
+	ID    string `gorm:"primaryKey;type:varchar(255)"`
+	Email string `gorm:"unique;type:varchar(255)"`
+	// E.164 (+62...) and optional, so it is a pointer to keep NULLs out of the unique index.
+	PhoneNumber     *string `gorm:"unique;type:varchar(20)"`
 	Password        string
-	Role            string `gorm:"type:enum('admin','user','driver')"`
+	Role            string `gorm:"type:enum('admin','user','driver','owner','government')"`
+	Locale          string `gorm:"type:varchar(8)"`
 	CreatedAt       time.Time
 	UpdatedAt       time.Time
 	DriverDetail    DriverDetails    `gorm:"foreignKey:ID;references:ID;constraint:OnDelete:CASCADE,OnUpdate:CASCADE"`
 	PassengerDetail PassengerDetails `gorm:"foreignKey:ID;references:ID;constraint:OnDelete:CASCADE,OnUpdate:CASCADE"`
 	AdminDetail     Admin            `gorm:"foreignKey:ID;references:ID;constraint:OnDelete:CASCADE,OnUpdate:CASCADE"`
+	OwnerDetail     OwnerDetails     `gorm:"foreignKey:ID;references:ID;constraint:OnDelete:CASCADE,OnUpdate:CASCADE"`
+	GovDetail       GovDetails       `gorm:"foreignKey:ID;references:ID;constraint:OnDelete:CASCADE,OnUpdate:CASCADE"`
+	// Only set when creating an account that must confirm its email first.
+	EmailVerification *EmailVerification `gorm:"foreignKey:UserID;references:ID;constraint:OnDelete:CASCADE"`
+	LinkedIdentities  []LinkedIdentity   `gorm:"foreignKey:UserID;references:ID;constraint:OnDelete:CASCADE"`
 }
 
 type DriverDetails struct {
@@ -23,7 +31,8 @@ type DriverDetails struct {
 	RouteID        *uint
 	Route          Route  `gorm:"foreignKey:RouteID;references:ID"`
 	LicenseNumber  string `gorm:"type:varchar(255)"`
-	SIM            string `gorm:"type:varchar(255)"`
+	SIMNumber      string `gorm:"type:varchar(255)"`
+	SIM            string `gorm:"type:varchar(255)"` // upload path under uploads/, empty when no scan was sent
 	Status         string `gorm:"type:varchar(255)"`
 	Verified       bool   `gorm:"default:false"`
 	AvailableSeats int
@@ -32,6 +41,39 @@ type DriverDetails struct {
 	KTP            string `gorm:"type:varchar(255)"`
 }
 
+type DriverVerification struct {
+	ID        int           `gorm:"primaryKey"`
+	DriverID  string        `gorm:"index;type:varchar(255)"`
+	Driver    DriverDetails `gorm:"foreignKey:DriverID;references:ID;constraint:OnDelete:CASCADE"`
+	AdminID   string        `gorm:"type:varchar(255)"`
+	Status    string        `gorm:"type:enum('approved','rejected')"`
+	Reason    string        `gorm:"type:varchar(255)"`
+	CreatedAt time.Time
+}
+
+type OwnerDetails struct {
+	ID             string `gorm:"type:varchar(255);primaryKey"`
+	Name           string `gorm:"type:varchar(255)"`
+	PhoneNumber    string `gorm:"type:varchar(255)"`
+	NIK            string `gorm:"type:varchar(16);unique"`
+	ProfilePicture string `gorm:"type:varchar(255)"`
+}
+
+type GovDetails struct {
+	ID             string `gorm:"type:varchar(255);primaryKey"`
+	Name           string `gorm:"type:varchar(255)"`
+	PhoneNumber    string `gorm:"type:varchar(255)"`
+	NIP            string `gorm:"type:varchar(18);unique"`
+	ProfilePicture string `gorm:"type:varchar(255)"`
+	Verified       bool   `gorm:"default:false"`
+	VerifiedAt     *time.Time
+	VerifiedBy     *string `gorm:"type:varchar(255)"`
+	// Set when an admin turns the registration down; cleared by a later approval.
+	RejectedAt      *time.Time
+	RejectedBy      *string `gorm:"type:varchar(255)"`
+	RejectionReason string  `gorm:"type:varchar(255)"`
+}
+
 type PassengerDetails struct {
 	ID          string    `gorm:"primaryKey;type:varchar(255)"`
 	Name        string    `gorm:"type:varchar(255)"`
@@ -48,13 +90,66 @@ type ResetPassword struct {
 	ID     int    `gorm:"primaryKey"`
 	UserID string `gorm:"unique;type:varchar(255)"`
 	User   User   `gorm:"foreignKey:UserID;references:ID;constraint:OnDelete:CASCADE"`
-	Code   string `gorm:"type:varchar(255)"`
+	// SHA-256 of the code sent by email, never the code itself.
+	Code            string `gorm:"type:varchar(255);index"`
+	ExpiresAt       time.Time
+	RequestCount    int
+	WindowStartedAt time.Time
 }
 
-type BlockedAccount struct {
+// ResetPasswordOTP is the phone based alternative to ResetPassword for
+// drivers who do not read their email.
+type ResetPasswordOTP struct {
 	ID     int    `gorm:"primaryKey"`
-	UserID string `gorm:"type:varchar(255);unique"`
+	UserID string `gorm:"unique;type:varchar(255)"`
+	User   User   `gorm:"foreignKey:UserID;references:ID;constraint:OnDelete:CASCADE"`
+	// SHA-256 of the OTP, never the OTP itself.
+	Code            string `gorm:"type:varchar(255)"`
+	ExpiresAt       time.Time
+	Attempts        int
+	RequestCount    int
+	WindowStartedAt time.Time
+}
+
+// LoginOTP holds the pending passwordless login code sent to a user's phone.
+type LoginOTP struct {
+	ID     int    `gorm:"primaryKey"`
+	UserID string `gorm:"unique;type:varchar(255)"`
 	User   User   `gorm:"foreignKey:UserID;references:ID;constraint:OnDelete:CASCADE"`
+	// SHA-256 of the OTP, never the OTP itself.
+	Code            string `gorm:"type:varchar(255)"`
+	ExpiresAt       time.Time
+	Attempts        int
+	RequestCount    int
+	WindowStartedAt time.Time
+}
+
+// EmailVerification marks an account whose email address has not been
+// confirmed yet; the row is removed once the verification link is opened.
+type EmailVerification struct {
+	ID        int    `gorm:"primaryKey"`
+	UserID    string `gorm:"type:varchar(255);unique"`
+	User      User   `gorm:"foreignKey:UserID;references:ID;constraint:OnDelete:CASCADE"`
+	SentAt    time.Time
+	CreatedAt time.Time
+}
+
+type BlockedAccount struct {
+	ID        int    `gorm:"primaryKey"`
+	UserID    string `gorm:"type:varchar(255);unique"`
+	User      User   `gorm:"foreignKey:UserID;references:ID;constraint:OnDelete:CASCADE"`
+	Reason    string `gorm:"type:varchar(255)"`
+	BlockedBy string `gorm:"type:varchar(255)"`
+	ExpiresAt *time.Time
+	CreatedAt time.Time
+}
+
+type AccountDeletion struct {
+	ID        int    `gorm:"primaryKey"`
+	UserID    string `gorm:"type:varchar(255);unique"`
+	User      User   `gorm:"foreignKey:UserID;references:ID;constraint:OnDelete:CASCADE"`
+	PurgeAt   time.Time
+	CreatedAt time.Time
 }
 
 type Route struct {
@@ -0,0 +1,4 @@
+// Package pb contains the generated gRPC stubs for proto/auth.proto.
+package pb
+
+//go:generate protoc -I ../../proto --go_out=. --go_opt=paths=source_relative --go-grpc_out=. --go-grpc_opt=paths=source_relative auth.proto
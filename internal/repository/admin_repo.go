@@ -0,0 +1,387 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"time"
+
+	"github.com/GabrielMoody/mikronet-auth-service/internal/helper"
+	"github.com/GabrielMoody/mikronet-auth-service/internal/models"
+	"github.com/go-sql-driver/mysql"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// UserFilter narrows GetUsers. Zero fields match every user.
+type UserFilter struct {
+	// Matched against email, phone number and the name of every role.
+	Search      string
+	Role        string
+	CreatedFrom time.Time
+	CreatedTo   time.Time
+	// "created_at" or "email", with a leading "-" for descending order.
+	Sort string
+}
+
+type AdminRepo interface {
+	ApproveGov(c context.Context, id string, adminID string) (res models.GovDetails, err error)
+	RejectGov(c context.Context, id string, adminID string, reason string) (res models.GovDetails, err error)
+	GetPendingDrivers(c context.Context, page, limit int) (res []models.User, total int64, err error)
+	GetDriverByID(c context.Context, id string) (res models.DriverDetails, err error)
+	VerifyDriver(c context.Context, data models.DriverVerification) (res models.DriverVerification, err error)
+	BlockAccount(c context.Context, data models.BlockedAccount) (res models.BlockedAccount, err error)
+	UnblockAccount(c context.Context, id string) (err error)
+	GetBlockedAccounts(c context.Context, page, limit int) (res []models.BlockedAccount, total int64, err error)
+	GetUsers(c context.Context, filter UserFilter, page, limit int) (res []models.User, total int64, err error)
+	GetUserByID(c context.Context, id string) (res models.User, err error)
+	IsBlocked(c context.Context, id string) (res bool, err error)
+	UpdateUser(c context.Context, data models.User) (res models.User, err error)
+}
+
+type AdminRepoImpl struct {
+	db *gorm.DB
+}
+
+func (a *AdminRepoImpl) ApproveGov(c context.Context, id string, adminID string) (res models.GovDetails, err error) {
+	if err := a.db.WithContext(c).First(&res, "id = ?", id).Error; err != nil {
+		return res, helper.ErrNotFound
+	}
+
+	now := time.Now()
+	res.Verified = true
+	res.VerifiedAt = &now
+	res.VerifiedBy = &adminID
+	res.RejectedAt = nil
+	res.RejectedBy = nil
+	res.RejectionReason = ""
+
+	if err := a.db.WithContext(c).Save(&res).Error; err != nil {
+		return res, helper.ErrDatabase
+	}
+
+	return res, nil
+}
+
+func (a *AdminRepoImpl) RejectGov(c context.Context, id string, adminID string, reason string) (res models.GovDetails, err error) {
+	if err := a.db.WithContext(c).First(&res, "id = ?", id).Error; err != nil {
+		return res, helper.ErrNotFound
+	}
+
+	now := time.Now()
+	res.Verified = false
+	res.VerifiedAt = nil
+	res.VerifiedBy = nil
+	res.RejectedAt = &now
+	res.RejectedBy = &adminID
+	res.RejectionReason = reason
+
+	if err := a.db.WithContext(c).Save(&res).Error; err != nil {
+		return res, helper.ErrDatabase
+	}
+
+	return res, nil
+}
+
+func (a *AdminRepoImpl) GetPendingDrivers(c context.Context, page, limit int) (res []models.User, total int64, err error) {
+	q := a.db.WithContext(c).
+		Model(&models.User{}).
+		Joins("DriverDetail").
+		Where("users.role = ? AND DriverDetail.verified = ?", "driver", false).
+		// Only the latest review counts, so a later verification row puts a
+		// rejected driver back in the queue.
+		Where("COALESCE((?), '') <> ?", a.db.Model(&models.DriverVerification{}).
+			Select("driver_verifications.status").
+			Where("driver_verifications.driver_id = users.id").
+			Order("driver_verifications.created_at desc, driver_verifications.id desc").
+			Limit(1), "rejected")
+
+	if err := q.Count(&total).Error; err != nil {
+		return res, 0, helper.ErrDatabase
+	}
+
+	if err := q.Order("users.created_at asc").
+		Offset((page - 1) * limit).
+		Limit(limit).
+		Find(&res).Error; err != nil {
+		return res, 0, helper.ErrDatabase
+	}
+
+	return res, total, nil
+}
+
+func (a *AdminRepoImpl) GetDriverByID(c context.Context, id string) (res models.DriverDetails, err error) {
+	if err := a.db.WithContext(c).First(&res, "id = ?", id).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return res, helper.ErrNotFound
+		}
+		return res, helper.ErrDatabase
+	}
+
+	return res, nil
+}
+
+func (a *AdminRepoImpl) VerifyDriver(c context.Context, data models.DriverVerification) (res models.DriverVerification, err error) {
+	tx := a.db.WithContext(c).Begin()
+
+	defer func() {
+		if r := recover(); r != nil {
+			tx.Rollback()
+		}
+	}()
+
+	var driver models.DriverDetails
+	if err := tx.First(&driver, "id = ?", data.DriverID).Error; err != nil {
+		tx.Rollback()
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return res, helper.ErrNotFound
+		}
+		return res, helper.ErrDatabase
+	}
+
+	var latest models.DriverVerification
+	if err := tx.Where("driver_id = ?", data.DriverID).
+		Order("created_at desc, id desc").
+		Limit(1).
+		Find(&latest).Error; err != nil {
+		tx.Rollback()
+		return res, helper.ErrDatabase
+	}
+
+	// Repeating the current decision would only duplicate the history.
+	unchanged := driver.Verified
+	if data.Status == "rejected" {
+		unchanged = !driver.Verified && latest.Status == "rejected"
+	}
+
+	if unchanged {
+		tx.Rollback()
+		return res, helper.ErrStatusUnchanged
+	}
+
+	if err := tx.Model(&driver).Update("verified", data.Status == "approved").Error; err != nil {
+		tx.Rollback()
+		return res, helper.ErrDatabase
+	}
+
+	if err := tx.Create(&data).Error; err != nil {
+		tx.Rollback()
+		return res, helper.ErrDatabase
+	}
+
+	tx.Commit()
+
+	return data, nil
+}
+
+func (a *AdminRepoImpl) BlockAccount(c context.Context, data models.BlockedAccount) (res models.BlockedAccount, err error) {
+	var user models.User
+	if err := a.db.WithContext(c).First(&user, "id = ?", data.UserID).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return res, helper.ErrNotFound
+		}
+		return res, helper.ErrDatabase
+	}
+
+	if err := a.db.WithContext(c).Clauses(clause.OnConflict{
+		Columns:   []clause.Column{{Name: "user_id"}},
+		DoUpdates: clause.AssignmentColumns([]string{"reason", "blocked_by", "expires_at", "created_at"}),
+	}).Create(&data).Error; err != nil {
+		return res, helper.ErrDatabase
+	}
+
+	return data, nil
+}
+
+func (a *AdminRepoImpl) UnblockAccount(c context.Context, id string) (err error) {
+	q := a.db.WithContext(c).Delete(&models.BlockedAccount{}, "user_id = ?", id)
+
+	if q.Error != nil {
+		return helper.ErrDatabase
+	}
+
+	if q.RowsAffected == 0 {
+		return helper.ErrNotFound
+	}
+
+	return nil
+}
+
+func (a *AdminRepoImpl) GetBlockedAccounts(c context.Context, page, limit int) (res []models.BlockedAccount, total int64, err error) {
+	q := a.db.WithContext(c).
+		Model(&models.BlockedAccount{}).
+		Where("expires_at IS NULL OR expires_at > ?", time.Now())
+
+	if err := q.Count(&total).Error; err != nil {
+		return res, 0, helper.ErrDatabase
+	}
+
+	if err := q.Preload("User").
+		Order("created_at desc").
+		Offset((page - 1) * limit).
+		Limit(limit).
+		Find(&res).Error; err != nil {
+		return res, 0, helper.ErrDatabase
+	}
+
+	return res, total, nil
+}
+
+// likeEscaper makes a search term match literally. The escape character is
+// "!" rather than a backslash, which MySQL and SQLite quote differently.
+var likeEscaper = strings.NewReplacer("!", "!!", "%", "!%", "_", "!_")
+
+func escapeLike(s string) string {
+	return likeEscaper.Replace(s)
+}
+
+func (a *AdminRepoImpl) GetUsers(c context.Context, filter UserFilter, page, limit int) (res []models.User, total int64, err error) {
+	q := a.db.WithContext(c).
+		Model(&models.User{}).
+		Joins("DriverDetail").
+		Joins("PassengerDetail").
+		Joins("AdminDetail").
+		Joins("OwnerDetail").
+		Joins("GovDetail")
+
+	if filter.Search != "" {
+		like := "%" + escapeLike(filter.Search) + "%"
+		q = q.Where("users.email LIKE ? ESCAPE '!' OR users.phone_number LIKE ? ESCAPE '!' OR DriverDetail.name LIKE ? ESCAPE '!' OR PassengerDetail.name LIKE ? ESCAPE '!' OR AdminDetail.name LIKE ? ESCAPE '!' OR OwnerDetail.name LIKE ? ESCAPE '!' OR GovDetail.name LIKE ? ESCAPE '!'",
+			like, like, like, like, like, like, like)
+	}
+
+	if filter.Role != "" {
+		q = q.Where("users.role = ?", filter.Role)
+	}
+
+	if !filter.CreatedFrom.IsZero() {
+		q = q.Where("users.created_at >= ?", filter.CreatedFrom)
+	}
+
+	if !filter.CreatedTo.IsZero() {
+		q = q.Where("users.created_at < ?", filter.CreatedTo)
+	}
+
+	if err := q.Count(&total).Error; err != nil {
+		return res, 0, helper.ErrDatabase
+	}
+
+	order := "users.created_at desc"
+	switch filter.Sort {
+	case "created_at":
+		order = "users.created_at asc"
+	case "email":
+		order = "users.email asc"
+	case "-email":
+		order = "users.email desc"
+	}
+
+	if err := q.Order(order).
+		Order("users.id asc").
+		Offset((page - 1) * limit).
+		Limit(limit).
+		Find(&res).Error; err != nil {
+		return res, 0, helper.ErrDatabase
+	}
+
+	return res, total, nil
+}
+
+func (a *AdminRepoImpl) GetUserByID(c context.Context, id string) (res models.User, err error) {
+	if err := a.db.WithContext(c).
+		Joins("DriverDetail").
+		Joins("PassengerDetail").
+		Joins("AdminDetail").
+		Joins("OwnerDetail").
+		Joins("GovDetail").
+		Preload("EmailVerification").
+		First(&res, "users.id = ?", id).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return res, helper.ErrNotFound
+		}
+		return res, helper.ErrDatabase
+	}
+
+	return res, nil
+}
+
+func (a *AdminRepoImpl) IsBlocked(c context.Context, id string) (res bool, err error) {
+	var blocked models.BlockedAccount
+
+	if err := a.db.WithContext(c).
+		Where("user_id = ? AND (expires_at IS NULL OR expires_at > ?)", id, time.Now()).
+		First(&blocked).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return false, nil
+		}
+		return false, helper.ErrDatabase
+	}
+
+	return true, nil
+}
+
+// UpdateUser saves the role and phone number of data and the details row of
+// its role, creating that row when the user did not have the role before.
+func (a *AdminRepoImpl) UpdateUser(c context.Context, data models.User) (res models.User, err error) {
+	tx := a.db.WithContext(c).Begin()
+
+	defer func() {
+		if r := recover(); r != nil {
+			tx.Rollback()
+		}
+	}()
+
+	if err := tx.Model(&models.User{}).
+		Where("id = ?", data.ID).
+		Updates(map[string]interface{}{"role": data.Role, "phone_number": data.PhoneNumber, "updated_at": time.Now()}).Error; err != nil {
+		tx.Rollback()
+
+		var mysqlErr *mysql.MySQLError
+
+		if errors.As(err, &mysqlErr) && mysqlErr.Number == 1062 {
+			return res, helper.ErrDuplicateEntry
+		}
+
+		return res, helper.ErrDatabase
+	}
+
+	var detail interface{}
+	switch data.Role {
+	case "driver":
+		detail = &data.DriverDetail
+	case "user":
+		detail = &data.PassengerDetail
+	case "admin":
+		detail = &data.AdminDetail
+	case "owner":
+		detail = &data.OwnerDetail
+	case "government":
+		detail = &data.GovDetail
+	}
+
+	if detail != nil {
+		if err := tx.Omit(clause.Associations).Save(detail).Error; err != nil {
+			tx.Rollback()
+
+			var mysqlErr *mysql.MySQLError
+
+			if errors.As(err, &mysqlErr) && mysqlErr.Number == 1062 {
+				return res, helper.ErrDuplicateEntry
+			}
+
+			return res, helper.ErrDatabase
+		}
+	}
+
+	if err := tx.Commit().Error; err != nil {
+		return res, helper.ErrDatabase
+	}
+
+	return a.GetUserByID(c, data.ID)
+}
+
+func NewAdminRepo(db *gorm.DB) AdminRepo {
+	return &AdminRepoImpl{
+		db: db,
+	}
+}
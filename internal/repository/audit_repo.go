@@ -0,0 +1,85 @@
+package repository
+
+import (
+	"context"
+	"time"
+
+	"github.com/GabrielMoody/mikronet-auth-service/internal/helper"
+	"github.com/GabrielMoody/mikronet-auth-service/internal/models"
+	"gorm.io/gorm"
+)
+
+// AuthEventFilter narrows GetAuthEvents. Zero fields match every event.
+type AuthEventFilter struct {
+	Type     string
+	Outcome  string
+	ActorID  string
+	TargetID string
+	From     time.Time
+	To       time.Time
+	// Only events older than this ID, newest first.
+	Cursor uint64
+	Limit  int
+}
+
+// AuditRepo only appends and reads; the log is never updated or deleted.
+type AuditRepo interface {
+	CreateAuthEvent(c context.Context, data models.AuthEvent) (err error)
+	GetAuthEvents(c context.Context, filter AuthEventFilter) (res []models.AuthEvent, err error)
+}
+
+type AuditRepoImpl struct {
+	db *gorm.DB
+}
+
+func (a *AuditRepoImpl) CreateAuthEvent(c context.Context, data models.AuthEvent) (err error) {
+	if err := a.db.WithContext(c).Create(&data).Error; err != nil {
+		return helper.ErrDatabase
+	}
+
+	return nil
+}
+
+func (a *AuditRepoImpl) GetAuthEvents(c context.Context, filter AuthEventFilter) (res []models.AuthEvent, err error) {
+	q := a.db.WithContext(c).Model(&models.AuthEvent{})
+
+	if filter.Type != "" {
+		q = q.Where("type = ?", filter.Type)
+	}
+
+	if filter.Outcome != "" {
+		q = q.Where("outcome = ?", filter.Outcome)
+	}
+
+	if filter.ActorID != "" {
+		q = q.Where("actor_id = ?", filter.ActorID)
+	}
+
+	if filter.TargetID != "" {
+		q = q.Where("target_id = ?", filter.TargetID)
+	}
+
+	if !filter.From.IsZero() {
+		q = q.Where("created_at >= ?", filter.From)
+	}
+
+	if !filter.To.IsZero() {
+		q = q.Where("created_at < ?", filter.To)
+	}
+
+	if filter.Cursor > 0 {
+		q = q.Where("id < ?", filter.Cursor)
+	}
+
+	if err := q.Order("id desc").Limit(filter.Limit).Find(&res).Error; err != nil {
+		return res, helper.ErrDatabase
+	}
+
+	return res, nil
+}
+
+func NewAuditRepo(db *gorm.DB) AuditRepo {
+	return &AuditRepoImpl{
+		db: db,
+	}
+}
@@ -2,7 +2,10 @@ package repository
 
 import (
 	"context"
+	"crypto/subtle"
 	"errors"
+	"fmt"
+	"time"
 
 	"github.com/GabrielMoody/mikronet-auth-service/internal/dto"
 	"github.com/GabrielMoody/mikronet-auth-service/internal/helper"
@@ -16,22 +19,48 @@ import (
 type AuthRepo interface {
 	CreateUser(c context.Context, data models.User) (res string, err error)
 	CreateDriver(c context.Context, data models.User) (res string, err error)
+	CreateOwner(c context.Context, data models.User) (res string, err error)
+	CreateGov(c context.Context, data models.User) (res string, err error)
+	CreateAdminInvite(c context.Context, data models.User, code string, expiresAt time.Time) (res string, err error)
+	RenewAdminInvite(c context.Context, id string, code string, expiresAt time.Time) (res models.User, err error)
 	LoginUser(c context.Context, data dto.UserLoginReq) (res models.User, err error)
-	SendResetPassword(c context.Context, email string, code string) (data models.ResetPassword, err error)
+	SendResetPassword(c context.Context, email string, code string, expiresAt time.Time, limit int) (data models.ResetPassword, err error)
 	ResetPassword(c context.Context, password string, code string) (res string, err error)
+	SendResetOTP(c context.Context, phone string, code string, expiresAt time.Time, limit int) (data models.ResetPasswordOTP, err error)
+	ResetPasswordWithOTP(c context.Context, phone string, code string, password string, maxAttempts int) (res string, err error)
+	SendLoginOTP(c context.Context, phone string, code string, expiresAt time.Time, limit int) (data models.LoginOTP, err error)
+	LoginWithOTP(c context.Context, phone string, code string, maxAttempts int) (res models.User, err error)
+	LoginWithIdentity(c context.Context, provider, subject string) (res models.User, err error)
+	LinkIdentity(c context.Context, id, password string, data models.LinkedIdentity) (res models.LinkedIdentity, err error)
+	UnlinkIdentity(c context.Context, id, password, provider string) (err error)
 	ChangePassword(c context.Context, oldPassword, newPassword, id string) (res string, err error)
 	DeleteUser(c context.Context, id string) (res models.User, err error)
-	isBlocked(c context.Context, id string) (bool, error)
-	isVerified(c context.Context, id string) (bool, error)
+	CheckPassword(c context.Context, id, password string) (err error)
+	GetIdentity(c context.Context, provider, subject string) (res models.LinkedIdentity, err error)
+	ScheduleDeletion(c context.Context, id string, purgeAt time.Time) (res models.AccountDeletion, err error)
+	CancelDeletion(c context.Context, id string) (err error)
+	GetDueDeletions(c context.Context, now time.Time) (res []models.User, err error)
+	GetUserByID(c context.Context, id string) (res models.User, err error)
+	IsBlocked(c context.Context, id string) (bool, error)
+	IsVerified(c context.Context, id string) (bool, error)
+	IsGovVerified(c context.Context, id string) (bool, error)
+	GetDriverRejection(c context.Context, id string) (res models.DriverVerification, err error)
+	GetGovRejection(c context.Context, id string) (res models.GovDetails, err error)
+	IsEmailVerified(c context.Context, id string) (bool, error)
+	CreateEmailVerification(c context.Context, id string) (res models.EmailVerification, err error)
+	GetEmailVerification(c context.Context, email string) (res models.EmailVerification, err error)
+	VerifyEmail(c context.Context, id string) (err error)
 }
 
 type AuthRepoImpl struct {
 	db *gorm.DB
 }
 
-func (a *AuthRepoImpl) isBlocked(c context.Context, id string) (bool, error) {
+func (a *AuthRepoImpl) IsBlocked(c context.Context, id string) (bool, error) {
 	var res models.BlockedAccount
-	if err := a.db.WithContext(c).First(&res, "account_id = ?", id).Error; err != nil {
+	if err := a.db.WithContext(c).
+		Where("user_id = ? AND (expires_at IS NULL OR expires_at > ?)", id, time.Now()).
+		First(&res).Error; err != nil {
 		if errors.Is(err, gorm.ErrRecordNotFound) {
 			return false, nil
 		}
@@ -41,7 +70,7 @@ func (a *AuthRepoImpl) isBlocked(c context.Context, id string) (bool, error) {
 	return true, nil
 }
 
-func (a *AuthRepoImpl) isVerified(c context.Context, id string) (bool, error) {
+func (a *AuthRepoImpl) IsVerified(c context.Context, id string) (bool, error) {
 	var res models.DriverDetails
 	if err := a.db.WithContext(c).First(&res, "id = ?", id).Error; err != nil {
 		if errors.Is(err, gorm.ErrRecordNotFound) {
@@ -53,6 +82,112 @@ func (a *AuthRepoImpl) isVerified(c context.Context, id string) (bool, error) {
 	return res.Verified, nil
 }
 
+func (a *AuthRepoImpl) IsGovVerified(c context.Context, id string) (bool, error) {
+	var res models.GovDetails
+	if err := a.db.WithContext(c).First(&res, "id = ?", id).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return false, nil
+		}
+		return false, helper.ErrDatabase
+	}
+
+	return res.Verified, nil
+}
+
+func (a *AuthRepoImpl) GetDriverRejection(c context.Context, id string) (res models.DriverVerification, err error) {
+	if err := a.db.WithContext(c).
+		Where("driver_id = ?", id).
+		Order("created_at desc, id desc").
+		First(&res).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return res, helper.ErrNotFound
+		}
+		return res, helper.ErrDatabase
+	}
+
+	if res.Status != "rejected" {
+		return res, helper.ErrNotFound
+	}
+
+	return res, nil
+}
+
+func (a *AuthRepoImpl) GetGovRejection(c context.Context, id string) (res models.GovDetails, err error) {
+	if err := a.db.WithContext(c).First(&res, "id = ?", id).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return res, helper.ErrNotFound
+		}
+		return res, helper.ErrDatabase
+	}
+
+	if res.RejectedAt == nil {
+		return res, helper.ErrNotFound
+	}
+
+	return res, nil
+}
+
+func (a *AuthRepoImpl) IsEmailVerified(c context.Context, id string) (bool, error) {
+	var res models.EmailVerification
+	if err := a.db.WithContext(c).First(&res, "user_id = ?", id).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return true, nil
+		}
+		return false, helper.ErrDatabase
+	}
+
+	return false, nil
+}
+
+func (a *AuthRepoImpl) CreateEmailVerification(c context.Context, id string) (res models.EmailVerification, err error) {
+	res = models.EmailVerification{
+		UserID: id,
+		SentAt: time.Now(),
+	}
+
+	if err := a.db.WithContext(c).Clauses(clause.OnConflict{
+		Columns:   []clause.Column{{Name: "user_id"}},
+		DoUpdates: clause.AssignmentColumns([]string{"sent_at"}),
+	}).Create(&res).Error; err != nil {
+		return res, helper.ErrDatabase
+	}
+
+	return res, nil
+}
+
+func (a *AuthRepoImpl) GetEmailVerification(c context.Context, email string) (res models.EmailVerification, err error) {
+	if err := a.db.WithContext(c).
+		Joins("User").
+		Where("User.email = ?", email).
+		First(&res).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return res, helper.ErrNotFound
+		}
+		return res, helper.ErrDatabase
+	}
+
+	return res, nil
+}
+
+func (a *AuthRepoImpl) VerifyEmail(c context.Context, id string) (err error) {
+	if err := a.db.WithContext(c).Delete(&models.EmailVerification{}, "user_id = ?", id).Error; err != nil {
+		return helper.ErrDatabase
+	}
+
+	return nil
+}
+
+func (a *AuthRepoImpl) GetUserByID(c context.Context, id string) (res models.User, err error) {
+	if err := a.db.WithContext(c).First(&res, "id = ?", id).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return res, helper.ErrNotFound
+		}
+		return res, helper.ErrDatabase
+	}
+
+	return res, nil
+}
+
 func (a *AuthRepoImpl) DeleteUser(c context.Context, id string) (res models.User, err error) {
 	if err := a.db.WithContext(c).Delete(&res, "id = ?", id).Error; err != nil {
 		return res, helper.ErrDatabase
@@ -61,6 +196,67 @@ func (a *AuthRepoImpl) DeleteUser(c context.Context, id string) (res models.User
 	return res, nil
 }
 
+// CheckPassword re-authenticates a signed-in user before a destructive
+// action.
+func (a *AuthRepoImpl) CheckPassword(c context.Context, id, password string) (err error) {
+	var user models.User
+
+	if err := a.db.WithContext(c).First(&user, "id = ?", id).Error; err != nil {
+		return helper.ErrNotFound
+	}
+
+	if err := bcrypt.CompareHashAndPassword([]byte(user.Password), []byte(password)); err != nil {
+		return helper.ErrPasswordIncorrect
+	}
+
+	return nil
+}
+
+func (a *AuthRepoImpl) ScheduleDeletion(c context.Context, id string, purgeAt time.Time) (res models.AccountDeletion, err error) {
+	res = models.AccountDeletion{
+		UserID:  id,
+		PurgeAt: purgeAt,
+	}
+
+	if err := a.db.WithContext(c).Clauses(clause.OnConflict{DoNothing: true}).Create(&res).Error; err != nil {
+		return res, helper.ErrDatabase
+	}
+
+	// An existing request keeps its original date instead of being pushed back.
+	if err := a.db.WithContext(c).First(&res, "user_id = ?", id).Error; err != nil {
+		return res, helper.ErrDatabase
+	}
+
+	return res, nil
+}
+
+func (a *AuthRepoImpl) CancelDeletion(c context.Context, id string) (err error) {
+	q := a.db.WithContext(c).Delete(&models.AccountDeletion{}, "user_id = ?", id)
+
+	if q.Error != nil {
+		return helper.ErrDatabase
+	}
+
+	if q.RowsAffected == 0 {
+		return helper.ErrNotFound
+	}
+
+	return nil
+}
+
+func (a *AuthRepoImpl) GetDueDeletions(c context.Context, now time.Time) (res []models.User, err error) {
+	if err := a.db.WithContext(c).
+		Preload("DriverDetail").
+		Preload("OwnerDetail").
+		Preload("GovDetail").
+		Where("id IN (?)", a.db.Model(&models.AccountDeletion{}).Select("user_id").Where("purge_at <= ?", now)).
+		Find(&res).Error; err != nil {
+		return res, helper.ErrDatabase
+	}
+
+	return res, nil
+}
+
 func (a *AuthRepoImpl) ChangePassword(c context.Context, oldPassword, newPassword, id string) (res string, err error) {
 	var user models.User
 
@@ -79,31 +275,74 @@ func (a *AuthRepoImpl) ChangePassword(c context.Context, oldPassword, newPasswor
 	return "Success updating the password", nil
 }
 
-func (a *AuthRepoImpl) LoginUser(c context.Context, data dto.UserLoginReq) (res models.User, err error) {
-	if err := a.db.WithContext(c).First(&res, "email = ?", data.Email).Error; err != nil {
-		return res, helper.ErrNotFound
+// checkAccountStatus holds the checks every login method shares: the account
+// must not be blocked, and drivers and officers must have been approved.
+func (a *AuthRepoImpl) checkAccountStatus(c context.Context, user models.User) error {
+	b, _ := a.IsBlocked(c, user.ID)
+	if b {
+		return helper.ErrBlockedAccount
 	}
 
-	b, _ := a.isBlocked(c, res.ID)
-	if b {
-		return res, helper.ErrBlockedAccount
+	if user.Role == "driver" {
+		v, _ := a.IsVerified(c, user.ID)
+		if !v {
+			if r, err := a.GetDriverRejection(c, user.ID); err == nil {
+				return fmt.Errorf("%w: %s", helper.ErrRejected, r.Reason)
+			}
+			return helper.ErrNotVerified
+		}
 	}
 
-	if res.Role == "driver" {
-		v, _ := a.isVerified(c, res.ID)
+	if user.Role == "government" {
+		v, _ := a.IsGovVerified(c, user.ID)
 		if !v {
-			return res, helper.ErrNotVerified
+			if r, err := a.GetGovRejection(c, user.ID); err == nil {
+				return fmt.Errorf("%w: %s", helper.ErrRejected, r.RejectionReason)
+			}
+			return helper.ErrNotVerified
 		}
 	}
 
+	return nil
+}
+
+func (a *AuthRepoImpl) LoginUser(c context.Context, data dto.UserLoginReq) (res models.User, err error) {
+	if err := a.db.WithContext(c).First(&res, "email = ?", data.Email).Error; err != nil {
+		return res, helper.ErrNotFound
+	}
+
 	if err := bcrypt.CompareHashAndPassword([]byte(res.Password), []byte(data.Password)); err != nil {
 		return res, helper.ErrPasswordIncorrect
 	}
 
+	// Only after the password: a rejection carries the admin's reason.
+	if err := a.checkAccountStatus(c, res); err != nil {
+		return res, err
+	}
+
+	v, err := a.IsEmailVerified(c, res.ID)
+	if err != nil {
+		return res, err
+	}
+
+	if !v {
+		return res, helper.ErrEmailNotVerified
+	}
+
 	return res, nil
 }
 
 func (a *AuthRepoImpl) CreateUser(c context.Context, data models.User) (res string, err error) {
+	return a.createWithDetail(c, data)
+}
+
+func (a *AuthRepoImpl) CreateDriver(c context.Context, data models.User) (res string, err error) {
+	return a.createWithDetail(c, data)
+}
+
+// createWithDetail inserts the user together with the role detail set on it,
+// and any extra rows, in one transaction. Every Create* method goes through it.
+func (a *AuthRepoImpl) createWithDetail(c context.Context, data models.User, extra ...interface{}) (res string, err error) {
 	tx := a.db.WithContext(c).Begin()
 
 	defer func() {
@@ -124,12 +363,136 @@ func (a *AuthRepoImpl) CreateUser(c context.Context, data models.User) (res stri
 		return "", helper.ErrDatabase
 	}
 
-	tx.Commit()
+	for _, row := range extra {
+		if err := tx.Omit(clause.Associations).Create(row).Error; err != nil {
+			tx.Rollback()
+			return "", helper.ErrDatabase
+		}
+	}
+
+	if err := tx.Commit().Error; err != nil {
+		return "", helper.ErrDatabase
+	}
 
 	return data.ID, nil
 }
 
-func (a *AuthRepoImpl) CreateDriver(c context.Context, data models.User) (res string, err error) {
+func (a *AuthRepoImpl) CreateOwner(c context.Context, data models.User) (res string, err error) {
+	return a.createWithDetail(c, data)
+}
+
+func (a *AuthRepoImpl) CreateGov(c context.Context, data models.User) (res string, err error) {
+	return a.createWithDetail(c, data)
+}
+
+// CreateAdminInvite creates the admin together with the reset code the
+// invite links to. The code does not count against the reset limit.
+func (a *AuthRepoImpl) CreateAdminInvite(c context.Context, data models.User, code string, expiresAt time.Time) (res string, err error) {
+	return a.createWithDetail(c, data, &models.ResetPassword{
+		UserID:    data.ID,
+		Code:      code,
+		ExpiresAt: expiresAt,
+	})
+}
+
+// RenewAdminInvite replaces the invite code of an admin who has not set a
+// password yet. Any other account is ErrNotFound.
+func (a *AuthRepoImpl) RenewAdminInvite(c context.Context, id string, code string, expiresAt time.Time) (res models.User, err error) {
+	if err := a.db.WithContext(c).Preload("AdminDetail").
+		First(&res, "id = ? AND role = ? AND password = ?", id, "admin", "").Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return res, helper.ErrNotFound
+		}
+		return res, helper.ErrDatabase
+	}
+
+	if err := a.db.WithContext(c).Clauses(clause.OnConflict{
+		Columns:   []clause.Column{{Name: "user_id"}},
+		DoUpdates: clause.AssignmentColumns([]string{"code", "expires_at"}),
+	}).Omit("User").Create(&models.ResetPassword{
+		UserID:    id,
+		Code:      code,
+		ExpiresAt: expiresAt,
+	}).Error; err != nil {
+		return res, helper.ErrDatabase
+	}
+
+	return res, nil
+}
+
+func (a *AuthRepoImpl) SendResetPassword(c context.Context, email string, code string, expiresAt time.Time, limit int) (data models.ResetPassword, err error) {
+	var user models.User
+
+	if err := a.db.WithContext(c).First(&user, "email = ?", email).Error; err != nil {
+		return data, helper.ErrNotFound
+	}
+
+	now := time.Now()
+
+	if err := a.takeRequestSlot(c, &models.ResetPassword{UserID: user.ID, ExpiresAt: now, WindowStartedAt: now}, user.ID, limit, map[string]interface{}{
+		"code":       code,
+		"expires_at": expiresAt,
+	}); err != nil {
+		return data, err
+	}
+
+	var rp models.ResetPassword
+
+	if err := a.db.WithContext(c).First(&rp, "user_id = ?", user.ID).Error; err != nil {
+		return data, helper.ErrDatabase
+	}
+
+	rp.User = user
+
+	return rp, nil
+}
+
+// takeRequestSlot counts one code request against the user's hourly limit
+// and stores the new code, in a single conditional UPDATE so parallel
+// requests cannot go past limit. row names the table and is inserted first
+// if the user has none yet.
+func (a *AuthRepoImpl) takeRequestSlot(c context.Context, row interface{}, userID string, limit int, updates map[string]interface{}) error {
+	db := a.db.WithContext(c)
+
+	if err := db.Clauses(clause.OnConflict{DoNothing: true}).Omit("User").Create(row).Error; err != nil {
+		return helper.ErrDatabase
+	}
+
+	now := time.Now()
+	windowStart := now.Add(-time.Hour)
+
+	// Gorm writes map updates in key order, so request_count is computed
+	// from the old window_started_at on MySQL as well.
+	updates["request_count"] = gorm.Expr("CASE WHEN window_started_at <= ? THEN 1 ELSE request_count + 1 END", windowStart)
+	updates["window_started_at"] = gorm.Expr("CASE WHEN window_started_at <= ? THEN ? ELSE window_started_at END", windowStart, now)
+
+	q := db.Model(row).
+		Where("user_id = ? AND (request_count < ? OR window_started_at <= ?)", userID, limit, windowStart).
+		Updates(updates)
+
+	if q.Error != nil {
+		return helper.ErrDatabase
+	}
+
+	if q.RowsAffected == 0 {
+		var window struct{ WindowStartedAt time.Time }
+		if err := db.Model(row).Select("window_started_at").Where("user_id = ?", userID).Scan(&window).Error; err != nil {
+			return helper.ErrDatabase
+		}
+
+		return &helper.TooManyAttemptsError{RetryAfter: window.WindowStartedAt.Add(time.Hour).Sub(now)}
+	}
+
+	return nil
+}
+
+func (a *AuthRepoImpl) ResetPassword(c context.Context, password string, code string) (res string, err error) {
+	var rp models.ResetPassword
+
+	if err := a.db.WithContext(c).First(&rp, "code = ? AND expires_at > ?", code, time.Now()).Error; err != nil {
+		return "Link has expired or not valid", helper.ErrExpired
+	}
+
 	tx := a.db.WithContext(c).Begin()
 
 	defer func() {
@@ -138,65 +501,288 @@ func (a *AuthRepoImpl) CreateDriver(c context.Context, data models.User) (res st
 		}
 	}()
 
-	if err := tx.Create(&data).Error; err != nil {
+	// Burn the code first so two concurrent requests cannot both use it.
+	q := tx.Model(&models.ResetPassword{}).
+		Where("id = ? AND code = ?", rp.ID, code).
+		Updates(map[string]interface{}{"code": "", "expires_at": time.Now()})
+
+	if q.Error != nil {
+		tx.Rollback()
+		return "something happened", helper.ErrDatabase
+	}
+
+	if q.RowsAffected == 0 {
 		tx.Rollback()
+		return "Link has expired or not valid", helper.ErrExpired
+	}
 
-		var mysqlErr *mysql.MySQLError
+	if err := tx.Model(&models.User{}).Where("id = ?", rp.UserID).Update("password", password).Error; err != nil {
+		tx.Rollback()
+		return "something happened", helper.ErrDatabase
+	}
 
-		if errors.As(err, &mysqlErr) && mysqlErr.Number == 1062 {
-			return "", helper.ErrDuplicateEntry
+	tx.Commit()
+
+	return rp.UserID, nil
+}
+
+func (a *AuthRepoImpl) SendResetOTP(c context.Context, phone string, code string, expiresAt time.Time, limit int) (data models.ResetPasswordOTP, err error) {
+	var driver models.User
+
+	if err := a.db.WithContext(c).First(&driver, "phone_number = ? AND role = ?", phone, "driver").Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return data, helper.ErrNotFound
 		}
+		return data, helper.ErrDatabase
+	}
 
-		return "", helper.ErrDatabase
+	now := time.Now()
+
+	if err := a.takeRequestSlot(c, &models.ResetPasswordOTP{UserID: driver.ID, ExpiresAt: now, WindowStartedAt: now}, driver.ID, limit, map[string]interface{}{
+		"code":       code,
+		"expires_at": expiresAt,
+		"attempts":   0,
+	}); err != nil {
+		return data, err
+	}
+
+	if err := a.db.WithContext(c).First(&data, "user_id = ?", driver.ID).Error; err != nil {
+		return data, helper.ErrDatabase
+	}
+
+	return data, nil
+}
+
+func (a *AuthRepoImpl) ResetPasswordWithOTP(c context.Context, phone string, code string, password string, maxAttempts int) (res string, err error) {
+	var driver models.User
+
+	if err := a.db.WithContext(c).First(&driver, "phone_number = ? AND role = ?", phone, "driver").Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return res, helper.ErrOTPExpired
+		}
+		return res, helper.ErrDatabase
+	}
+
+	var otp models.ResetPasswordOTP
+
+	if err := a.db.WithContext(c).First(&otp, "user_id = ?", driver.ID).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return res, helper.ErrOTPExpired
+		}
+		return res, helper.ErrDatabase
+	}
+
+	if err := a.takeOTPAttempt(c, &models.ResetPasswordOTP{}, otp.ID, maxAttempts); err != nil {
+		return res, err
+	}
+
+	if subtle.ConstantTimeCompare([]byte(otp.Code), []byte(code)) != 1 {
+		return res, helper.ErrInvalidOTP
+	}
+
+	tx := a.db.WithContext(c).Begin()
+
+	defer func() {
+		if r := recover(); r != nil {
+			tx.Rollback()
+		}
+	}()
+
+	// Same as ResetPassword: burn the OTP before touching the password.
+	q := tx.Model(&models.ResetPasswordOTP{}).
+		Where("id = ? AND code = ?", otp.ID, code).
+		Updates(map[string]interface{}{"code": "", "expires_at": time.Now()})
+
+	if q.Error != nil {
+		tx.Rollback()
+		return res, helper.ErrDatabase
+	}
+
+	if q.RowsAffected == 0 {
+		tx.Rollback()
+		return res, helper.ErrOTPExpired
+	}
+
+	if err := tx.Model(&models.User{}).Where("id = ?", otp.UserID).Update("password", password).Error; err != nil {
+		tx.Rollback()
+		return res, helper.ErrDatabase
 	}
 
 	tx.Commit()
 
-	return data.ID, nil
+	return otp.UserID, nil
 }
 
-func (a *AuthRepoImpl) SendResetPassword(c context.Context, email string, code string) (data models.ResetPassword, err error) {
+// takeOTPAttempt spends one attempt of a live OTP before its code is
+// compared, so parallel guesses cannot go past maxAttempts.
+func (a *AuthRepoImpl) takeOTPAttempt(c context.Context, model interface{}, id int, maxAttempts int) error {
+	q := a.db.WithContext(c).Model(model).
+		Where("id = ? AND code <> '' AND expires_at > ? AND attempts < ?", id, time.Now(), maxAttempts).
+		Update("attempts", gorm.Expr("attempts + 1"))
+
+	if q.Error != nil {
+		return helper.ErrDatabase
+	}
+
+	if q.RowsAffected == 0 {
+		return helper.ErrOTPExpired
+	}
+
+	return nil
+}
+
+func (a *AuthRepoImpl) SendLoginOTP(c context.Context, phone string, code string, expiresAt time.Time, limit int) (data models.LoginOTP, err error) {
 	var user models.User
 
-	if err := a.db.WithContext(c).First(&user, "email = ?", email).Error; err != nil {
-		return data, helper.ErrNotFound
+	if err := a.db.WithContext(c).First(&user, "phone_number = ?", phone).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return data, helper.ErrNotFound
+		}
+		return data, helper.ErrDatabase
 	}
 
-	rp := models.ResetPassword{
-		UserID: user.ID,
-		Code:   code,
+	now := time.Now()
+
+	if err := a.takeRequestSlot(c, &models.LoginOTP{UserID: user.ID, ExpiresAt: now, WindowStartedAt: now}, user.ID, limit, map[string]interface{}{
+		"code":       code,
+		"expires_at": expiresAt,
+		"attempts":   0,
+	}); err != nil {
+		return data, err
 	}
 
-	if err := a.db.Clauses(clause.OnConflict{
-		Columns:   []clause.Column{{Name: "user_id"}},
-		DoUpdates: clause.AssignmentColumns([]string{"code"}),
-	}).Create(&rp).Error; err != nil {
+	if err := a.db.WithContext(c).First(&data, "user_id = ?", user.ID).Error; err != nil {
 		return data, helper.ErrDatabase
 	}
 
-	return rp, nil
+	return data, nil
 }
 
-func (a *AuthRepoImpl) ResetPassword(c context.Context, password string, code string) (res string, err error) {
-	var rp models.ResetPassword
+func (a *AuthRepoImpl) LoginWithOTP(c context.Context, phone string, code string, maxAttempts int) (res models.User, err error) {
+	if err := a.db.WithContext(c).First(&res, "phone_number = ?", phone).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return res, helper.ErrOTPExpired
+		}
+		return res, helper.ErrDatabase
+	}
 
-	if err := a.db.WithContext(c).First(&rp, "code = ?", code).Error; err != nil {
-		return "Link has expired or not valid", helper.ErrExpired
+	var otp models.LoginOTP
+
+	if err := a.db.WithContext(c).First(&otp, "user_id = ?", res.ID).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return res, helper.ErrOTPExpired
+		}
+		return res, helper.ErrDatabase
 	}
 
-	user := models.User{
-		Password: password,
+	if err := a.takeOTPAttempt(c, &models.LoginOTP{}, otp.ID, maxAttempts); err != nil {
+		return res, err
 	}
 
-	if err := a.db.WithContext(c).Where("id = ?", rp.UserID).Updates(&user).Error; err != nil {
-		return "something happened", helper.ErrDatabase
+	if subtle.ConstantTimeCompare([]byte(otp.Code), []byte(code)) != 1 {
+		return res, helper.ErrInvalidOTP
 	}
 
-	if err := a.db.WithContext(c).Delete(&rp).Error; err != nil {
-		return "something happened", helper.ErrDatabase
+	q := a.db.WithContext(c).Model(&models.LoginOTP{}).
+		Where("id = ? AND code = ?", otp.ID, code).
+		Updates(map[string]interface{}{"code": "", "expires_at": time.Now()})
+
+	if q.Error != nil {
+		return res, helper.ErrDatabase
+	}
+
+	if q.RowsAffected == 0 {
+		return res, helper.ErrOTPExpired
+	}
+
+	// The OTP proves ownership of the phone, so unlike LoginUser a pending
+	// email verification does not block this login.
+	if err := a.checkAccountStatus(c, res); err != nil {
+		return res, err
+	}
+
+	return res, nil
+}
+
+func (a *AuthRepoImpl) LoginWithIdentity(c context.Context, provider, subject string) (res models.User, err error) {
+	var identity models.LinkedIdentity
+
+	if err := a.db.WithContext(c).Preload("User").
+		First(&identity, "provider = ? AND subject = ?", provider, subject).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return res, helper.ErrNotFound
+		}
+		return res, helper.ErrDatabase
+	}
+
+	res = identity.User
+
+	if err := a.checkAccountStatus(c, res); err != nil {
+		return res, err
+	}
+
+	return res, nil
+}
+
+func (a *AuthRepoImpl) GetIdentity(c context.Context, provider, subject string) (res models.LinkedIdentity, err error) {
+	if err := a.db.WithContext(c).First(&res, "provider = ? AND subject = ?", provider, subject).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return res, helper.ErrNotFound
+		}
+		return res, helper.ErrDatabase
+	}
+
+	return res, nil
+}
+
+func (a *AuthRepoImpl) LinkIdentity(c context.Context, id, password string, data models.LinkedIdentity) (res models.LinkedIdentity, err error) {
+	var user models.User
+
+	if err := a.db.WithContext(c).First(&user, "id = ?", id).Error; err != nil {
+		return res, helper.ErrNotFound
+	}
+
+	if err := bcrypt.CompareHashAndPassword([]byte(user.Password), []byte(password)); err != nil {
+		return res, helper.ErrPasswordIncorrect
+	}
+
+	data.UserID = id
+
+	if err := a.db.WithContext(c).Omit("User").Create(&data).Error; err != nil {
+		var mysqlErr *mysql.MySQLError
+
+		if errors.As(err, &mysqlErr) && mysqlErr.Number == 1062 {
+			return res, helper.ErrDuplicateEntry
+		}
+
+		return res, helper.ErrDatabase
+	}
+
+	return data, nil
+}
+
+func (a *AuthRepoImpl) UnlinkIdentity(c context.Context, id, password, provider string) (err error) {
+	var user models.User
+
+	if err := a.db.WithContext(c).First(&user, "id = ?", id).Error; err != nil {
+		return helper.ErrNotFound
+	}
+
+	if err := bcrypt.CompareHashAndPassword([]byte(user.Password), []byte(password)); err != nil {
+		return helper.ErrPasswordIncorrect
+	}
+
+	q := a.db.WithContext(c).Delete(&models.LinkedIdentity{}, "user_id = ? AND provider = ?", id, provider)
+
+	if q.Error != nil {
+		return helper.ErrDatabase
+	}
+
+	if q.RowsAffected == 0 {
+		return helper.ErrNotFound
 	}
 
-	return "Success", nil
+	return nil
 }
 
 func NewAuthRepo(db *gorm.DB) AuthRepo {
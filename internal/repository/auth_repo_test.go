@@ -0,0 +1,202 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/GabrielMoody/mikronet-auth-service/internal/dto"
+	"github.com/GabrielMoody/mikronet-auth-service/internal/helper"
+	"github.com/GabrielMoody/mikronet-auth-service/internal/models"
+	"github.com/GabrielMoody/mikronet-auth-service/internal/testdb"
+	"golang.org/x/crypto/bcrypt"
+)
+
+func TestLoginUser(t *testing.T) {
+	ctx := context.Background()
+	db := testdb.New(t)
+	repo := NewAuthRepo(db)
+
+	hashed, _ := bcrypt.GenerateFromPassword([]byte("password123"), bcrypt.MinCost)
+
+	driver := models.User{
+		ID:       "driver-1",
+		Email:    "driver@example.com",
+		Password: string(hashed),
+		Role:     "driver",
+		DriverDetail: models.DriverDetails{
+			ID:   "driver-1",
+			Name: "Driver",
+		},
+	}
+	if err := db.Create(&driver).Error; err != nil {
+		t.Fatalf("error while creating driver: %v", err)
+	}
+
+	if err := db.Create(&models.DriverVerification{DriverID: "driver-1", AdminID: "admin-1", Status: "rejected", Reason: "SIM tidak terbaca"}).Error; err != nil {
+		t.Fatalf("error while rejecting driver: %v", err)
+	}
+
+	tests := []struct {
+		name       string
+		req        dto.UserLoginReq
+		wantErr    error
+		wantReason bool
+	}{
+		{"unknown email", dto.UserLoginReq{Email: "nobody@example.com", Password: "password123"}, helper.ErrNotFound, false},
+		// The rejection reason must not leak to someone without the password.
+		{"rejected driver with wrong password", dto.UserLoginReq{Email: "driver@example.com", Password: "wrong-password"}, helper.ErrPasswordIncorrect, false},
+		{"rejected driver with password", dto.UserLoginReq{Email: "driver@example.com", Password: "password123"}, helper.ErrRejected, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := repo.LoginUser(ctx, tt.req)
+
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("LoginUser error = %v, want %v", err, tt.wantErr)
+			}
+
+			if got := strings.Contains(err.Error(), "SIM tidak terbaca"); got != tt.wantReason {
+				t.Errorf("error %q shows the reason: %v, want %v", err, got, tt.wantReason)
+			}
+		})
+	}
+}
+
+func TestResetPasswordWithOTPAttempts(t *testing.T) {
+	ctx := context.Background()
+	db := testdb.New(t)
+	// One connection still interleaves the goroutines between statements,
+	// which is where a separate check and increment lets extra guesses in.
+	sqlDB, _ := db.DB()
+	sqlDB.SetMaxOpenConns(1)
+	repo := NewAuthRepo(db)
+
+	const maxAttempts, guesses = 5, 20
+
+	phone := "+6281234567890"
+	driver := models.User{
+		ID:          "driver-1",
+		Email:       "driver@example.com",
+		PhoneNumber: &phone,
+		Role:        "driver",
+		DriverDetail: models.DriverDetails{
+			ID:          "driver-1",
+			Name:        "Driver",
+			PhoneNumber: "+6281234567890",
+		},
+	}
+	if err := db.Create(&driver).Error; err != nil {
+		t.Fatalf("error while creating driver: %v", err)
+	}
+
+	if _, err := repo.SendResetOTP(ctx, "+6281234567890", helper.HashToken("123456"), time.Now().Add(time.Minute), 3); err != nil {
+		t.Fatalf("error while sending OTP: %v", err)
+	}
+
+	var invalid atomic.Int64
+	var wg sync.WaitGroup
+	for i := 0; i < guesses; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			_, err := repo.ResetPasswordWithOTP(ctx, "+6281234567890", helper.HashToken("000000"), "hashed", maxAttempts)
+			switch {
+			case errors.Is(err, helper.ErrInvalidOTP):
+				invalid.Add(1)
+			case !errors.Is(err, helper.ErrOTPExpired):
+				t.Errorf("unexpected error: %v", err)
+			}
+		}()
+	}
+	wg.Wait()
+
+	if got := invalid.Load(); got != maxAttempts {
+		t.Errorf("%d guesses were compared, want %d", got, maxAttempts)
+	}
+
+	// The right code is no use once the attempts are spent.
+	if _, err := repo.ResetPasswordWithOTP(ctx, "+6281234567890", helper.HashToken("123456"), "hashed", maxAttempts); !errors.Is(err, helper.ErrOTPExpired) {
+		t.Errorf("expected %v after the attempts ran out, got %v", helper.ErrOTPExpired, err)
+	}
+}
+
+func TestSendResetOTPOnlyForDrivers(t *testing.T) {
+	ctx := context.Background()
+	db := testdb.New(t)
+	repo := NewAuthRepo(db)
+
+	phone := "+6281234567890"
+	user := models.User{ID: "user-1", Email: "user@example.com", PhoneNumber: &phone, Role: "user"}
+	if err := db.Create(&user).Error; err != nil {
+		t.Fatalf("error while creating user: %v", err)
+	}
+
+	if _, err := repo.SendResetOTP(ctx, phone, helper.HashToken("123456"), time.Now().Add(time.Minute), 3); !errors.Is(err, helper.ErrNotFound) {
+		t.Errorf("SendResetOTP for a passenger = %v, want %v", err, helper.ErrNotFound)
+	}
+
+	if _, err := repo.ResetPasswordWithOTP(ctx, phone, helper.HashToken("123456"), "hashed", 5); !errors.Is(err, helper.ErrOTPExpired) {
+		t.Errorf("ResetPasswordWithOTP for a passenger = %v, want %v", err, helper.ErrOTPExpired)
+	}
+}
+
+func TestSendResetPasswordLimit(t *testing.T) {
+	ctx := context.Background()
+	db := testdb.New(t)
+	sqlDB, _ := db.DB()
+	sqlDB.SetMaxOpenConns(1)
+	repo := NewAuthRepo(db)
+
+	const limit, requests = 3, 20
+
+	user := models.User{ID: "user-1", Email: "user@example.com", Role: "passenger"}
+	if err := db.Create(&user).Error; err != nil {
+		t.Fatalf("error while creating user: %v", err)
+	}
+
+	var sent atomic.Int64
+	var wg sync.WaitGroup
+	for i := 0; i < requests; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			_, err := repo.SendResetPassword(ctx, "user@example.com", helper.HashToken("code"), time.Now().Add(time.Hour), limit)
+			var tooMany *helper.TooManyAttemptsError
+			switch {
+			case err == nil:
+				sent.Add(1)
+			case errors.As(err, &tooMany):
+				if tooMany.RetryAfter <= 0 || tooMany.RetryAfter > time.Hour {
+					t.Errorf("unexpected retry after %v", tooMany.RetryAfter)
+				}
+			default:
+				t.Errorf("unexpected error: %v", err)
+			}
+		}()
+	}
+	wg.Wait()
+
+	if got := sent.Load(); got != limit {
+		t.Errorf("%d requests were sent, want %d", got, limit)
+	}
+
+	// Once the window has passed the count starts over.
+	if err := db.Model(&models.ResetPassword{}).Where("user_id = ?", user.ID).
+		Update("window_started_at", time.Now().Add(-2*time.Hour)).Error; err != nil {
+		t.Fatalf("error while moving the window back: %v", err)
+	}
+
+	rp, err := repo.SendResetPassword(ctx, "user@example.com", helper.HashToken("code"), time.Now().Add(time.Hour), limit)
+	if err != nil {
+		t.Fatalf("expected a request after the window passed, got %v", err)
+	}
+	if rp.RequestCount != 1 {
+		t.Errorf("request count is %d, want 1", rp.RequestCount)
+	}
+}
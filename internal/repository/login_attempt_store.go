@@ -0,0 +1,155 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"time"
+
+	"github.com/GabrielMoody/mikronet-auth-service/internal/helper"
+	"github.com/GabrielMoody/mikronet-auth-service/internal/models"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// LoginAttemptStore keeps failed login counters keyed by email or client IP.
+type LoginAttemptStore interface {
+	GetAttempt(c context.Context, key string) (res models.LoginAttempt, err error)
+	// AddFailure counts one failure in a single atomic step, starting over
+	// when the previous failure is older than window, and returns the
+	// updated counter so concurrent failures never see the same count.
+	AddFailure(c context.Context, key string, window time.Duration) (res models.LoginAttempt, err error)
+	// LockAttempt locks key until the given time unless it is already locked
+	// for longer.
+	LockAttempt(c context.Context, key string, until time.Time) (err error)
+	ResetAttempt(c context.Context, key string) (err error)
+}
+
+type LoginAttemptStoreImpl struct {
+	db *gorm.DB
+}
+
+func (a *LoginAttemptStoreImpl) GetAttempt(c context.Context, key string) (res models.LoginAttempt, err error) {
+	if err := a.db.WithContext(c).First(&res, "`key` = ?", key).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return models.LoginAttempt{Key: key}, nil
+		}
+		return res, helper.ErrDatabase
+	}
+
+	return res, nil
+}
+
+func (a *LoginAttemptStoreImpl) AddFailure(c context.Context, key string, window time.Duration) (res models.LoginAttempt, err error) {
+	now := time.Now()
+
+	data := models.LoginAttempt{
+		Key:           key,
+		Failures:      1,
+		LastFailureAt: now,
+	}
+
+	// Assignments are applied in key order, so failures is computed from the
+	// previous last_failure_at.
+	if err := a.db.WithContext(c).Clauses(clause.OnConflict{
+		Columns: []clause.Column{{Name: "key"}},
+		DoUpdates: clause.Assignments(map[string]interface{}{
+			"failures":        gorm.Expr("CASE WHEN last_failure_at < ? THEN 1 ELSE failures + 1 END", now.Add(-window)),
+			"last_failure_at": now,
+		}),
+	}).Create(&data).Error; err != nil {
+		return res, helper.ErrDatabase
+	}
+
+	return a.GetAttempt(c, key)
+}
+
+func (a *LoginAttemptStoreImpl) LockAttempt(c context.Context, key string, until time.Time) (err error) {
+	if err := a.db.WithContext(c).
+		Model(&models.LoginAttempt{}).
+		Where("`key` = ? AND (locked_until IS NULL OR locked_until < ?)", key, until).
+		Update("locked_until", until).Error; err != nil {
+		return helper.ErrDatabase
+	}
+
+	return nil
+}
+
+func (a *LoginAttemptStoreImpl) ResetAttempt(c context.Context, key string) (err error) {
+	if err := a.db.WithContext(c).Delete(&models.LoginAttempt{}, "`key` = ?", key).Error; err != nil {
+		return helper.ErrDatabase
+	}
+
+	return nil
+}
+
+func NewLoginAttemptStore(db *gorm.DB) LoginAttemptStore {
+	return &LoginAttemptStoreImpl{
+		db: db,
+	}
+}
+
+// MemoryLoginAttemptStore is a process-local LoginAttemptStore meant for tests.
+type MemoryLoginAttemptStore struct {
+	mu       sync.Mutex
+	attempts map[string]models.LoginAttempt
+}
+
+func (a *MemoryLoginAttemptStore) GetAttempt(c context.Context, key string) (res models.LoginAttempt, err error) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	if res, ok := a.attempts[key]; ok {
+		return res, nil
+	}
+
+	return models.LoginAttempt{Key: key}, nil
+}
+
+func (a *MemoryLoginAttemptStore) AddFailure(c context.Context, key string, window time.Duration) (res models.LoginAttempt, err error) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	now := time.Now()
+
+	res, ok := a.attempts[key]
+	if !ok || now.Sub(res.LastFailureAt) > window {
+		res = models.LoginAttempt{Key: key, LockedUntil: res.LockedUntil}
+	}
+
+	res.Failures++
+	res.LastFailureAt = now
+	a.attempts[key] = res
+
+	return res, nil
+}
+
+func (a *MemoryLoginAttemptStore) LockAttempt(c context.Context, key string, until time.Time) (err error) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	res, ok := a.attempts[key]
+	if !ok || (res.LockedUntil != nil && !res.LockedUntil.Before(until)) {
+		return nil
+	}
+
+	res.LockedUntil = &until
+	a.attempts[key] = res
+
+	return nil
+}
+
+func (a *MemoryLoginAttemptStore) ResetAttempt(c context.Context, key string) (err error) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	delete(a.attempts, key)
+
+	return nil
+}
+
+func NewMemoryLoginAttemptStore() LoginAttemptStore {
+	return &MemoryLoginAttemptStore{
+		attempts: make(map[string]models.LoginAttempt),
+	}
+}
@@ -0,0 +1,160 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/GabrielMoody/mikronet-auth-service/internal/helper"
+	"github.com/GabrielMoody/mikronet-auth-service/internal/models"
+	"github.com/go-sql-driver/mysql"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+type OAuthRepo interface {
+	CreateClient(c context.Context, data models.OAuthClient) (res models.OAuthClient, err error)
+	GetClient(c context.Context, id string) (res models.OAuthClient, err error)
+	GetClients(c context.Context) (res []models.OAuthClient, err error)
+	DeleteClient(c context.Context, id string) (err error)
+	CreateAuthorizationCode(c context.Context, data models.AuthorizationCode) (err error)
+	ConsumeAuthorizationCode(c context.Context, code string) (res models.AuthorizationCode, err error)
+}
+
+type OAuthRepoImpl struct {
+	db *gorm.DB
+}
+
+func (a *OAuthRepoImpl) CreateClient(c context.Context, data models.OAuthClient) (res models.OAuthClient, err error) {
+	if err := a.db.WithContext(c).Create(&data).Error; err != nil {
+		var mysqlErr *mysql.MySQLError
+
+		if errors.As(err, &mysqlErr) && mysqlErr.Number == 1062 {
+			return res, helper.ErrDuplicateEntry
+		}
+
+		return res, helper.ErrDatabase
+	}
+
+	return data, nil
+}
+
+func (a *OAuthRepoImpl) GetClient(c context.Context, id string) (res models.OAuthClient, err error) {
+	if err := a.db.WithContext(c).First(&res, "id = ?", id).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return res, helper.ErrNotFound
+		}
+		return res, helper.ErrDatabase
+	}
+
+	return res, nil
+}
+
+func (a *OAuthRepoImpl) GetClients(c context.Context) (res []models.OAuthClient, err error) {
+	if err := a.db.WithContext(c).Order("created_at asc").Find(&res).Error; err != nil {
+		return res, helper.ErrDatabase
+	}
+
+	return res, nil
+}
+
+func (a *OAuthRepoImpl) DeleteClient(c context.Context, id string) (err error) {
+	tx := a.db.WithContext(c).Begin()
+
+	defer func() {
+		if r := recover(); r != nil {
+			tx.Rollback()
+		}
+	}()
+
+	if err := tx.Delete(&models.AuthorizationCode{}, "client_id = ?", id).Error; err != nil {
+		tx.Rollback()
+		return helper.ErrDatabase
+	}
+
+	// The client's sessions end with it: their refresh families are revoked
+	// and the sids blacklisted so access tokens already issued stop working.
+	var sessionIDs []string
+	if err := tx.Model(&models.Session{}).Where("client_id = ?", id).Pluck("id", &sessionIDs).Error; err != nil {
+		tx.Rollback()
+		return helper.ErrDatabase
+	}
+
+	if len(sessionIDs) > 0 {
+		now := time.Now()
+
+		if err := tx.Model(&models.RefreshToken{}).
+			Where("family_id IN ? AND revoked_at IS NULL", sessionIDs).
+			Update("revoked_at", now).Error; err != nil {
+			tx.Rollback()
+			return helper.ErrDatabase
+		}
+
+		revoked := make([]models.RevokedToken, 0, len(sessionIDs))
+		for _, sid := range sessionIDs {
+			revoked = append(revoked, models.RevokedToken{ID: sid, ExpiresAt: now.Add(helper.AccessTokenTTL)})
+		}
+
+		if err := tx.Clauses(clause.OnConflict{DoNothing: true}).Create(&revoked).Error; err != nil {
+			tx.Rollback()
+			return helper.ErrDatabase
+		}
+	}
+
+	q := tx.Delete(&models.OAuthClient{}, "id = ?", id)
+
+	if q.Error != nil {
+		tx.Rollback()
+		return helper.ErrDatabase
+	}
+
+	if q.RowsAffected == 0 {
+		tx.Rollback()
+		return helper.ErrNotFound
+	}
+
+	tx.Commit()
+
+	return nil
+}
+
+func (a *OAuthRepoImpl) CreateAuthorizationCode(c context.Context, data models.AuthorizationCode) (err error) {
+	if err := a.db.WithContext(c).Omit("Client", "User").Create(&data).Error; err != nil {
+		return helper.ErrDatabase
+	}
+
+	return nil
+}
+
+// ConsumeAuthorizationCode marks the code as used, so it can be exchanged
+// only once even when two requests race.
+func (a *OAuthRepoImpl) ConsumeAuthorizationCode(c context.Context, code string) (res models.AuthorizationCode, err error) {
+	if err := a.db.WithContext(c).First(&res, "id = ?", code).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return res, helper.ErrInvalidGrant
+		}
+		return res, helper.ErrDatabase
+	}
+
+	now := time.Now()
+
+	q := a.db.WithContext(c).Model(&models.AuthorizationCode{}).
+		Where("id = ? AND used_at IS NULL AND expires_at > ?", code, now).
+		Update("used_at", now)
+
+	if q.Error != nil {
+		return res, helper.ErrDatabase
+	}
+
+	if q.RowsAffected == 0 {
+		return res, helper.ErrInvalidGrant
+	}
+
+	return res, nil
+}
+
+func NewOAuthRepo(db *gorm.DB) OAuthRepo {
+	return &OAuthRepoImpl{
+		db: db,
+	}
+}
@@ -0,0 +1,61 @@
+package repository
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/GabrielMoody/mikronet-auth-service/internal/models"
+	"github.com/GabrielMoody/mikronet-auth-service/internal/testdb"
+	"gorm.io/gorm/clause"
+)
+
+func TestDeleteClientEndsItsSessions(t *testing.T) {
+	ctx := context.Background()
+	db := testdb.New(t)
+	repo := NewOAuthRepo(db)
+	store := NewRevocationStore(db)
+
+	if err := db.Omit(clause.Associations).Create(&models.User{ID: "user-1", Email: "user@example.com", Role: "user"}).Error; err != nil {
+		t.Fatalf("error while creating user: %v", err)
+	}
+
+	for _, id := range []string{"app", "other"} {
+		if _, err := repo.CreateClient(ctx, models.OAuthClient{ID: id, Name: id}); err != nil {
+			t.Fatalf("error while creating client %s: %v", id, err)
+		}
+	}
+
+	// One session each for the deleted client, another client and the
+	// first-party apps.
+	for sid, clientID := range map[string]string{"sid-app": "app", "sid-other": "other", "sid-first-party": ""} {
+		if err := db.Omit(clause.Associations).Create(&models.Session{ID: sid, UserID: "user-1", ClientID: clientID}).Error; err != nil {
+			t.Fatalf("error while creating session %s: %v", sid, err)
+		}
+		if err := db.Omit(clause.Associations).Create(&models.RefreshToken{ID: "rt-" + sid, FamilyID: sid, UserID: "user-1", ExpiresAt: time.Now().Add(time.Hour)}).Error; err != nil {
+			t.Fatalf("error while creating refresh token for %s: %v", sid, err)
+		}
+	}
+
+	if err := repo.DeleteClient(ctx, "app"); err != nil {
+		t.Fatalf("error while deleting client: %v", err)
+	}
+
+	for sid, wantRevoked := range map[string]bool{"sid-app": true, "sid-other": false, "sid-first-party": false} {
+		var rt models.RefreshToken
+		if err := db.First(&rt, "id = ?", "rt-"+sid).Error; err != nil {
+			t.Fatalf("error while reading refresh token for %s: %v", sid, err)
+		}
+		if got := rt.RevokedAt != nil; got != wantRevoked {
+			t.Errorf("refresh token of %s revoked = %v, want %v", sid, got, wantRevoked)
+		}
+
+		revoked, err := store.IsRevoked(ctx, []string{sid}, "user-1", time.Now())
+		if err != nil {
+			t.Fatalf("error while checking %s: %v", sid, err)
+		}
+		if revoked != wantRevoked {
+			t.Errorf("%s blacklisted = %v, want %v", sid, revoked, wantRevoked)
+		}
+	}
+}
@@ -0,0 +1,189 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"time"
+
+	"github.com/GabrielMoody/mikronet-auth-service/internal/helper"
+	"github.com/GabrielMoody/mikronet-auth-service/internal/models"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// RevocationStore keeps track of access and refresh tokens that must no longer
+// be accepted even though their signature and expiry are still valid.
+type RevocationStore interface {
+	RevokeToken(c context.Context, jti string, expiresAt time.Time) (err error)
+	RevokeUserTokens(c context.Context, userID string, before time.Time) (err error)
+	// IsRevoked checks ids (the token's jti and, for access tokens, its
+	// session id) against RevokeToken and issuedAt against the user's cutoff.
+	// iat only has second precision, so the cutoff is kept truncated to the
+	// second and a token issued in that second stays valid; otherwise the
+	// tokens handed out right after the cutoff would be revoked with it.
+	IsRevoked(c context.Context, ids []string, userID string, issuedAt time.Time) (res bool, err error)
+	// PruneExpired forgets revoked tokens that have expired anyway and
+	// cutoffs older than any token still in circulation.
+	PruneExpired(c context.Context) (res int64, err error)
+}
+
+type RevocationStoreImpl struct {
+	db *gorm.DB
+}
+
+func (a *RevocationStoreImpl) RevokeToken(c context.Context, jti string, expiresAt time.Time) (err error) {
+	rt := models.RevokedToken{
+		ID:        jti,
+		ExpiresAt: expiresAt,
+	}
+
+	if err := a.db.WithContext(c).Clauses(clause.OnConflict{DoNothing: true}).Create(&rt).Error; err != nil {
+		return helper.ErrDatabase
+	}
+
+	return nil
+}
+
+func (a *RevocationStoreImpl) RevokeUserTokens(c context.Context, userID string, before time.Time) (err error) {
+	cutoff := models.UserTokenCutoff{
+		UserID:        userID,
+		RevokedBefore: before.Truncate(time.Second),
+	}
+
+	if err := a.db.WithContext(c).Clauses(clause.OnConflict{
+		Columns:   []clause.Column{{Name: "user_id"}},
+		DoUpdates: clause.AssignmentColumns([]string{"revoked_before"}),
+	}).Create(&cutoff).Error; err != nil {
+		return helper.ErrDatabase
+	}
+
+	return nil
+}
+
+func (a *RevocationStoreImpl) IsRevoked(c context.Context, ids []string, userID string, issuedAt time.Time) (res bool, err error) {
+	if len(ids) > 0 {
+		var rt models.RevokedToken
+		err := a.db.WithContext(c).First(&rt, "id IN ?", ids).Error
+		if err == nil {
+			return true, nil
+		}
+		if !errors.Is(err, gorm.ErrRecordNotFound) {
+			return false, helper.ErrDatabase
+		}
+	}
+
+	var cutoff models.UserTokenCutoff
+	if err := a.db.WithContext(c).First(&cutoff, "user_id = ?", userID).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return false, nil
+		}
+		return false, helper.ErrDatabase
+	}
+
+	return issuedAt.Unix() < cutoff.RevokedBefore.Unix(), nil
+}
+
+func (a *RevocationStoreImpl) PruneExpired(c context.Context) (res int64, err error) {
+	now := time.Now()
+
+	q := a.db.WithContext(c).Where("expires_at < ?", now).Delete(&models.RevokedToken{})
+	if q.Error != nil {
+		return res, helper.ErrDatabase
+	}
+
+	res = q.RowsAffected
+
+	q = a.db.WithContext(c).Where("revoked_before < ?", now.Add(-helper.RefreshTokenTTL)).Delete(&models.UserTokenCutoff{})
+	if q.Error != nil {
+		return res, helper.ErrDatabase
+	}
+
+	return res + q.RowsAffected, nil
+}
+
+func NewRevocationStore(db *gorm.DB) RevocationStore {
+	return &RevocationStoreImpl{
+		db: db,
+	}
+}
+
+// MemoryRevocationStore is a process-local RevocationStore meant for tests and
+// single-instance development setups.
+type MemoryRevocationStore struct {
+	mu      sync.RWMutex
+	tokens  map[string]time.Time
+	cutoffs map[string]time.Time
+}
+
+func (a *MemoryRevocationStore) RevokeToken(c context.Context, jti string, expiresAt time.Time) (err error) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	now := time.Now()
+	for id, exp := range a.tokens {
+		if exp.Before(now) {
+			delete(a.tokens, id)
+		}
+	}
+
+	a.tokens[jti] = expiresAt
+
+	return nil
+}
+
+func (a *MemoryRevocationStore) RevokeUserTokens(c context.Context, userID string, before time.Time) (err error) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	a.cutoffs[userID] = before.Truncate(time.Second)
+
+	return nil
+}
+
+func (a *MemoryRevocationStore) IsRevoked(c context.Context, ids []string, userID string, issuedAt time.Time) (res bool, err error) {
+	a.mu.RLock()
+	defer a.mu.RUnlock()
+
+	for _, id := range ids {
+		if _, ok := a.tokens[id]; ok {
+			return true, nil
+		}
+	}
+
+	if cutoff, ok := a.cutoffs[userID]; ok {
+		return issuedAt.Unix() < cutoff.Unix(), nil
+	}
+
+	return false, nil
+}
+
+func (a *MemoryRevocationStore) PruneExpired(c context.Context) (res int64, err error) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	now := time.Now()
+
+	for id, exp := range a.tokens {
+		if exp.Before(now) {
+			delete(a.tokens, id)
+			res++
+		}
+	}
+
+	for userID, cutoff := range a.cutoffs {
+		if cutoff.Before(now.Add(-helper.RefreshTokenTTL)) {
+			delete(a.cutoffs, userID)
+			res++
+		}
+	}
+
+	return res, nil
+}
+
+func NewMemoryRevocationStore() RevocationStore {
+	return &MemoryRevocationStore{
+		tokens:  make(map[string]time.Time),
+		cutoffs: make(map[string]time.Time),
+	}
+}
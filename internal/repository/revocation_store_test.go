@@ -0,0 +1,140 @@
+package repository
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/GabrielMoody/mikronet-auth-service/internal/helper"
+	"github.com/GabrielMoody/mikronet-auth-service/internal/models"
+	"github.com/GabrielMoody/mikronet-auth-service/internal/testdb"
+	"gorm.io/gorm/clause"
+)
+
+var revocationStores = map[string]func(t *testing.T) RevocationStore{
+	"db": func(t *testing.T) RevocationStore {
+		db := testdb.New(t)
+		for _, id := range []string{"user-1", "user-2"} {
+			if err := db.Omit(clause.Associations).Create(&models.User{ID: id, Email: id + "@example.com", Role: "user"}).Error; err != nil {
+				t.Fatalf("error while creating user: %v", err)
+			}
+		}
+		return NewRevocationStore(db)
+	},
+	"memory": func(t *testing.T) RevocationStore {
+		return NewMemoryRevocationStore()
+	},
+}
+
+func TestRevocationStore(t *testing.T) {
+	ctx := context.Background()
+	// A whole second plus a fraction, like time.Now() when "logout all" runs.
+	cutoff := time.Unix(1_700_000_000, 600_000_000)
+
+	tests := []struct {
+		name     string
+		ids      []string
+		userID   string
+		issuedAt time.Time
+		want     bool
+	}{
+		{"revoked jti", []string{"jti-revoked"}, "user-2", cutoff, true},
+		{"revoked session id", []string{"jti-other", "sid-revoked"}, "user-2", cutoff, true},
+		{"unknown ids and no cutoff", []string{"jti-other"}, "user-2", cutoff.Add(-time.Hour), false},
+		{"issued a second before the cutoff", nil, "user-1", cutoff.Add(-time.Second).Truncate(time.Second), true},
+		// Tokens issued right after "logout all", in the same second, must
+		// keep working.
+		{"issued in the cutoff's second", nil, "user-1", cutoff.Truncate(time.Second), false},
+		{"issued after the cutoff", nil, "user-1", cutoff.Add(time.Second), false},
+	}
+
+	for name, newStore := range revocationStores {
+		t.Run(name, func(t *testing.T) {
+			store := newStore(t)
+			expires := time.Now().Add(time.Hour)
+
+			for _, id := range []string{"jti-revoked", "sid-revoked"} {
+				if err := store.RevokeToken(ctx, id, expires); err != nil {
+					t.Fatalf("error while revoking %s: %v", id, err)
+				}
+			}
+
+			// Revoking twice must not fail on the primary key.
+			if err := store.RevokeToken(ctx, "jti-revoked", expires); err != nil {
+				t.Fatalf("error while revoking twice: %v", err)
+			}
+
+			if err := store.RevokeUserTokens(ctx, "user-1", cutoff); err != nil {
+				t.Fatalf("error while setting cutoff: %v", err)
+			}
+
+			for _, tt := range tests {
+				t.Run(tt.name, func(t *testing.T) {
+					got, err := store.IsRevoked(ctx, tt.ids, tt.userID, tt.issuedAt)
+					if err != nil {
+						t.Fatalf("unexpected error: %v", err)
+					}
+					if got != tt.want {
+						t.Errorf("IsRevoked = %v, want %v", got, tt.want)
+					}
+				})
+			}
+		})
+	}
+}
+
+func TestRevocationStorePruneExpired(t *testing.T) {
+	ctx := context.Background()
+	now := time.Now()
+
+	for name, newStore := range revocationStores {
+		t.Run(name, func(t *testing.T) {
+			store := newStore(t)
+
+			if err := store.RevokeToken(ctx, "jti-live", now.Add(time.Hour)); err != nil {
+				t.Fatalf("error while revoking: %v", err)
+			}
+			if err := store.RevokeToken(ctx, "jti-expired", now.Add(-time.Minute)); err != nil {
+				t.Fatalf("error while revoking: %v", err)
+			}
+			if err := store.RevokeUserTokens(ctx, "user-1", now.Add(-helper.RefreshTokenTTL-time.Minute)); err != nil {
+				t.Fatalf("error while setting cutoff: %v", err)
+			}
+			if err := store.RevokeUserTokens(ctx, "user-2", now); err != nil {
+				t.Fatalf("error while setting cutoff: %v", err)
+			}
+
+			res, err := store.PruneExpired(ctx)
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if res != 2 {
+				t.Errorf("PruneExpired = %d, want 2", res)
+			}
+
+			// Pruned entries are gone, live ones still apply.
+			tests := []struct {
+				name     string
+				ids      []string
+				userID   string
+				issuedAt time.Time
+				want     bool
+			}{
+				{"expired jti", []string{"jti-expired"}, "user-3", now, false},
+				{"live jti", []string{"jti-live"}, "user-3", now, true},
+				{"stale cutoff", nil, "user-1", now.Add(-helper.RefreshTokenTTL - time.Hour), false},
+				{"recent cutoff", nil, "user-2", now.Add(-time.Minute), true},
+			}
+
+			for _, tt := range tests {
+				got, err := store.IsRevoked(ctx, tt.ids, tt.userID, tt.issuedAt)
+				if err != nil {
+					t.Fatalf("%s: unexpected error: %v", tt.name, err)
+				}
+				if got != tt.want {
+					t.Errorf("%s: IsRevoked = %v, want %v", tt.name, got, tt.want)
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,93 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/GabrielMoody/mikronet-auth-service/internal/helper"
+	"github.com/GabrielMoody/mikronet-auth-service/internal/models"
+	"github.com/go-sql-driver/mysql"
+	"gorm.io/gorm"
+)
+
+type ServiceAccountRepo interface {
+	CreateServiceAccount(c context.Context, data models.ServiceAccount) (res models.ServiceAccount, err error)
+	GetServiceAccount(c context.Context, id string) (res models.ServiceAccount, err error)
+	GetServiceAccounts(c context.Context) (res []models.ServiceAccount, err error)
+	RotateServiceAccountSecret(c context.Context, id, secretHash string) (res models.ServiceAccount, err error)
+	DeleteServiceAccount(c context.Context, id string) (err error)
+}
+
+type ServiceAccountRepoImpl struct {
+	db *gorm.DB
+}
+
+func (a *ServiceAccountRepoImpl) CreateServiceAccount(c context.Context, data models.ServiceAccount) (res models.ServiceAccount, err error) {
+	if err := a.db.WithContext(c).Create(&data).Error; err != nil {
+		var mysqlErr *mysql.MySQLError
+
+		if errors.As(err, &mysqlErr) && mysqlErr.Number == 1062 {
+			return res, helper.ErrDuplicateEntry
+		}
+
+		return res, helper.ErrDatabase
+	}
+
+	return data, nil
+}
+
+func (a *ServiceAccountRepoImpl) GetServiceAccount(c context.Context, id string) (res models.ServiceAccount, err error) {
+	if err := a.db.WithContext(c).First(&res, "id = ?", id).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return res, helper.ErrNotFound
+		}
+		return res, helper.ErrDatabase
+	}
+
+	return res, nil
+}
+
+func (a *ServiceAccountRepoImpl) GetServiceAccounts(c context.Context) (res []models.ServiceAccount, err error) {
+	if err := a.db.WithContext(c).Order("created_at asc").Find(&res).Error; err != nil {
+		return res, helper.ErrDatabase
+	}
+
+	return res, nil
+}
+
+func (a *ServiceAccountRepoImpl) RotateServiceAccountSecret(c context.Context, id, secretHash string) (res models.ServiceAccount, err error) {
+	q := a.db.WithContext(c).Model(&models.ServiceAccount{}).
+		Where("id = ?", id).
+		Updates(map[string]interface{}{"secret_hash": secretHash, "secret_rotated_at": time.Now()})
+
+	if q.Error != nil {
+		return res, helper.ErrDatabase
+	}
+
+	if q.RowsAffected == 0 {
+		return res, helper.ErrNotFound
+	}
+
+	return a.GetServiceAccount(c, id)
+}
+
+func (a *ServiceAccountRepoImpl) DeleteServiceAccount(c context.Context, id string) (err error) {
+	q := a.db.WithContext(c).Delete(&models.ServiceAccount{}, "id = ?", id)
+
+	if q.Error != nil {
+		return helper.ErrDatabase
+	}
+
+	if q.RowsAffected == 0 {
+		return helper.ErrNotFound
+	}
+
+	return nil
+}
+
+func NewServiceAccountRepo(db *gorm.DB) ServiceAccountRepo {
+	return &ServiceAccountRepoImpl{
+		db: db,
+	}
+}
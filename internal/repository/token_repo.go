@@ -0,0 +1,167 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/GabrielMoody/mikronet-auth-service/internal/helper"
+	"github.com/GabrielMoody/mikronet-auth-service/internal/models"
+	"gorm.io/gorm"
+)
+
+type TokenRepo interface {
+	CreateRefreshToken(c context.Context, data models.RefreshToken) (res models.RefreshToken, err error)
+	RotateRefreshToken(c context.Context, id string) (res models.RefreshToken, err error)
+	GetRefreshToken(c context.Context, id string) (res models.RefreshToken, err error)
+	RevokeTokenFamily(c context.Context, familyID string) (err error)
+	RevokeUserRefreshTokens(c context.Context, userID string) (err error)
+	GetUserByID(c context.Context, id string) (res models.User, err error)
+	CreateSession(c context.Context, data models.Session) (res models.Session, err error)
+	TouchSession(c context.Context, id, ip, userAgent string) (err error)
+	GetActiveSessions(c context.Context, userID string) (res []models.Session, err error)
+	GetSession(c context.Context, id string) (res models.Session, err error)
+}
+
+type TokenRepoImpl struct {
+	db *gorm.DB
+}
+
+func (a *TokenRepoImpl) CreateRefreshToken(c context.Context, data models.RefreshToken) (res models.RefreshToken, err error) {
+	if err := a.db.WithContext(c).Create(&data).Error; err != nil {
+		return res, helper.ErrDatabase
+	}
+
+	return data, nil
+}
+
+func (a *TokenRepoImpl) RotateRefreshToken(c context.Context, id string) (res models.RefreshToken, err error) {
+	if err := a.db.WithContext(c).First(&res, "id = ?", id).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return res, helper.ErrInvalidToken
+		}
+		return res, helper.ErrDatabase
+	}
+
+	if res.RevokedAt != nil {
+		return res, helper.ErrInvalidToken
+	}
+
+	if res.RotatedAt != nil {
+		return res, helper.ErrTokenReused
+	}
+
+	// Only one request may rotate a given token; a concurrent loser is treated as reuse.
+	q := a.db.WithContext(c).
+		Model(&models.RefreshToken{}).
+		Where("id = ? AND rotated_at IS NULL", id).
+		Update("rotated_at", time.Now())
+
+	if q.Error != nil {
+		return res, helper.ErrDatabase
+	}
+
+	if q.RowsAffected == 0 {
+		return res, helper.ErrTokenReused
+	}
+
+	return res, nil
+}
+
+func (a *TokenRepoImpl) GetRefreshToken(c context.Context, id string) (res models.RefreshToken, err error) {
+	if err := a.db.WithContext(c).First(&res, "id = ?", id).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return res, helper.ErrInvalidToken
+		}
+		return res, helper.ErrDatabase
+	}
+
+	return res, nil
+}
+
+func (a *TokenRepoImpl) RevokeUserRefreshTokens(c context.Context, userID string) (err error) {
+	if err := a.db.WithContext(c).
+		Model(&models.RefreshToken{}).
+		Where("user_id = ? AND revoked_at IS NULL", userID).
+		Update("revoked_at", time.Now()).Error; err != nil {
+		return helper.ErrDatabase
+	}
+
+	return nil
+}
+
+func (a *TokenRepoImpl) RevokeTokenFamily(c context.Context, familyID string) (err error) {
+	if err := a.db.WithContext(c).
+		Model(&models.RefreshToken{}).
+		Where("family_id = ? AND revoked_at IS NULL", familyID).
+		Update("revoked_at", time.Now()).Error; err != nil {
+		return helper.ErrDatabase
+	}
+
+	return nil
+}
+
+func (a *TokenRepoImpl) GetUserByID(c context.Context, id string) (res models.User, err error) {
+	if err := a.db.WithContext(c).First(&res, "id = ?", id).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return res, helper.ErrNotFound
+		}
+		return res, helper.ErrDatabase
+	}
+
+	return res, nil
+}
+
+func (a *TokenRepoImpl) CreateSession(c context.Context, data models.Session) (res models.Session, err error) {
+	if err := a.db.WithContext(c).Omit("User").Create(&data).Error; err != nil {
+		return res, helper.ErrDatabase
+	}
+
+	return data, nil
+}
+
+// TouchSession records that the session's refresh token was just used.
+func (a *TokenRepoImpl) TouchSession(c context.Context, id, ip, userAgent string) (err error) {
+	if err := a.db.WithContext(c).
+		Model(&models.Session{}).
+		Where("id = ?", id).
+		Updates(map[string]interface{}{"last_seen_at": time.Now(), "ip": ip, "user_agent": userAgent}).Error; err != nil {
+		return helper.ErrDatabase
+	}
+
+	return nil
+}
+
+// GetActiveSessions returns the sessions that still hold a usable refresh
+// token, so logout, logout-all and reuse detection need no extra bookkeeping.
+func (a *TokenRepoImpl) GetActiveSessions(c context.Context, userID string) (res []models.Session, err error) {
+	active := a.db.Model(&models.RefreshToken{}).
+		Select("family_id").
+		Where("user_id = ? AND rotated_at IS NULL AND revoked_at IS NULL AND expires_at > ?", userID, time.Now())
+
+	if err := a.db.WithContext(c).
+		Where("user_id = ? AND id IN (?)", userID, active).
+		Order("last_seen_at desc").
+		Find(&res).Error; err != nil {
+		return res, helper.ErrDatabase
+	}
+
+	return res, nil
+}
+
+func (a *TokenRepoImpl) GetSession(c context.Context, id string) (res models.Session, err error) {
+	if err := a.db.WithContext(c).First(&res, "id = ?", id).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return res, helper.ErrNotFound
+		}
+		return res, helper.ErrDatabase
+	}
+
+	return res, nil
+}
+
+func NewTokenRepo(db *gorm.DB) TokenRepo {
+	return &TokenRepoImpl{
+		db: db,
+	}
+}
@@ -0,0 +1,156 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/GabrielMoody/mikronet-auth-service/internal/helper"
+	"github.com/GabrielMoody/mikronet-auth-service/internal/models"
+	"gorm.io/gorm"
+)
+
+type TwoFactorRepo interface {
+	GetTwoFactor(c context.Context, userID string) (res models.TwoFactor, err error)
+	SaveTwoFactor(c context.Context, data models.TwoFactor) (res models.TwoFactor, err error)
+	EnableTwoFactor(c context.Context, userID string, step int64, recoveryCodes []string) (err error)
+	UseTOTPStep(c context.Context, userID string, step int64) (err error)
+	UseRecoveryCode(c context.Context, userID string, code string) (err error)
+	IsTwoFactorRequired(c context.Context, role string) (bool, error)
+	SetTwoFactorPolicy(c context.Context, data models.TwoFactorPolicy) (res models.TwoFactorPolicy, err error)
+	GetTwoFactorPolicies(c context.Context) (res []models.TwoFactorPolicy, err error)
+}
+
+type TwoFactorRepoImpl struct {
+	db *gorm.DB
+}
+
+func (a *TwoFactorRepoImpl) GetTwoFactor(c context.Context, userID string) (res models.TwoFactor, err error) {
+	if err := a.db.WithContext(c).First(&res, "user_id = ?", userID).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return res, helper.ErrNotFound
+		}
+		return res, helper.ErrDatabase
+	}
+
+	return res, nil
+}
+
+func (a *TwoFactorRepoImpl) SaveTwoFactor(c context.Context, data models.TwoFactor) (res models.TwoFactor, err error) {
+	if err := a.db.WithContext(c).Omit("User").Save(&data).Error; err != nil {
+		return res, helper.ErrDatabase
+	}
+
+	return data, nil
+}
+
+func (a *TwoFactorRepoImpl) EnableTwoFactor(c context.Context, userID string, step int64, recoveryCodes []string) (err error) {
+	tx := a.db.WithContext(c).Begin()
+
+	defer func() {
+		if r := recover(); r != nil {
+			tx.Rollback()
+		}
+	}()
+
+	q := tx.Model(&models.TwoFactor{}).
+		Where("user_id = ? AND enabled = ?", userID, false).
+		Updates(map[string]interface{}{"enabled": true, "enabled_at": time.Now(), "last_used_step": step})
+
+	if q.Error != nil {
+		tx.Rollback()
+		return helper.ErrDatabase
+	}
+
+	if q.RowsAffected == 0 {
+		tx.Rollback()
+		return helper.ErrNotFound
+	}
+
+	if err := tx.Where("user_id = ?", userID).Delete(&models.RecoveryCode{}).Error; err != nil {
+		tx.Rollback()
+		return helper.ErrDatabase
+	}
+
+	codes := make([]models.RecoveryCode, 0, len(recoveryCodes))
+	for _, code := range recoveryCodes {
+		codes = append(codes, models.RecoveryCode{UserID: userID, Code: code})
+	}
+
+	if err := tx.Omit("User").Create(&codes).Error; err != nil {
+		tx.Rollback()
+		return helper.ErrDatabase
+	}
+
+	tx.Commit()
+
+	return nil
+}
+
+// UseTOTPStep records the step of an accepted code; a code from the same or
+// an older step has already been used and is rejected.
+func (a *TwoFactorRepoImpl) UseTOTPStep(c context.Context, userID string, step int64) (err error) {
+	q := a.db.WithContext(c).Model(&models.TwoFactor{}).
+		Where("user_id = ? AND enabled = ? AND last_used_step < ?", userID, true, step).
+		Update("last_used_step", step)
+
+	if q.Error != nil {
+		return helper.ErrDatabase
+	}
+
+	if q.RowsAffected == 0 {
+		return helper.ErrInvalidOTP
+	}
+
+	return nil
+}
+
+func (a *TwoFactorRepoImpl) UseRecoveryCode(c context.Context, userID string, code string) (err error) {
+	q := a.db.WithContext(c).Model(&models.RecoveryCode{}).
+		Where("user_id = ? AND code = ? AND used_at IS NULL", userID, code).
+		Update("used_at", time.Now())
+
+	if q.Error != nil {
+		return helper.ErrDatabase
+	}
+
+	if q.RowsAffected == 0 {
+		return helper.ErrInvalidOTP
+	}
+
+	return nil
+}
+
+func (a *TwoFactorRepoImpl) IsTwoFactorRequired(c context.Context, role string) (bool, error) {
+	var res models.TwoFactorPolicy
+	if err := a.db.WithContext(c).First(&res, "role = ?", role).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return false, nil
+		}
+		return false, helper.ErrDatabase
+	}
+
+	return res.Required, nil
+}
+
+func (a *TwoFactorRepoImpl) SetTwoFactorPolicy(c context.Context, data models.TwoFactorPolicy) (res models.TwoFactorPolicy, err error) {
+	if err := a.db.WithContext(c).Save(&data).Error; err != nil {
+		return res, helper.ErrDatabase
+	}
+
+	return data, nil
+}
+
+func (a *TwoFactorRepoImpl) GetTwoFactorPolicies(c context.Context) (res []models.TwoFactorPolicy, err error) {
+	if err := a.db.WithContext(c).Order("role asc").Find(&res).Error; err != nil {
+		return res, helper.ErrDatabase
+	}
+
+	return res, nil
+}
+
+func NewTwoFactorRepo(db *gorm.DB) TwoFactorRepo {
+	return &TwoFactorRepoImpl{
+		db: db,
+	}
+}
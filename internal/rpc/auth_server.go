@@ -0,0 +1,176 @@
+package rpc
+
+import (
+	"context"
+	"errors"
+	"slices"
+	"strings"
+
+	"github.com/GabrielMoody/mikronet-auth-service/internal/helper"
+	"github.com/GabrielMoody/mikronet-auth-service/internal/middleware"
+	"github.com/GabrielMoody/mikronet-auth-service/internal/pb"
+	"github.com/GabrielMoody/mikronet-auth-service/internal/repository"
+	"github.com/GabrielMoody/mikronet-auth-service/internal/service"
+	"github.com/gofiber/fiber/v2"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+)
+
+// IntrospectScope is the service-account scope every RPC requires.
+const IntrospectScope = "auth.introspect"
+
+type AuthServer struct {
+	pb.UnimplementedAuthServiceServer
+	AuthService     service.AuthService
+	RevocationStore repository.RevocationStore
+}
+
+func toStatus(err *helper.ErrorStruct) error {
+	var code codes.Code
+	switch err.Code {
+	case fiber.StatusBadRequest:
+		code = codes.InvalidArgument
+	case fiber.StatusUnauthorized:
+		code = codes.Unauthenticated
+	case fiber.StatusForbidden:
+		code = codes.PermissionDenied
+	case fiber.StatusNotFound:
+		code = codes.NotFound
+	case fiber.StatusConflict:
+		code = codes.AlreadyExists
+	case fiber.StatusGone:
+		// Expired links and codes; asking again will not help.
+		code = codes.FailedPrecondition
+	case fiber.StatusTooManyRequests:
+		code = codes.ResourceExhausted
+	default:
+		code = codes.Internal
+	}
+
+	msg := "internal error"
+	if err.Err != nil {
+		msg = err.Err.Error()
+	}
+
+	return status.Error(code, msg)
+}
+
+func (a *AuthServer) ValidateToken(c context.Context, req *pb.ValidateTokenRequest) (*pb.ValidateTokenResponse, error) {
+	claims, err := middleware.GetJWTPayload(a.RevocationStore, req.GetToken())
+
+	// Tokens issued to OAuth clients are only good for the OAuth endpoints.
+	if err != nil || claims["typ"] != helper.TokenTypeAccess || helper.NewClaims(claims).IssuedToClient() {
+		return &pb.ValidateTokenResponse{Valid: false}, nil
+	}
+
+	res := &pb.ValidateTokenResponse{Valid: true}
+	res.UserId, _ = claims["id"].(string)
+	res.Email, _ = claims["email"].(string)
+	res.Role, _ = claims["role"].(string)
+	res.Jti, _ = claims["jti"].(string)
+
+	if exp, errExp := claims.GetExpirationTime(); errExp == nil && exp != nil {
+		res.ExpiresAt = exp.Unix()
+	}
+
+	return res, nil
+}
+
+func (a *AuthServer) GetUser(c context.Context, req *pb.GetUserRequest) (*pb.GetUserResponse, error) {
+	if req.GetId() == "" {
+		return nil, status.Error(codes.InvalidArgument, "id is required")
+	}
+
+	res, err := a.AuthService.GetUserService(c, req.GetId())
+
+	if err != nil {
+		return nil, toStatus(err)
+	}
+
+	return &pb.GetUserResponse{
+		User: &pb.User{
+			Id:        res.ID,
+			Email:     res.Email,
+			Role:      res.Role,
+			Blocked:   res.Blocked,
+			Verified:  res.Verified,
+			CreatedAt: res.CreatedAt.Unix(),
+		},
+	}, nil
+}
+
+func (a *AuthServer) IsBlocked(c context.Context, req *pb.IsBlockedRequest) (*pb.IsBlockedResponse, error) {
+	if req.GetUserId() == "" {
+		return nil, status.Error(codes.InvalidArgument, "user_id is required")
+	}
+
+	res, err := a.AuthService.IsBlockedService(c, req.GetUserId())
+
+	if err != nil {
+		return nil, toStatus(err)
+	}
+
+	return &pb.IsBlockedResponse{Blocked: res}, nil
+}
+
+func (a *AuthServer) IsDriverVerified(c context.Context, req *pb.IsDriverVerifiedRequest) (*pb.IsDriverVerifiedResponse, error) {
+	if req.GetUserId() == "" {
+		return nil, status.Error(codes.InvalidArgument, "user_id is required")
+	}
+
+	res, err := a.AuthService.IsDriverVerifiedService(c, req.GetUserId())
+
+	if err != nil {
+		return nil, toStatus(err)
+	}
+
+	return &pb.IsDriverVerifiedResponse{Verified: res}, nil
+}
+
+// authInterceptor lets through calls that send a service-account token
+// granted IntrospectScope as "authorization: Bearer <token>" metadata.
+func authInterceptor(store repository.RevocationStore) grpc.UnaryServerInterceptor {
+	return func(c context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (interface{}, error) {
+		var token string
+		if md, ok := metadata.FromIncomingContext(c); ok {
+			if values := md.Get("authorization"); len(values) > 0 {
+				token, _ = strings.CutPrefix(values[0], "Bearer ")
+			}
+		}
+
+		if token == "" {
+			return nil, status.Error(codes.Unauthenticated, "service token is required")
+		}
+
+		payload, err := middleware.GetJWTPayload(store, token)
+
+		if errors.Is(err, helper.ErrDatabase) {
+			return nil, status.Error(codes.Internal, err.Error())
+		}
+
+		if err != nil {
+			return nil, status.Error(codes.Unauthenticated, "invalid service token")
+		}
+
+		claims := helper.NewClaims(payload)
+
+		if claims.Type != helper.TokenTypeService || !slices.Contains(claims.Scopes, IntrospectScope) {
+			return nil, status.Error(codes.PermissionDenied, "service token lacks the "+IntrospectScope+" scope")
+		}
+
+		return handler(c, req)
+	}
+}
+
+func NewAuthServer(authService service.AuthService, revocationStore repository.RevocationStore) *grpc.Server {
+	srv := grpc.NewServer(grpc.UnaryInterceptor(authInterceptor(revocationStore)))
+
+	pb.RegisterAuthServiceServer(srv, &AuthServer{
+		AuthService:     authService,
+		RevocationStore: revocationStore,
+	})
+
+	return srv
+}
@@ -0,0 +1,335 @@
+package rpc
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/GabrielMoody/mikronet-auth-service/internal/dto"
+	"github.com/GabrielMoody/mikronet-auth-service/internal/helper"
+	"github.com/GabrielMoody/mikronet-auth-service/internal/pb"
+	"github.com/GabrielMoody/mikronet-auth-service/internal/repository"
+	"github.com/GabrielMoody/mikronet-auth-service/internal/service"
+	"github.com/golang-jwt/jwt/v5"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+)
+
+// fakeAuthService answers the lookups the gRPC server makes; any other
+// method panics through the nil embedded interface.
+type fakeAuthService struct {
+	service.AuthService
+	users map[string]dto.UserStatusResp
+}
+
+func (f *fakeAuthService) GetUserService(c context.Context, id string) (res dto.UserStatusResp, err *helper.ErrorStruct) {
+	user, ok := f.users[id]
+	if !ok {
+		return res, helper.CheckError(helper.ErrNotFound)
+	}
+
+	return user, nil
+}
+
+func (f *fakeAuthService) IsBlockedService(c context.Context, id string) (res bool, err *helper.ErrorStruct) {
+	user, ok := f.users[id]
+	if !ok {
+		return res, helper.CheckError(helper.ErrNotFound)
+	}
+
+	return user.Blocked, nil
+}
+
+func (f *fakeAuthService) IsDriverVerifiedService(c context.Context, id string) (res bool, err *helper.ErrorStruct) {
+	user, ok := f.users[id]
+	if !ok || user.Role != "driver" {
+		return res, helper.CheckError(helper.ErrNotFound)
+	}
+
+	return user.Verified, nil
+}
+
+// newTestClient returns a client that sends a service token granted
+// IntrospectScope with every call.
+func newTestClient(t *testing.T, store repository.RevocationStore) pb.AuthServiceClient {
+	t.Helper()
+	t.Setenv("JWT_SECRET", "test-secret")
+
+	token := signTestToken(t, serviceClaims("jti-svc", IntrospectScope))
+
+	return newUnauthenticatedClient(t, store, grpc.WithUnaryInterceptor(func(c context.Context, method string, req, reply any, cc *grpc.ClientConn, invoker grpc.UnaryInvoker, opts ...grpc.CallOption) error {
+		return invoker(metadata.AppendToOutgoingContext(c, "authorization", "Bearer "+token), method, req, reply, cc, opts...)
+	}))
+}
+
+func newUnauthenticatedClient(t *testing.T, store repository.RevocationStore, opts ...grpc.DialOption) pb.AuthServiceClient {
+	t.Helper()
+
+	client, stop, err := newBufconnClient(&fakeAuthService{
+		users: map[string]dto.UserStatusResp{
+			"driver-1": {ID: "driver-1", Email: "driver@example.com", Role: "driver", Verified: true},
+			"user-1":   {ID: "user-1", Email: "user@example.com", Role: "user", Blocked: true},
+		},
+	}, store, opts...)
+	if err != nil {
+		t.Fatalf("error while starting bufconn server: %v", err)
+	}
+	t.Cleanup(stop)
+
+	return client
+}
+
+func signTestToken(t *testing.T, claims jwt.MapClaims) string {
+	t.Helper()
+
+	token, err := helper.SignJWT(claims)
+	if err != nil {
+		t.Fatalf("error while signing token: %v", err)
+	}
+
+	return token
+}
+
+func serviceClaims(jti, scope string) jwt.MapClaims {
+	now := time.Now()
+
+	return jwt.MapClaims{
+		"sub":       "svc-1",
+		"client_id": "svc-1",
+		"scope":     scope,
+		"jti":       jti,
+		"typ":       helper.TokenTypeService,
+		"iat":       now.Unix(),
+		"exp":       now.Add(time.Minute).Unix(),
+	}
+}
+
+func TestServiceTokenRequired(t *testing.T) {
+	t.Setenv("JWT_SECRET", "test-secret")
+	store := repository.NewMemoryRevocationStore()
+	client := newUnauthenticatedClient(t, store)
+	now := time.Now()
+
+	if err := store.RevokeToken(context.Background(), "jti-revoked", now.Add(time.Minute)); err != nil {
+		t.Fatalf("error while revoking token: %v", err)
+	}
+
+	tests := []struct {
+		name     string
+		token    string
+		wantCode codes.Code
+	}{
+		{"service token", signTestToken(t, serviceClaims("jti-1", "users.read "+IntrospectScope)), codes.OK},
+		{"no token", "", codes.Unauthenticated},
+		{"garbage", "not-a-jwt", codes.Unauthenticated},
+		{"revoked service token", signTestToken(t, serviceClaims("jti-revoked", IntrospectScope)), codes.Unauthenticated},
+		{"service token without the scope", signTestToken(t, serviceClaims("jti-2", "users.read")), codes.PermissionDenied},
+		{"user access token", signTestToken(t, jwt.MapClaims{
+			"id":  "user-1",
+			"jti": "jti-3",
+			"typ": helper.TokenTypeAccess,
+			"iat": now.Unix(),
+			"exp": now.Add(time.Minute).Unix(),
+		}), codes.PermissionDenied},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx := context.Background()
+			if tt.token != "" {
+				ctx = metadata.AppendToOutgoingContext(ctx, "authorization", "Bearer "+tt.token)
+			}
+
+			_, err := client.IsBlocked(ctx, &pb.IsBlockedRequest{UserId: "user-1"})
+
+			if got := status.Code(err); got != tt.wantCode {
+				t.Errorf("code = %v, want %v (%v)", got, tt.wantCode, err)
+			}
+		})
+	}
+}
+
+func TestValidateToken(t *testing.T) {
+	t.Setenv("JWT_SECRET", "test-secret")
+	store := repository.NewMemoryRevocationStore()
+	client := newTestClient(t, store)
+	now := time.Now()
+
+	if err := store.RevokeToken(context.Background(), "jti-revoked", now.Add(time.Minute)); err != nil {
+		t.Fatalf("error while revoking token: %v", err)
+	}
+
+	access := jwt.MapClaims{
+		"id":    "user-1",
+		"email": "user@example.com",
+		"role":  "user",
+		"jti":   "jti-1",
+		"typ":   helper.TokenTypeAccess,
+		"iat":   now.Unix(),
+		"exp":   now.Add(time.Minute).Unix(),
+	}
+
+	tests := []struct {
+		name  string
+		token string
+		want  *pb.ValidateTokenResponse
+	}{
+		{
+			name:  "access token",
+			token: signTestToken(t, access),
+			want: &pb.ValidateTokenResponse{
+				Valid:     true,
+				UserId:    "user-1",
+				Email:     "user@example.com",
+				Role:      "user",
+				Jti:       "jti-1",
+				ExpiresAt: now.Add(time.Minute).Unix(),
+			},
+		},
+		{
+			name:  "refresh token",
+			token: signTestToken(t, jwt.MapClaims{"id": "user-1", "jti": "jti-2", "typ": helper.TokenTypeRefresh, "exp": now.Add(time.Minute).Unix()}),
+			want:  &pb.ValidateTokenResponse{Valid: false},
+		},
+		{
+			name:  "expired token",
+			token: signTestToken(t, jwt.MapClaims{"id": "user-1", "jti": "jti-3", "typ": helper.TokenTypeAccess, "exp": now.Add(-time.Minute).Unix()}),
+			want:  &pb.ValidateTokenResponse{Valid: false},
+		},
+		{
+			name:  "token issued to an oauth client",
+			token: signTestToken(t, jwt.MapClaims{"id": "user-1", "jti": "jti-4", "typ": helper.TokenTypeAccess, "aud": "client-1", "client_id": "client-1", "exp": now.Add(time.Minute).Unix()}),
+			want:  &pb.ValidateTokenResponse{Valid: false},
+		},
+		{
+			name:  "revoked token",
+			token: signTestToken(t, jwt.MapClaims{"id": "user-1", "jti": "jti-revoked", "typ": helper.TokenTypeAccess, "iat": now.Unix(), "exp": now.Add(time.Minute).Unix()}),
+			want:  &pb.ValidateTokenResponse{Valid: false},
+		},
+		{
+			name:  "garbage",
+			token: "not-a-jwt",
+			want:  &pb.ValidateTokenResponse{Valid: false},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			res, err := client.ValidateToken(context.Background(), &pb.ValidateTokenRequest{Token: tt.token})
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+
+			if res.GetValid() != tt.want.GetValid() ||
+				res.GetUserId() != tt.want.GetUserId() ||
+				res.GetEmail() != tt.want.GetEmail() ||
+				res.GetRole() != tt.want.GetRole() ||
+				res.GetJti() != tt.want.GetJti() ||
+				res.GetExpiresAt() != tt.want.GetExpiresAt() {
+				t.Errorf("ValidateToken = %v, want %v", res, tt.want)
+			}
+		})
+	}
+}
+
+func TestLookups(t *testing.T) {
+	client := newTestClient(t, repository.NewMemoryRevocationStore())
+	ctx := context.Background()
+
+	tests := []struct {
+		name     string
+		call     func() (any, error)
+		want     any
+		wantCode codes.Code
+	}{
+		{
+			name: "get user",
+			call: func() (any, error) {
+				res, err := client.GetUser(ctx, &pb.GetUserRequest{Id: "driver-1"})
+				return res.GetUser().GetEmail(), err
+			},
+			want: "driver@example.com",
+		},
+		{
+			name: "get unknown user",
+			call: func() (any, error) {
+				return client.GetUser(ctx, &pb.GetUserRequest{Id: "nobody"})
+			},
+			wantCode: codes.NotFound,
+		},
+		{
+			name: "get user without id",
+			call: func() (any, error) {
+				return client.GetUser(ctx, &pb.GetUserRequest{})
+			},
+			wantCode: codes.InvalidArgument,
+		},
+		{
+			name: "is blocked",
+			call: func() (any, error) {
+				res, err := client.IsBlocked(ctx, &pb.IsBlockedRequest{UserId: "user-1"})
+				return res.GetBlocked(), err
+			},
+			want: true,
+		},
+		{
+			name: "is driver verified",
+			call: func() (any, error) {
+				res, err := client.IsDriverVerified(ctx, &pb.IsDriverVerifiedRequest{UserId: "driver-1"})
+				return res.GetVerified(), err
+			},
+			want: true,
+		},
+		{
+			name: "is driver verified for a passenger",
+			call: func() (any, error) {
+				return client.IsDriverVerified(ctx, &pb.IsDriverVerifiedRequest{UserId: "user-1"})
+			},
+			wantCode: codes.NotFound,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := tt.call()
+
+			if tt.wantCode != codes.OK {
+				if status.Code(err) != tt.wantCode {
+					t.Fatalf("expected %v, got %v", tt.wantCode, err)
+				}
+				return
+			}
+
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if got != tt.want {
+				t.Errorf("got %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestToStatus(t *testing.T) {
+	tests := []struct {
+		code int
+		want codes.Code
+	}{
+		{400, codes.InvalidArgument},
+		{401, codes.Unauthenticated},
+		{403, codes.PermissionDenied},
+		{404, codes.NotFound},
+		{409, codes.AlreadyExists},
+		{410, codes.FailedPrecondition},
+		{429, codes.ResourceExhausted},
+		{500, codes.Internal},
+	}
+
+	for _, tt := range tests {
+		if got := status.Code(toStatus(&helper.ErrorStruct{Code: tt.code})); got != tt.want {
+			t.Errorf("toStatus(%d) = %v, want %v", tt.code, got, tt.want)
+		}
+	}
+}
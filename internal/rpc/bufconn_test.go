@@ -0,0 +1,46 @@
+package rpc
+
+import (
+	"context"
+	"net"
+
+	"github.com/GabrielMoody/mikronet-auth-service/internal/pb"
+	"github.com/GabrielMoody/mikronet-auth-service/internal/repository"
+	"github.com/GabrielMoody/mikronet-auth-service/internal/service"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/test/bufconn"
+)
+
+const bufSize = 1024 * 1024
+
+// newBufconnClient serves the auth gRPC service on an in-memory listener and
+// returns a client connected to it with opts, so callers can exercise the
+// RPCs in-process against any AuthService (a real one or a fake) and
+// revocation store. The returned func stops the server and closes the
+// connection.
+func newBufconnClient(authService service.AuthService, revocationStore repository.RevocationStore, opts ...grpc.DialOption) (pb.AuthServiceClient, func(), error) {
+	lis := bufconn.Listen(bufSize)
+	srv := NewAuthServer(authService, revocationStore)
+
+	go srv.Serve(lis)
+
+	opts = append(opts,
+		grpc.WithContextDialer(func(c context.Context, _ string) (net.Conn, error) {
+			return lis.DialContext(c)
+		}),
+		grpc.WithTransportCredentials(insecure.NewCredentials()),
+	)
+
+	conn, err := grpc.NewClient("passthrough:///bufnet", opts...)
+
+	if err != nil {
+		srv.Stop()
+		return nil, nil, err
+	}
+
+	return pb.NewAuthServiceClient(conn), func() {
+		conn.Close()
+		srv.Stop()
+	}, nil
+}
@@ -0,0 +1,593 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/GabrielMoody/mikronet-auth-service/internal/dto"
+	"github.com/GabrielMoody/mikronet-auth-service/internal/helper"
+	"github.com/GabrielMoody/mikronet-auth-service/internal/models"
+	"github.com/GabrielMoody/mikronet-auth-service/internal/repository"
+	"github.com/gofiber/fiber/v2"
+)
+
+type AdminService interface {
+	ApproveGovService(c context.Context, id string, adminID string) (res string, err *helper.ErrorStruct)
+	RejectGovService(c context.Context, id string, adminID string, data dto.RejectGovReq) (res string, err *helper.ErrorStruct)
+	GetPendingDriversService(c context.Context, page, limit int) (res []dto.DriverVerificationResp, meta dto.PaginationResp, err *helper.ErrorStruct)
+	GetDriverDocumentService(c context.Context, id string, document string) (res string, err *helper.ErrorStruct)
+	ApproveDriverService(c context.Context, id string, adminID string) (res string, err *helper.ErrorStruct)
+	RejectDriverService(c context.Context, id string, adminID string, data dto.RejectDriverReq) (res string, err *helper.ErrorStruct)
+	BlockAccountService(c context.Context, id string, adminID string, data dto.BlockAccountReq) (res dto.BlockedAccountResp, err *helper.ErrorStruct)
+	UnblockAccountService(c context.Context, id string, adminID string) (res string, err *helper.ErrorStruct)
+	GetBlockedAccountsService(c context.Context, page, limit int) (res []dto.BlockedAccountResp, meta dto.PaginationResp, err *helper.ErrorStruct)
+	GetUsersService(c context.Context, query dto.AdminUserQuery) (res []dto.AdminUserResp, meta dto.PaginationResp, err *helper.ErrorStruct)
+	GetUserService(c context.Context, id string) (res dto.AdminUserDetailResp, err *helper.ErrorStruct)
+	UpdateUserService(c context.Context, id string, adminID string, data dto.AdminUpdateUserReq) (res dto.AdminUserDetailResp, err *helper.ErrorStruct)
+}
+
+type AdminServiceImpl struct {
+	AdminRepo    repository.AdminRepo
+	TokenService TokenService
+	AuditService AuditService
+}
+
+func (a *AdminServiceImpl) ApproveGovService(c context.Context, id string, adminID string) (res string, err *helper.ErrorStruct) {
+	resRepo, errRepo := a.AdminRepo.ApproveGov(c, id, adminID)
+
+	recordResult(c, a.AuditService, models.AuthEvent{
+		Type:     models.EventGovApprove,
+		ActorID:  adminID,
+		TargetID: id,
+	}, errRepo)
+
+	if errRepo != nil {
+		return res, helper.CheckError(errRepo)
+	}
+
+	return resRepo.ID, nil
+}
+
+func (a *AdminServiceImpl) RejectGovService(c context.Context, id string, adminID string, data dto.RejectGovReq) (res string, err *helper.ErrorStruct) {
+	if errValidate := helper.Validate.Struct(data); errValidate != nil {
+		return res, &helper.ErrorStruct{
+			Code:             fiber.StatusBadRequest,
+			ValidationErrors: helper.ValidationError(errValidate),
+		}
+	}
+
+	resRepo, errRepo := a.AdminRepo.RejectGov(c, id, adminID, data.Reason)
+
+	recordResult(c, a.AuditService, models.AuthEvent{
+		Type:     models.EventGovReject,
+		ActorID:  adminID,
+		TargetID: id,
+		Reason:   data.Reason,
+	}, errRepo)
+
+	if errRepo != nil {
+		return res, helper.CheckError(errRepo)
+	}
+
+	return resRepo.ID, nil
+}
+
+func pagination(page, limit int) (int, int) {
+	if page < 1 {
+		page = 1
+	}
+
+	if limit < 1 {
+		limit = 10
+	}
+
+	if limit > 100 {
+		limit = 100
+	}
+
+	return page, limit
+}
+
+func (a *AdminServiceImpl) GetPendingDriversService(c context.Context, page, limit int) (res []dto.DriverVerificationResp, meta dto.PaginationResp, err *helper.ErrorStruct) {
+	page, limit = pagination(page, limit)
+
+	resRepo, total, errRepo := a.AdminRepo.GetPendingDrivers(c, page, limit)
+
+	if errRepo != nil {
+		return res, meta, helper.CheckError(errRepo)
+	}
+
+	res = make([]dto.DriverVerificationResp, 0, len(resRepo))
+	for _, user := range resRepo {
+		res = append(res, dto.DriverVerificationResp{
+			ID:            user.ID,
+			Email:         user.Email,
+			Name:          user.DriverDetail.Name,
+			PhoneNumber:   user.DriverDetail.PhoneNumber,
+			LicenseNumber: user.DriverDetail.LicenseNumber,
+			SIMNumber:     user.DriverDetail.SIMNumber,
+			CreatedAt:     user.CreatedAt,
+		})
+	}
+
+	return res, dto.PaginationResp{
+		Page:  page,
+		Limit: limit,
+		Total: total,
+	}, nil
+}
+
+func (a *AdminServiceImpl) GetDriverDocumentService(c context.Context, id string, document string) (res string, err *helper.ErrorStruct) {
+	driver, errRepo := a.AdminRepo.GetDriverByID(c, id)
+
+	if errRepo != nil {
+		return res, helper.CheckError(errRepo)
+	}
+
+	switch document {
+	case "ktp":
+		res = driver.KTP
+	case "sim":
+		res = driver.SIM
+	case "profile_picture":
+		res = driver.ProfilePicture
+	default:
+		return res, helper.CheckError(helper.ErrBadRequest)
+	}
+
+	// Only files this service wrote are served, whatever the column holds.
+	res = filepath.Clean(res)
+	if !strings.HasPrefix(res, "uploads"+string(filepath.Separator)) {
+		return "", helper.CheckError(helper.ErrNotFound)
+	}
+
+	if info, errStat := os.Stat(res); errStat != nil || info.IsDir() {
+		return "", helper.CheckError(helper.ErrNotFound)
+	}
+
+	return res, nil
+}
+
+func (a *AdminServiceImpl) ApproveDriverService(c context.Context, id string, adminID string) (res string, err *helper.ErrorStruct) {
+	resRepo, errRepo := a.AdminRepo.VerifyDriver(c, models.DriverVerification{
+		DriverID: id,
+		AdminID:  adminID,
+		Status:   "approved",
+	})
+
+	recordResult(c, a.AuditService, models.AuthEvent{
+		Type:     models.EventDriverApprove,
+		ActorID:  adminID,
+		TargetID: id,
+	}, errRepo)
+
+	if errRepo != nil {
+		return res, helper.CheckError(errRepo)
+	}
+
+	return resRepo.DriverID, nil
+}
+
+func (a *AdminServiceImpl) RejectDriverService(c context.Context, id string, adminID string, data dto.RejectDriverReq) (res string, err *helper.ErrorStruct) {
+	if errValidate := helper.Validate.Struct(data); errValidate != nil {
+		return res, &helper.ErrorStruct{
+			Code:             fiber.StatusBadRequest,
+			ValidationErrors: helper.ValidationError(errValidate),
+		}
+	}
+
+	resRepo, errRepo := a.AdminRepo.VerifyDriver(c, models.DriverVerification{
+		DriverID: id,
+		AdminID:  adminID,
+		Status:   "rejected",
+		Reason:   data.Reason,
+	})
+
+	recordResult(c, a.AuditService, models.AuthEvent{
+		Type:     models.EventDriverReject,
+		ActorID:  adminID,
+		TargetID: id,
+		Reason:   data.Reason,
+	}, errRepo)
+
+	if errRepo != nil {
+		return res, helper.CheckError(errRepo)
+	}
+
+	return resRepo.DriverID, nil
+}
+
+func (a *AdminServiceImpl) BlockAccountService(c context.Context, id string, adminID string, data dto.BlockAccountReq) (res dto.BlockedAccountResp, err *helper.ErrorStruct) {
+	if errValidate := helper.Validate.Struct(data); errValidate != nil {
+		return res, &helper.ErrorStruct{
+			Code:             fiber.StatusBadRequest,
+			ValidationErrors: helper.ValidationError(errValidate),
+		}
+	}
+
+	if data.ExpiresAt != nil && !data.ExpiresAt.After(time.Now()) {
+		return res, &helper.ErrorStruct{
+			Code:             fiber.StatusBadRequest,
+			ValidationErrors: map[string]string{"ExpiresAt": "ExpiresAt must be in the future"},
+		}
+	}
+
+	if id == adminID {
+		return res, &helper.ErrorStruct{
+			Err:  errors.New("tidak dapat memblokir akun sendiri"),
+			Code: fiber.StatusBadRequest,
+		}
+	}
+
+	resRepo, errRepo := a.AdminRepo.BlockAccount(c, models.BlockedAccount{
+		UserID:    id,
+		Reason:    data.Reason,
+		BlockedBy: adminID,
+		ExpiresAt: data.ExpiresAt,
+		CreatedAt: time.Now(),
+	})
+
+	recordResult(c, a.AuditService, models.AuthEvent{
+		Type:     models.EventAccountBlock,
+		ActorID:  adminID,
+		TargetID: id,
+		Reason:   data.Reason,
+	}, errRepo)
+
+	if errRepo != nil {
+		return res, helper.CheckError(errRepo)
+	}
+
+	if _, errLogout := a.TokenService.LogoutAllService(c, id); errLogout != nil {
+		return res, errLogout
+	}
+
+	return dto.BlockedAccountResp{
+		UserID:    resRepo.UserID,
+		Reason:    resRepo.Reason,
+		BlockedBy: resRepo.BlockedBy,
+		ExpiresAt: resRepo.ExpiresAt,
+		CreatedAt: resRepo.CreatedAt,
+	}, nil
+}
+
+func (a *AdminServiceImpl) UnblockAccountService(c context.Context, id string, adminID string) (res string, err *helper.ErrorStruct) {
+	errRepo := a.AdminRepo.UnblockAccount(c, id)
+
+	recordResult(c, a.AuditService, models.AuthEvent{
+		Type:     models.EventAccountUnblock,
+		ActorID:  adminID,
+		TargetID: id,
+	}, errRepo)
+
+	if errRepo != nil {
+		return res, helper.CheckError(errRepo)
+	}
+
+	return id, nil
+}
+
+func (a *AdminServiceImpl) GetBlockedAccountsService(c context.Context, page, limit int) (res []dto.BlockedAccountResp, meta dto.PaginationResp, err *helper.ErrorStruct) {
+	page, limit = pagination(page, limit)
+
+	resRepo, total, errRepo := a.AdminRepo.GetBlockedAccounts(c, page, limit)
+
+	if errRepo != nil {
+		return res, meta, helper.CheckError(errRepo)
+	}
+
+	res = make([]dto.BlockedAccountResp, 0, len(resRepo))
+	for _, blocked := range resRepo {
+		res = append(res, dto.BlockedAccountResp{
+			UserID:    blocked.UserID,
+			Email:     blocked.User.Email,
+			Reason:    blocked.Reason,
+			BlockedBy: blocked.BlockedBy,
+			ExpiresAt: blocked.ExpiresAt,
+			CreatedAt: blocked.CreatedAt,
+		})
+	}
+
+	return res, dto.PaginationResp{
+		Page:  page,
+		Limit: limit,
+		Total: total,
+	}, nil
+}
+
+func (a *AdminServiceImpl) GetUsersService(c context.Context, query dto.AdminUserQuery) (res []dto.AdminUserResp, meta dto.PaginationResp, err *helper.ErrorStruct) {
+	if errValidate := helper.Validate.Struct(query); errValidate != nil {
+		return res, meta, &helper.ErrorStruct{
+			Code:             fiber.StatusBadRequest,
+			ValidationErrors: helper.ValidationError(errValidate),
+		}
+	}
+
+	page, limit := pagination(query.Page, query.Limit)
+
+	filter := repository.UserFilter{
+		Search: strings.TrimSpace(query.Search),
+		Role:   query.Role,
+		Sort:   query.Sort,
+	}
+
+	// Validated above.
+	filter.CreatedFrom, _ = parseTime(query.CreatedFrom)
+	filter.CreatedTo, _ = parseTime(query.CreatedTo)
+
+	resRepo, total, errRepo := a.AdminRepo.GetUsers(c, filter, page, limit)
+
+	if errRepo != nil {
+		return res, meta, helper.CheckError(errRepo)
+	}
+
+	res = make([]dto.AdminUserResp, 0, len(resRepo))
+	for _, user := range resRepo {
+		res = append(res, dto.AdminUserResp{
+			ID:          user.ID,
+			Email:       user.Email,
+			PhoneNumber: phoneNumber(user),
+			Role:        user.Role,
+			Name:        roleName(user),
+			CreatedAt:   user.CreatedAt,
+		})
+	}
+
+	return res, dto.PaginationResp{
+		Page:  page,
+		Limit: limit,
+		Total: total,
+	}, nil
+}
+
+func phoneNumber(user models.User) string {
+	if user.PhoneNumber == nil {
+		return ""
+	}
+
+	return *user.PhoneNumber
+}
+
+// roleName returns the name kept in the details of the user's role.
+func roleName(user models.User) string {
+	switch user.Role {
+	case "driver":
+		return user.DriverDetail.Name
+	case "user":
+		return user.PassengerDetail.Name
+	case "admin":
+		return user.AdminDetail.Name
+	case "owner":
+		return user.OwnerDetail.Name
+	case "government":
+		return user.GovDetail.Name
+	}
+
+	return ""
+}
+
+func (a *AdminServiceImpl) userDetail(c context.Context, user models.User) (res dto.AdminUserDetailResp, err *helper.ErrorStruct) {
+	blocked, errRepo := a.AdminRepo.IsBlocked(c, user.ID)
+
+	if errRepo != nil {
+		return res, helper.CheckError(errRepo)
+	}
+
+	res = dto.AdminUserDetailResp{
+		ID:            user.ID,
+		Email:         user.Email,
+		PhoneNumber:   phoneNumber(user),
+		Role:          user.Role,
+		Locale:        user.Locale,
+		EmailVerified: user.EmailVerification == nil,
+		Blocked:       blocked,
+		CreatedAt:     user.CreatedAt,
+		UpdatedAt:     user.UpdatedAt,
+	}
+
+	switch user.Role {
+	case "driver":
+		res.Driver = &dto.DriverDetailResp{
+			Name:           user.DriverDetail.Name,
+			PhoneNumber:    user.DriverDetail.PhoneNumber,
+			LicenseNumber:  user.DriverDetail.LicenseNumber,
+			SIMNumber:      user.DriverDetail.SIMNumber,
+			RouteID:        user.DriverDetail.RouteID,
+			Status:         user.DriverDetail.Status,
+			Verified:       user.DriverDetail.Verified,
+			AvailableSeats: user.DriverDetail.AvailableSeats,
+		}
+	case "user":
+		res.Passenger = &dto.PassengerDetailResp{
+			Name:        user.PassengerDetail.Name,
+			DateOfBirth: user.PassengerDetail.DateOfBirth,
+			Age:         user.PassengerDetail.Age,
+		}
+	case "admin":
+		res.Admin = &dto.AdminDetailResp{
+			Name: user.AdminDetail.Name,
+		}
+	case "owner":
+		res.Owner = &dto.OwnerDetailResp{
+			Name:        user.OwnerDetail.Name,
+			PhoneNumber: user.OwnerDetail.PhoneNumber,
+			NIK:         user.OwnerDetail.NIK,
+		}
+	case "government":
+		res.Government = &dto.GovDetailResp{
+			Name:        user.GovDetail.Name,
+			PhoneNumber: user.GovDetail.PhoneNumber,
+			NIP:         user.GovDetail.NIP,
+			Verified:    user.GovDetail.Verified,
+			VerifiedAt:  user.GovDetail.VerifiedAt,
+		}
+	}
+
+	return res, nil
+}
+
+func (a *AdminServiceImpl) GetUserService(c context.Context, id string) (res dto.AdminUserDetailResp, err *helper.ErrorStruct) {
+	user, errRepo := a.AdminRepo.GetUserByID(c, id)
+
+	if errRepo != nil {
+		return res, helper.CheckError(errRepo)
+	}
+
+	return a.userDetail(c, user)
+}
+
+func (a *AdminServiceImpl) UpdateUserService(c context.Context, id string, adminID string, data dto.AdminUpdateUserReq) (res dto.AdminUserDetailResp, err *helper.ErrorStruct) {
+	if errValidate := helper.Validate.Struct(data); errValidate != nil {
+		return res, &helper.ErrorStruct{
+			Code:             fiber.StatusBadRequest,
+			ValidationErrors: helper.ValidationError(errValidate),
+		}
+	}
+
+	user, errRepo := a.AdminRepo.GetUserByID(c, id)
+
+	if errRepo != nil {
+		return res, helper.CheckError(errRepo)
+	}
+
+	role := user.Role
+	if data.Role != nil {
+		role = *data.Role
+	}
+	roleChanged := role != user.Role
+
+	if roleChanged && id == adminID {
+		return res, &helper.ErrorStruct{
+			Err:  errors.New("tidak dapat mengubah role akun sendiri"),
+			Code: fiber.StatusBadRequest,
+		}
+	}
+
+	// Role specific fields have to match the role the user ends up with, and
+	// NIK and NIP are required for a user who never had that role.
+	invalid := map[string]string{}
+	if data.LicenseNumber != nil && role != "driver" {
+		invalid["LicenseNumber"] = "LicenseNumber only applies to role driver"
+	}
+	if data.NIK != nil && role != "owner" {
+		invalid["NIK"] = "NIK only applies to role owner"
+	}
+	if data.NIK == nil && role == "owner" && user.OwnerDetail.ID == "" {
+		invalid["NIK"] = "NIK is required for role owner"
+	}
+	if data.NIP != nil && role != "government" {
+		invalid["NIP"] = "NIP only applies to role government"
+	}
+	if data.NIP == nil && role == "government" && user.GovDetail.ID == "" {
+		invalid["NIP"] = "NIP is required for role government"
+	}
+
+	if len(invalid) > 0 {
+		return res, &helper.ErrorStruct{
+			Code:             fiber.StatusBadRequest,
+			ValidationErrors: invalid,
+		}
+	}
+
+	var changes []string
+
+	// A new role starts with the name the user had under the old one.
+	name := roleName(user)
+	if roleChanged {
+		changes = append(changes, fmt.Sprintf("role: %s -> %s", user.Role, role))
+		user.Role = role
+	}
+
+	if data.Name != nil {
+		name = *data.Name
+		changes = append(changes, "name")
+	}
+
+	phone := phoneNumber(user)
+	if data.PhoneNumber != nil {
+		phone, _ = helper.NormalizePhone(*data.PhoneNumber)
+		user.PhoneNumber = &phone
+		changes = append(changes, "phone_number")
+	}
+
+	switch role {
+	case "driver":
+		if user.DriverDetail.ID == "" || data.PhoneNumber != nil {
+			user.DriverDetail.PhoneNumber = phone
+		}
+		if data.LicenseNumber != nil {
+			user.DriverDetail.LicenseNumber = *data.LicenseNumber
+			changes = append(changes, "license_number")
+		}
+		user.DriverDetail.ID = id
+		user.DriverDetail.Name = name
+	case "user":
+		user.PassengerDetail.ID = id
+		user.PassengerDetail.Name = name
+	case "admin":
+		user.AdminDetail.ID = id
+		user.AdminDetail.Name = name
+	case "owner":
+		if user.OwnerDetail.ID == "" || data.PhoneNumber != nil {
+			user.OwnerDetail.PhoneNumber = phone
+		}
+		if data.NIK != nil {
+			user.OwnerDetail.NIK = *data.NIK
+			changes = append(changes, "nik")
+		}
+		user.OwnerDetail.ID = id
+		user.OwnerDetail.Name = name
+	case "government":
+		if user.GovDetail.ID == "" || data.PhoneNumber != nil {
+			user.GovDetail.PhoneNumber = phone
+		}
+		if data.NIP != nil {
+			user.GovDetail.NIP = *data.NIP
+			changes = append(changes, "nip")
+		}
+		user.GovDetail.ID = id
+		user.GovDetail.Name = name
+	}
+
+	if len(changes) == 0 {
+		return a.userDetail(c, user)
+	}
+
+	resRepo, errRepo := a.AdminRepo.UpdateUser(c, user)
+
+	event := models.AuthEvent{
+		Type:     models.EventAccountUpdate,
+		ActorID:  adminID,
+		TargetID: id,
+	}
+	if errRepo == nil {
+		event.Reason = strings.Join(changes, ", ")
+	}
+	recordResult(c, a.AuditService, event, errRepo)
+
+	if errRepo != nil {
+		return res, helper.CheckError(errRepo)
+	}
+
+	// Access tokens carry the role, so make the user sign in again.
+	if roleChanged {
+		if _, errLogout := a.TokenService.LogoutAllService(c, id); errLogout != nil {
+			return res, errLogout
+		}
+	}
+
+	return a.userDetail(c, resRepo)
+}
+
+func NewAdminService(adminRepo repository.AdminRepo, tokenService TokenService, auditService AuditService) AdminService {
+	return &AdminServiceImpl{
+		AdminRepo:    adminRepo,
+		TokenService: tokenService,
+		AuditService: auditService,
+	}
+}
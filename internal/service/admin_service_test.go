@@ -0,0 +1,270 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/GabrielMoody/mikronet-auth-service/internal/dto"
+	"github.com/GabrielMoody/mikronet-auth-service/internal/helper"
+	"github.com/GabrielMoody/mikronet-auth-service/internal/models"
+	"github.com/GabrielMoody/mikronet-auth-service/internal/repository"
+	"github.com/GabrielMoody/mikronet-auth-service/internal/testdb"
+)
+
+// chdir moves the test into dir so relative uploads/ paths resolve there.
+func chdir(t *testing.T, dir string) {
+	t.Helper()
+
+	wd, err := os.Getwd()
+	if err != nil {
+		t.Fatalf("error while reading working directory: %v", err)
+	}
+
+	if err := os.Chdir(dir); err != nil {
+		t.Fatalf("error while changing directory: %v", err)
+	}
+
+	t.Cleanup(func() { os.Chdir(wd) })
+}
+
+func TestGetDriverDocumentService(t *testing.T) {
+	ctx := context.Background()
+	chdir(t, t.TempDir())
+
+	if err := os.Mkdir("uploads", 0755); err != nil {
+		t.Fatalf("error while creating uploads: %v", err)
+	}
+	for _, name := range []string{filepath.Join("uploads", "driver-1_sim"), ".env"} {
+		if err := os.WriteFile(name, []byte("x"), 0644); err != nil {
+			t.Fatalf("error while writing %s: %v", name, err)
+		}
+	}
+
+	tests := []struct {
+		name     string
+		sim      string
+		want     string
+		wantCode int
+	}{
+		{name: "uploaded scan", sim: "uploads/driver-1_sim", want: filepath.Join("uploads", "driver-1_sim")},
+		{name: "no scan", sim: "", wantCode: 404},
+		{name: "file outside uploads", sim: ".env", wantCode: 404},
+		{name: "traversal out of uploads", sim: "uploads/../.env", wantCode: 404},
+		{name: "absolute path", sim: "/etc/passwd", wantCode: 404},
+		{name: "missing upload", sim: "uploads/driver-2_sim", wantCode: 404},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			db := testdb.New(t)
+			driver := models.User{
+				ID:           "driver-1",
+				Email:        "driver@example.com",
+				Role:         "driver",
+				DriverDetail: models.DriverDetails{ID: "driver-1", SIM: tt.sim},
+			}
+			if err := db.Create(&driver).Error; err != nil {
+				t.Fatalf("error while creating driver: %v", err)
+			}
+
+			s := &AdminServiceImpl{AdminRepo: repository.NewAdminRepo(db)}
+
+			res, err := s.GetDriverDocumentService(ctx, "driver-1", "sim")
+
+			if tt.wantCode != 0 {
+				if err == nil || err.Code != tt.wantCode {
+					t.Fatalf("expected %d, got %q, %+v", tt.wantCode, res, err)
+				}
+				return
+			}
+
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err.Err)
+			}
+			if res != tt.want {
+				t.Errorf("path = %q, want %q", res, tt.want)
+			}
+		})
+	}
+}
+
+func TestGovApproval(t *testing.T) {
+	ctx := context.Background()
+	chdir(t, t.TempDir())
+	db := testdb.New(t)
+	auth := newTestAuthService(t, db)
+	admin := &AdminServiceImpl{AdminRepo: repository.NewAdminRepo(db), AuditService: auth.AuditService}
+
+	id, err := auth.CreateGovService(ctx, dto.GovRegistrationReq{
+		Name:                 "Officer",
+		Email:                "officer@example.com",
+		PhoneNumber:          "081234567890",
+		NIP:                  "198503302010011001",
+		Password:             "password123",
+		PasswordConfirmation: "password123",
+	}, "government", nil)
+	if err != nil {
+		t.Fatalf("unexpected error: %v %v", err.Err, err.ValidationErrors)
+	}
+
+	login := func() *helper.ErrorStruct {
+		_, err := auth.LoginUserService(ctx, dto.UserLoginReq{Email: "officer@example.com", Password: "password123"}, "127.0.0.1")
+		return err
+	}
+
+	if err := login(); err == nil || !errors.Is(err.Err, helper.ErrNotVerified) {
+		t.Fatalf("login before review = %+v, want %v", err, helper.ErrNotVerified)
+	}
+
+	if _, err := admin.RejectGovService(ctx, id, "admin-1", dto.RejectGovReq{}); err == nil || err.ValidationErrors["Reason"] == "" {
+		t.Fatalf("expected a validation error on Reason, got %+v", err)
+	}
+
+	if _, err := admin.RejectGovService(ctx, "unknown", "admin-1", dto.RejectGovReq{Reason: "NIP tidak valid"}); err == nil || err.Code != 404 {
+		t.Fatalf("rejecting an unknown officer = %+v, want 404", err)
+	}
+
+	if _, err := admin.RejectGovService(ctx, id, "admin-1", dto.RejectGovReq{Reason: "NIP tidak valid"}); err != nil {
+		t.Fatalf("unexpected error: %v", err.Err)
+	}
+
+	err = login()
+	if err == nil || !errors.Is(err.Err, helper.ErrRejected) || !strings.Contains(err.Err.Error(), "NIP tidak valid") {
+		t.Fatalf("login after rejection = %+v, want %v with the reason", err, helper.ErrRejected)
+	}
+
+	// A rejection can be overturned.
+	if _, err := admin.ApproveGovService(ctx, id, "admin-1"); err != nil {
+		t.Fatalf("unexpected error: %v", err.Err)
+	}
+
+	if err := login(); err != nil {
+		t.Fatalf("login after approval: %v", err.Err)
+	}
+
+	var events []models.AuthEvent
+	db.Order("id").Find(&events, "target_id = ? AND type IN ?", id, []string{models.EventGovReject, models.EventGovApprove})
+	if len(events) != 2 || events[0].Type != models.EventGovReject || events[0].Reason != "NIP tidak valid" || events[1].Type != models.EventGovApprove {
+		t.Errorf("audit events = %+v, want a rejection with its reason then an approval", events)
+	}
+}
+
+func TestDriverVerification(t *testing.T) {
+	ctx := context.Background()
+	db := testdb.New(t)
+	admin := &AdminServiceImpl{AdminRepo: repository.NewAdminRepo(db), AuditService: NewAuditService(repository.NewAuditRepo(db))}
+
+	for _, id := range []string{"driver-1", "driver-2"} {
+		driver := models.User{
+			ID:           id,
+			Email:        id + "@example.com",
+			Role:         "driver",
+			DriverDetail: models.DriverDetails{ID: id},
+		}
+		if err := db.Create(&driver).Error; err != nil {
+			t.Fatalf("error while creating driver: %v", err)
+		}
+	}
+
+	pending := func() []string {
+		t.Helper()
+
+		res, _, err := admin.GetPendingDriversService(ctx, 1, 10)
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err.Err)
+		}
+
+		var ids []string
+		for _, driver := range res {
+			ids = append(ids, driver.ID)
+		}
+		return ids
+	}
+
+	if _, err := admin.ApproveDriverService(ctx, "driver-1", "admin-1"); err != nil {
+		t.Fatalf("unexpected error: %v", err.Err)
+	}
+
+	if _, err := admin.ApproveDriverService(ctx, "driver-1", "admin-1"); err == nil || err.Code != 409 {
+		t.Fatalf("approving twice = %+v, want 409", err)
+	}
+
+	if _, err := admin.RejectDriverService(ctx, "driver-2", "admin-1", dto.RejectDriverReq{Reason: "SIM buram"}); err != nil {
+		t.Fatalf("unexpected error: %v", err.Err)
+	}
+
+	if _, err := admin.RejectDriverService(ctx, "driver-2", "admin-1", dto.RejectDriverReq{Reason: "SIM buram"}); err == nil || err.Code != 409 {
+		t.Fatalf("rejecting twice = %+v, want 409", err)
+	}
+
+	if ids := pending(); len(ids) != 0 {
+		t.Fatalf("pending = %v, want none", ids)
+	}
+
+	// Only the latest review decides whether a driver is back in the queue.
+	if err := db.Model(&models.DriverDetails{}).Where("id = ?", "driver-1").Update("verified", false).Error; err != nil {
+		t.Fatalf("error while resetting driver: %v", err)
+	}
+
+	if err := db.Create(&models.DriverVerification{DriverID: "driver-1", Status: "rejected"}).Error; err != nil {
+		t.Fatalf("error while creating verification: %v", err)
+	}
+	if err := db.Create(&models.DriverVerification{DriverID: "driver-1", Status: "approved"}).Error; err != nil {
+		t.Fatalf("error while creating verification: %v", err)
+	}
+
+	if ids := pending(); len(ids) != 1 || ids[0] != "driver-1" {
+		t.Fatalf("pending = %v, want [driver-1]", ids)
+	}
+
+	var count int64
+	db.Model(&models.DriverVerification{}).Where("driver_id = ?", "driver-2").Count(&count)
+	if count != 1 {
+		t.Errorf("driver-2 has %d verification rows, want 1", count)
+	}
+}
+
+func TestGetUsersSearchIsLiteral(t *testing.T) {
+	ctx := context.Background()
+	db := testdb.New(t)
+	admin := &AdminServiceImpl{AdminRepo: repository.NewAdminRepo(db)}
+
+	for _, email := range []string{"first_last@example.com", "firstXlast@example.com", "100%@example.com"} {
+		user := models.User{ID: email, Email: email, Role: "user"}
+		if err := db.Omit("DriverDetail", "PassengerDetail", "AdminDetail", "OwnerDetail", "GovDetail").Create(&user).Error; err != nil {
+			t.Fatalf("error while creating user: %v", err)
+		}
+	}
+
+	tests := []struct {
+		search string
+		want   []string
+	}{
+		{"first_last", []string{"first_last@example.com"}},
+		{"_", []string{"first_last@example.com"}},
+		{"%", []string{"100%@example.com"}},
+		{"!", nil},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.search, func(t *testing.T) {
+			res, _, err := admin.GetUsersService(ctx, dto.AdminUserQuery{Search: tt.search})
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err.Err)
+			}
+
+			var got []string
+			for _, user := range res {
+				got = append(got, user.Email)
+			}
+
+			if strings.Join(got, ",") != strings.Join(tt.want, ",") {
+				t.Errorf("search %q = %v, want %v", tt.search, got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,125 @@
+package service
+
+import (
+	"context"
+	"log"
+	"time"
+
+	"github.com/GabrielMoody/mikronet-auth-service/internal/dto"
+	"github.com/GabrielMoody/mikronet-auth-service/internal/helper"
+	"github.com/GabrielMoody/mikronet-auth-service/internal/models"
+	"github.com/GabrielMoody/mikronet-auth-service/internal/repository"
+	"github.com/gofiber/fiber/v2"
+)
+
+type AuditService interface {
+	RecordEvent(c context.Context, event models.AuthEvent)
+	GetAuthEventsService(c context.Context, query dto.AuthEventQuery) (res []dto.AuthEventResp, meta dto.CursorResp, err *helper.ErrorStruct)
+}
+
+type AuditServiceImpl struct {
+	AuditRepo repository.AuditRepo
+}
+
+// RecordEvent fills in the client details of the current request and appends
+// event to the log. A failed write is logged rather than failing the action
+// being audited.
+func (a *AuditServiceImpl) RecordEvent(c context.Context, event models.AuthEvent) {
+	info := helper.RequestInfoFrom(c)
+
+	event.IP = info.IP
+	event.UserAgent = info.UserAgent
+	if len(event.UserAgent) > 512 {
+		event.UserAgent = event.UserAgent[:512]
+	}
+	if len(event.Reason) > 255 {
+		event.Reason = event.Reason[:255]
+	}
+	event.CreatedAt = time.Now()
+
+	if err := a.AuditRepo.CreateAuthEvent(c, event); err != nil {
+		log.Printf("error while recording %s event for %s: %v", event.Type, event.TargetID, err)
+	}
+}
+
+// recordResult records a success, or a failure with err as the reason.
+func recordResult(c context.Context, audit AuditService, event models.AuthEvent, err error) {
+	event.Outcome = models.OutcomeSuccess
+
+	if err != nil {
+		event.Outcome = models.OutcomeFailure
+		event.Reason = err.Error()
+	}
+
+	audit.RecordEvent(c, event)
+}
+
+func (a *AuditServiceImpl) GetAuthEventsService(c context.Context, query dto.AuthEventQuery) (res []dto.AuthEventResp, meta dto.CursorResp, err *helper.ErrorStruct) {
+	if errValidate := helper.Validate.Struct(query); errValidate != nil {
+		return res, meta, &helper.ErrorStruct{
+			Code:             fiber.StatusBadRequest,
+			ValidationErrors: helper.ValidationError(errValidate),
+		}
+	}
+
+	_, limit := pagination(1, query.Limit)
+
+	filter := repository.AuthEventFilter{
+		Type:     query.Type,
+		Outcome:  query.Outcome,
+		ActorID:  query.ActorID,
+		TargetID: query.TargetID,
+		Cursor:   query.Cursor,
+		// One extra row tells whether there is a next page.
+		Limit: limit + 1,
+	}
+
+	// Validated above.
+	filter.From, _ = parseTime(query.From)
+	filter.To, _ = parseTime(query.To)
+
+	resRepo, errRepo := a.AuditRepo.GetAuthEvents(c, filter)
+
+	if errRepo != nil {
+		return res, meta, helper.CheckError(errRepo)
+	}
+
+	meta.Limit = limit
+
+	if len(resRepo) > limit {
+		resRepo = resRepo[:limit]
+		meta.NextCursor = resRepo[limit-1].ID
+	}
+
+	res = make([]dto.AuthEventResp, 0, len(resRepo))
+	for _, event := range resRepo {
+		res = append(res, dto.AuthEventResp{
+			ID:         event.ID,
+			Type:       event.Type,
+			Outcome:    event.Outcome,
+			ActorID:    event.ActorID,
+			TargetID:   event.TargetID,
+			Identifier: event.Identifier,
+			IP:         event.IP,
+			UserAgent:  event.UserAgent,
+			Reason:     event.Reason,
+			CreatedAt:  event.CreatedAt,
+		})
+	}
+
+	return res, meta, nil
+}
+
+func parseTime(s string) (time.Time, error) {
+	if s == "" {
+		return time.Time{}, nil
+	}
+
+	return time.Parse(time.RFC3339, s)
+}
+
+func NewAuditService(auditRepo repository.AuditRepo) AuditService {
+	return &AuditServiceImpl{
+		AuditRepo: auditRepo,
+	}
+}
@@ -4,38 +4,136 @@ import (
 	"context"
 	"errors"
 	"fmt"
+	"log"
 	"os"
 	"path/filepath"
+	"strings"
 	"time"
 
 	"github.com/GabrielMoody/mikronet-auth-service/internal/dto"
+	"github.com/GabrielMoody/mikronet-auth-service/internal/google"
 	"github.com/GabrielMoody/mikronet-auth-service/internal/helper"
+	"github.com/GabrielMoody/mikronet-auth-service/internal/mailer"
 	"github.com/GabrielMoody/mikronet-auth-service/internal/models"
 	"github.com/GabrielMoody/mikronet-auth-service/internal/repository"
+	"github.com/GabrielMoody/mikronet-auth-service/internal/sms"
 	"github.com/gofiber/fiber/v2"
+	"github.com/golang-jwt/jwt/v5"
 	"github.com/google/uuid"
 	"golang.org/x/crypto/bcrypt"
-	"gopkg.in/gomail.v2"
 )
 
 type AuthService interface {
 	CreateUserService(c context.Context, data dto.UserRegistrationsReq, role string) (res string, err *helper.ErrorStruct)
-	CreateDriverService(c context.Context, data dto.DriverRegistrationsReq, role string, pp []byte, ktp []byte) (res string, err *helper.ErrorStruct)
-	LoginUserService(c context.Context, data dto.UserLoginReq) (res dto.UserRegistrationsResp, err *helper.ErrorStruct)
+	CreateDriverService(c context.Context, data dto.DriverRegistrationsReq, role string, pp []byte, ktp []byte, sim []byte) (res string, err *helper.ErrorStruct)
+	CreateOwnerService(c context.Context, data dto.OwnerRegistrationsReq, role string, pp []byte) (res string, err *helper.ErrorStruct)
+	CreateGovService(c context.Context, data dto.GovRegistrationReq, role string, pp []byte) (res string, err *helper.ErrorStruct)
+	CreateAdminService(c context.Context, data dto.CreateAdminReq) (res string, err *helper.ErrorStruct)
+	InviteAdminService(c context.Context, adminID string, data dto.InviteAdminReq) (res string, err *helper.ErrorStruct)
+	ResendAdminInviteService(c context.Context, adminID string, id string) (res string, err *helper.ErrorStruct)
+	LoginUserService(c context.Context, data dto.UserLoginReq, ip string) (res dto.UserRegistrationsResp, err *helper.ErrorStruct)
 	SendResetPasswordService(c context.Context, email dto.ForgotPasswordReq) (res string, err *helper.ErrorStruct)
 	ResetPassword(c context.Context, data dto.ResetPasswordReq, code string) (res string, err *helper.ErrorStruct)
+	SendResetOTPService(c context.Context, data dto.ForgotPasswordOTPReq) (res string, err *helper.ErrorStruct)
+	ResetPasswordWithOTPService(c context.Context, data dto.ResetPasswordOTPReq) (res string, err *helper.ErrorStruct)
+	SendLoginOTPService(c context.Context, data dto.LoginOTPReq) (res string, err *helper.ErrorStruct)
+	LoginWithOTPService(c context.Context, data dto.LoginOTPVerifyReq) (res dto.UserRegistrationsResp, err *helper.ErrorStruct)
+	GoogleLoginService(c context.Context, data dto.GoogleLoginReq) (res dto.UserRegistrationsResp, err *helper.ErrorStruct)
+	LinkGoogleService(c context.Context, id string, data dto.LinkGoogleReq) (res string, err *helper.ErrorStruct)
+	UnlinkGoogleService(c context.Context, id string, data dto.UnlinkGoogleReq) (res string, err *helper.ErrorStruct)
 	ChangePasswordService(c context.Context, id string, data dto.ChangePasswordReq) (res string, err *helper.ErrorStruct)
+	GetUserService(c context.Context, id string) (res dto.UserStatusResp, err *helper.ErrorStruct)
+	IsBlockedService(c context.Context, id string) (res bool, err *helper.ErrorStruct)
+	IsDriverVerifiedService(c context.Context, id string) (res bool, err *helper.ErrorStruct)
+	DeleteAccountService(c context.Context, id string, data dto.DeleteAccountReq) (res time.Time, err *helper.ErrorStruct)
+	CancelDeletionService(c context.Context, id string) (res string, err *helper.ErrorStruct)
+	PurgeAccountsService(c context.Context) (res int, err *helper.ErrorStruct)
+	VerifyEmailService(c context.Context, token string) (res string, err *helper.ErrorStruct)
+	ResendVerificationService(c context.Context, data dto.ResendVerificationReq) (res string, err *helper.ErrorStruct)
+}
+
+func publicBaseURL() string {
+	if u := os.Getenv("PUBLIC_BASE_URL"); u != "" {
+		return strings.TrimSuffix(u, "/")
+	}
+
+	return "http://localhost:8050"
 }
 
 type AuthServiceImpl struct {
-	AuthRepo repository.AuthRepo
+	AuthRepo          repository.AuthRepo
+	LoginAttemptStore repository.LoginAttemptStore
+	Mailer            mailer.Mailer
+	Templates         *mailer.Templates
+	SMSSender         sms.SMSSender
+	GoogleVerifier    google.Verifier
+	TokenService      TokenService
+	AuditService      AuditService
+}
+
+func (a *AuthServiceImpl) sendMail(c context.Context, name, locale, to string, data any) error {
+	msg, err := a.Templates.Render(name, locale, to, data)
+	if err != nil {
+		return err
+	}
+
+	return a.Mailer.Send(c, msg)
+}
+
+func (a *AuthServiceImpl) sendSecurityAlert(c context.Context, id, event string) {
+	user, err := a.AuthRepo.GetUserByID(c, id)
+	if err == nil {
+		err = a.sendMail(c, "security_alert", mailer.ResolveLocale(user.Locale), user.Email, fiber.Map{
+			"Event": event,
+			"Time":  time.Now(),
+		})
+	}
+
+	if err != nil {
+		log.Printf("error while sending %s alert to %s: %v", event, id, err)
+	}
+}
+
+// recordLogin audits a sign-in attempt on the account id, which is empty
+// when identifier matched no account.
+func (a *AuthServiceImpl) recordLogin(c context.Context, eventType, id, identifier string, err error) {
+	event := models.AuthEvent{
+		Type:       eventType,
+		TargetID:   id,
+		Identifier: identifier,
+	}
+
+	if err == nil {
+		event.ActorID = id
+	}
+
+	recordResult(c, a.AuditService, event, err)
+}
+
+func (a *AuthServiceImpl) recordPasswordChange(c context.Context, id string, err error) {
+	recordResult(c, a.AuditService, models.AuthEvent{
+		Type:     models.EventPasswordChange,
+		ActorID:  id,
+		TargetID: id,
+	}, err)
+}
+
+// normalizePhone returns nil for an empty number so the optional column
+// stays NULL. Callers validate with the "phone" tag first.
+func normalizePhone(phone string) *string {
+	if phone == "" {
+		return nil
+	}
+
+	p, _ := helper.NormalizePhone(phone)
+	return &p
 }
 
 func generateQrisData(id string) string {
 	return fmt.Sprintf("0002010102115802ID6006Manado6208%s530336054060006304A1B2", id)
 }
 
-func (a *AuthServiceImpl) CreateDriverService(c context.Context, data dto.DriverRegistrationsReq, role string, pp []byte, ktp []byte) (res string, err *helper.ErrorStruct) {
+func (a *AuthServiceImpl) CreateDriverService(c context.Context, data dto.DriverRegistrationsReq, role string, pp []byte, ktp []byte, sim []byte) (res string, err *helper.ErrorStruct) {
 	if errValidate := helper.Validate.Struct(data); errValidate != nil {
 		return "", &helper.ErrorStruct{
 			Code:             fiber.StatusBadRequest,
@@ -58,17 +156,29 @@ func (a *AuthServiceImpl) CreateDriverService(c context.Context, data dto.Driver
 	fullPath := id + "_" + timestamp
 	filePath := filepath.Join("./uploads", fullPath)
 
+	var simPath string
+	if len(sim) > 0 {
+		simPath = filePath + "_sim"
+	}
+
+	phone := normalizePhone(data.PhoneNumber)
+	if phone != nil {
+		data.PhoneNumber = *phone
+	}
+
 	user := models.User{
-		ID:       id,
-		Email:    data.Email,
-		Password: string(hashed),
-		Role:     role,
+		ID:          id,
+		Email:       data.Email,
+		PhoneNumber: phone,
+		Password:    string(hashed),
+		Role:        role,
 		DriverDetail: models.DriverDetails{
 			ID:             id,
 			Name:           data.Name,
 			PhoneNumber:    data.PhoneNumber,
 			LicenseNumber:  data.LicenseNumber,
-			SIM:            data.SIM,
+			SIMNumber:      data.SIM,
+			SIM:            simPath,
 			QrisData:       qris,
 			ProfilePicture: filePath,
 			KTP:            filePath + "_ktp",
@@ -84,6 +194,116 @@ func (a *AuthServiceImpl) CreateDriverService(c context.Context, data dto.Driver
 	os.WriteFile(filePath, pp, 0644)
 	os.WriteFile(filePath+"_ktp", ktp, 0644)
 
+	if len(sim) > 0 {
+		os.WriteFile(simPath, sim, 0644)
+	}
+
+	return resRepo, nil
+}
+
+func (a *AuthServiceImpl) CreateOwnerService(c context.Context, data dto.OwnerRegistrationsReq, role string, pp []byte) (res string, err *helper.ErrorStruct) {
+	if errValidate := helper.Validate.Struct(data); errValidate != nil {
+		return "", &helper.ErrorStruct{
+			Code:             fiber.StatusBadRequest,
+			ValidationErrors: helper.ValidationError(errValidate),
+		}
+	}
+
+	hashed, errHash := bcrypt.GenerateFromPassword([]byte(data.Password), bcrypt.DefaultCost)
+
+	if errHash != nil {
+		return "", &helper.ErrorStruct{
+			Err:  errHash,
+			Code: fiber.StatusInternalServerError,
+		}
+	}
+	id := uuid.New().String()
+
+	timestamp := time.Now().Format("20060102_150405")
+	fullPath := id + "_" + timestamp
+	filePath := filepath.Join("./uploads", fullPath)
+
+	phone := normalizePhone(data.PhoneNumber)
+	if phone != nil {
+		data.PhoneNumber = *phone
+	}
+
+	user := models.User{
+		ID:          id,
+		Email:       data.Email,
+		PhoneNumber: phone,
+		Password:    string(hashed),
+		Role:        role,
+		OwnerDetail: models.OwnerDetails{
+			ID:             id,
+			Name:           data.Name,
+			PhoneNumber:    data.PhoneNumber,
+			NIK:            data.NIK,
+			ProfilePicture: filePath,
+		},
+	}
+
+	resRepo, errRepo := a.AuthRepo.CreateOwner(c, user)
+
+	if errRepo != nil {
+		return res, helper.CheckError(errRepo)
+	}
+
+	os.WriteFile(filePath, pp, 0644)
+
+	return resRepo, nil
+}
+
+func (a *AuthServiceImpl) CreateGovService(c context.Context, data dto.GovRegistrationReq, role string, pp []byte) (res string, err *helper.ErrorStruct) {
+	if errValidate := helper.Validate.Struct(data); errValidate != nil {
+		return "", &helper.ErrorStruct{
+			Code:             fiber.StatusBadRequest,
+			ValidationErrors: helper.ValidationError(errValidate),
+		}
+	}
+
+	hashed, errHash := bcrypt.GenerateFromPassword([]byte(data.Password), bcrypt.DefaultCost)
+
+	if errHash != nil {
+		return "", &helper.ErrorStruct{
+			Err:  errHash,
+			Code: fiber.StatusInternalServerError,
+		}
+	}
+	id := uuid.New().String()
+
+	timestamp := time.Now().Format("20060102_150405")
+	fullPath := id + "_" + timestamp
+	filePath := filepath.Join("./uploads", fullPath)
+
+	phone := normalizePhone(data.PhoneNumber)
+	if phone != nil {
+		data.PhoneNumber = *phone
+	}
+
+	user := models.User{
+		ID:          id,
+		Email:       data.Email,
+		PhoneNumber: phone,
+		Password:    string(hashed),
+		Role:        role,
+		GovDetail: models.GovDetails{
+			ID:             id,
+			Name:           data.Name,
+			PhoneNumber:    data.PhoneNumber,
+			NIP:            data.NIP,
+			ProfilePicture: filePath,
+		},
+	}
+
+	resRepo, errRepo := a.AuthRepo.CreateGov(c, user)
+
+	if errRepo != nil {
+		return res, helper.CheckError(errRepo)
+	}
+
+	os.WriteFile(filePath, pp, 0644)
+
 	return resRepo, nil
 }
 
@@ -98,6 +318,7 @@ func (a *AuthServiceImpl) ChangePasswordService(c context.Context, id string, da
 	hashedNewPassword, errHashed := bcrypt.GenerateFromPassword([]byte(data.NewPassword), bcrypt.DefaultCost)
 
 	if errHashed != nil {
+		a.recordPasswordChange(c, id, errHashed)
 		return res, &helper.ErrorStruct{
 			Err:  errHashed,
 			Code: fiber.StatusInternalServerError,
@@ -106,6 +327,8 @@ func (a *AuthServiceImpl) ChangePasswordService(c context.Context, id string, da
 
 	resRepo, errRepo := a.AuthRepo.ChangePassword(c, data.OldPassword, string(hashedNewPassword), id)
 
+	a.recordPasswordChange(c, id, errRepo)
+
 	if errRepo != nil {
 		var code int
 		switch {
@@ -123,6 +346,8 @@ func (a *AuthServiceImpl) ChangePasswordService(c context.Context, id string, da
 		}
 	}
 
+	a.sendSecurityAlert(c, id, "password_changed")
+
 	return resRepo, nil
 }
 
@@ -148,16 +373,21 @@ func (a *AuthServiceImpl) CreateUserService(c context.Context, data dto.UserRegi
 	id := uuid.New().String()
 
 	user := models.User{
-		ID:       id,
-		Email:    data.Email,
-		Password: string(hashed),
-		Role:     role,
+		ID:          id,
+		Email:       data.Email,
+		PhoneNumber: normalizePhone(data.PhoneNumber),
+		Password:    string(hashed),
+		Role:        role,
+		Locale:      mailer.ResolveLocale(data.Locale),
 		PassengerDetail: models.PassengerDetails{
 			ID:          id,
 			Name:        data.Name,
 			DateOfBirth: parsedDate,
 			Age:         data.Age,
 		},
+		EmailVerification: &models.EmailVerification{
+			SentAt: time.Now(),
+		},
 	}
 
 	resRepo, errRepo := a.AuthRepo.CreateUser(c, user)
@@ -166,10 +396,17 @@ func (a *AuthServiceImpl) CreateUserService(c context.Context, data dto.UserRegi
 		return res, helper.CheckError(errRepo)
 	}
 
+	// The account exists either way; a failed send can be retried through the resend endpoint.
+	if err := a.sendVerificationMail(c, user.ID, user.Email, user.Locale); err != nil {
+		log.Printf("error while sending verification email to %s: %v", user.Email, err)
+	}
+
 	return resRepo, nil
 }
 
-func (a *AuthServiceImpl) LoginUserService(c context.Context, data dto.UserLoginReq) (res dto.UserRegistrationsResp, err *helper.ErrorStruct) {
+// CreateAdminService creates an admin with a known password. It backs the
+// "admin create" command used to bootstrap the first admin.
+func (a *AuthServiceImpl) CreateAdminService(c context.Context, data dto.CreateAdminReq) (res string, err *helper.ErrorStruct) {
 	if errValidate := helper.Validate.Struct(data); errValidate != nil {
 		return res, &helper.ErrorStruct{
 			Code:             fiber.StatusBadRequest,
@@ -177,118 +414,843 @@ func (a *AuthServiceImpl) LoginUserService(c context.Context, data dto.UserLogin
 		}
 	}
 
-	resRepo, errRepo := a.AuthRepo.LoginUser(c, data)
+	hashed, errHash := bcrypt.GenerateFromPassword([]byte(data.Password), bcrypt.DefaultCost)
+
+	if errHash != nil {
+		return res, &helper.ErrorStruct{
+			Err:  errHash,
+			Code: fiber.StatusInternalServerError,
+		}
+	}
+
+	id := uuid.New().String()
+
+	resRepo, errRepo := a.AuthRepo.CreateUser(c, models.User{
+		ID:       id,
+		Email:    data.Email,
+		Password: string(hashed),
+		Role:     "admin",
+		Locale:   mailer.DefaultLocale,
+		AdminDetail: models.Admin{
+			ID:   id,
+			Name: data.Name,
+		},
+	})
+
+	recordResult(c, a.AuditService, models.AuthEvent{
+		Type:       models.EventAdminCreate,
+		TargetID:   resRepo,
+		Identifier: data.Email,
+	}, errRepo)
 
 	if errRepo != nil {
 		return res, helper.CheckError(errRepo)
 	}
 
-	return dto.UserRegistrationsResp{
-		ID:    resRepo.ID,
-		Email: resRepo.Email,
-		Role:  resRepo.Role,
-	}, nil
+	return resRepo, nil
 }
 
-func (a *AuthServiceImpl) SendResetPasswordService(c context.Context, email dto.ForgotPasswordReq) (res string, err *helper.ErrorStruct) {
-	if err := helper.Validate.Struct(email); err != nil {
+// InviteAdminService creates an admin without a password and emails them a
+// link to set one, which is a password reset link with a longer lifetime.
+// The admin is created even if the mail cannot be sent; the invite can then
+// be sent again with ResendAdminInviteService.
+func (a *AuthServiceImpl) InviteAdminService(c context.Context, adminID string, data dto.InviteAdminReq) (res string, err *helper.ErrorStruct) {
+	if errValidate := helper.Validate.Struct(data); errValidate != nil {
 		return res, &helper.ErrorStruct{
-			Err:  err,
-			Code: fiber.StatusBadRequest,
+			Code:             fiber.StatusBadRequest,
+			ValidationErrors: helper.ValidationError(errValidate),
+		}
+	}
+
+	code, errCode := helper.GenerateToken(32)
+
+	if errCode != nil {
+		return res, &helper.ErrorStruct{
+			Err:  errCode,
+			Code: fiber.StatusInternalServerError,
 		}
 	}
 
-	code := uuid.NewString()
+	id := uuid.New().String()
+	ttl := adminInviteTTL()
+
+	user := models.User{
+		ID:     id,
+		Email:  data.Email,
+		Role:   "admin",
+		Locale: mailer.ResolveLocale(data.Locale),
+		AdminDetail: models.Admin{
+			ID:   id,
+			Name: data.Name,
+		},
+	}
+
+	resRepo, errRepo := a.AuthRepo.CreateAdminInvite(c, user, helper.HashToken(code), time.Now().Add(ttl))
+
+	recordResult(c, a.AuditService, models.AuthEvent{
+		Type:       models.EventAdminInvite,
+		ActorID:    adminID,
+		TargetID:   resRepo,
+		Identifier: data.Email,
+	}, errRepo)
+
+	if errRepo != nil {
+		return res, helper.CheckError(errRepo)
+	}
+
+	if err := a.sendAdminInvite(c, user, code, ttl); err != nil {
+		log.Printf("error while sending the invite to admin %s, it can be resent: %v", resRepo, err)
+	}
+
+	return resRepo, nil
+}
 
-	resRepo, errRepo := a.AuthRepo.SendResetPassword(c, email.Email, code)
+// ResendAdminInviteService sends a new invite link to an admin who has not
+// set a password yet. The previous link stops working.
+func (a *AuthServiceImpl) ResendAdminInviteService(c context.Context, adminID string, id string) (res string, err *helper.ErrorStruct) {
+	code, errCode := helper.GenerateToken(32)
+
+	if errCode != nil {
+		return res, &helper.ErrorStruct{
+			Err:  errCode,
+			Code: fiber.StatusInternalServerError,
+		}
+	}
+
+	ttl := adminInviteTTL()
+
+	user, errRepo := a.AuthRepo.RenewAdminInvite(c, id, helper.HashToken(code), time.Now().Add(ttl))
+
+	recordResult(c, a.AuditService, models.AuthEvent{
+		Type:       models.EventAdminInviteResend,
+		ActorID:    adminID,
+		TargetID:   id,
+		Identifier: user.Email,
+	}, errRepo)
 
 	if errRepo != nil {
 		return res, helper.CheckError(errRepo)
 	}
 
-	const CONFIG_SMTP_HOST = "smtp.gmail.com"
-	const CONFIG_SMTP_PORT = 587
-	const CONFIG_SENDER_NAME = "Mikronet <test.mikronet@gmail.com>"
-	const CONFIG_AUTH_EMAIL = "test.mikronet@gmail.com"
-
-	html := fmt.Sprintf(`
-		<a href="http://188.166.179.146:8000/api/auth/reset-password/%s"
-        style="
-		color: #fff;
-		background-color: #0069d9;
-		display: inline-block;
-        font-weight: 400;
-        text-align: center;
-        white-space: nowrap;
-        vertical-align: middle;
-        -webkit-user-select: none;
-        -moz-user-select: none;
-        -ms-user-select: none;
-        user-select: none;
-        border: 1px solid transparent;
-        padding: .375rem .75rem;
-        font-size: 1rem;
-        line-height: 1.5;
-        border-radius: .25rem;
-		text-decoration: none;">Reset Password</a>
-	`, resRepo.Code)
-
-	mailer := gomail.NewMessage()
-	mailer.SetHeader("From", CONFIG_SENDER_NAME)
-	mailer.SetHeader("To", email.Email)
-	mailer.SetHeader("Subject", "Reset Password")
-	mailer.SetAddressHeader("Cc", CONFIG_AUTH_EMAIL, "Mikronet <test.mikronet@gmail.com>")
-	mailer.SetBody("text/html", html)
-
-	dialer := gomail.NewDialer(
-		CONFIG_SMTP_HOST,
-		CONFIG_SMTP_PORT,
-		CONFIG_AUTH_EMAIL,
-		"tiuq dxsj ubgf ztxf",
-	)
-
-	if err := dialer.DialAndSend(mailer); err != nil {
+	if err := a.sendAdminInvite(c, user, code, ttl); err != nil {
 		return res, &helper.ErrorStruct{
 			Err:  err,
 			Code: fiber.StatusInternalServerError,
 		}
 	}
 
-	return "Link reset password telah dikirim ke email anda!", nil
+	return "undangan berhasil dikirim ulang!", nil
 }
 
-func (a *AuthServiceImpl) ResetPassword(c context.Context, data dto.ResetPasswordReq, code string) (res string, err *helper.ErrorStruct) {
-	if err := helper.Validate.Struct(data); err != nil {
-		return "", &helper.ErrorStruct{
-			Err:  err,
-			Code: fiber.StatusBadRequest,
+func adminInviteTTL() time.Duration {
+	return helper.GetEnvDuration("ADMIN_INVITE_TTL", time.Hour*72)
+}
+
+func (a *AuthServiceImpl) sendAdminInvite(c context.Context, user models.User, code string, ttl time.Duration) error {
+	return a.sendMail(c, "admin_invite", mailer.ResolveLocale(user.Locale), user.Email, fiber.Map{
+		"Name":           user.AdminDetail.Name,
+		"Link":           fmt.Sprintf("%s/reset-password/%s", publicBaseURL(), code),
+		"ExpiresInHours": int(ttl.Hours()),
+	})
+}
+
+func (a *AuthServiceImpl) LoginUserService(c context.Context, data dto.UserLoginReq, ip string) (res dto.UserRegistrationsResp, err *helper.ErrorStruct) {
+	if errValidate := helper.Validate.Struct(data); errValidate != nil {
+		return res, &helper.ErrorStruct{
+			Code:             fiber.StatusBadRequest,
+			ValidationErrors: helper.ValidationError(errValidate),
 		}
 	}
 
-	password, _ := bcrypt.GenerateFromPassword([]byte(data.Password), bcrypt.DefaultCost)
+	emailKey, ipKey := emailAttemptKey(data.Email), ipAttemptKey(ip)
+
+	if errLockout := checkLockout(c, a.LoginAttemptStore, emailKey, ipKey); errLockout != nil {
+		a.recordLogin(c, models.EventLogin, "", data.Email, errLockout)
+		return res, helper.CheckError(errLockout)
+	}
+
+	resRepo, errRepo := a.AuthRepo.LoginUser(c, data)
+
+	// resRepo.ID is set whenever the email exists, even if the login failed.
+	a.recordLogin(c, models.EventLogin, resRepo.ID, data.Email, errRepo)
 
-	resRepo, errRepo := a.AuthRepo.ResetPassword(c, string(password), code)
+	if errors.Is(errRepo, helper.ErrPasswordIncorrect) || errors.Is(errRepo, helper.ErrNotFound) {
+		if errAttempt := registerFailure(c, a.LoginAttemptStore, emailKey, helper.GetEnvInt("LOGIN_MAX_ATTEMPTS_EMAIL", 5)); errAttempt != nil {
+			return res, helper.CheckError(errAttempt)
+		}
+
+		if errAttempt := registerFailure(c, a.LoginAttemptStore, ipKey, helper.GetEnvInt("LOGIN_MAX_ATTEMPTS_IP", 20)); errAttempt != nil {
+			return res, helper.CheckError(errAttempt)
+		}
+	}
 
 	if errRepo != nil {
-		var code int
-		switch {
-		case errors.Is(errRepo, helper.ErrExpired):
-			code = fiber.StatusGone
-		default:
-			code = fiber.StatusInternalServerError
+		return res, helper.CheckError(errRepo)
+	}
+
+	if errAttempt := a.LoginAttemptStore.ResetAttempt(c, emailKey); errAttempt != nil {
+		return res, helper.CheckError(errAttempt)
+	}
+
+	return dto.UserRegistrationsResp{
+		ID:    resRepo.ID,
+		Email: resRepo.Email,
+		Role:  resRepo.Role,
+	}, nil
+}
+
+func (a *AuthServiceImpl) SendLoginOTPService(c context.Context, data dto.LoginOTPReq) (res string, err *helper.ErrorStruct) {
+	if errValidate := helper.Validate.Struct(data); errValidate != nil {
+		return res, &helper.ErrorStruct{
+			Code:             fiber.StatusBadRequest,
+			ValidationErrors: helper.ValidationError(errValidate),
 		}
+	}
+
+	code, errCode := helper.GenerateOTP(6)
 
+	if errCode != nil {
 		return res, &helper.ErrorStruct{
-			Err:  errRepo,
-			Code: code,
+			Err:  errCode,
+			Code: fiber.StatusInternalServerError,
 		}
 	}
 
-	return resRepo, nil
+	phone, _ := helper.NormalizePhone(data.PhoneNumber)
+
+	ttl := helper.GetEnvDuration("LOGIN_OTP_TTL", 5*time.Minute)
+	limit := helper.GetEnvInt("LOGIN_OTP_MAX_PER_HOUR", 5)
+
+	if _, errRepo := a.AuthRepo.SendLoginOTP(c, phone, helper.HashToken(code), time.Now().Add(ttl), limit); errRepo != nil {
+		return res, helper.CheckError(errRepo)
+	}
+
+	body := fmt.Sprintf("Kode login Mikronet anda: %s. Berlaku %d menit. Jangan berikan kode ini kepada siapapun.", code, int(ttl.Minutes()))
+
+	if err := a.SMSSender.Send(c, phone, body); err != nil {
+		return res, &helper.ErrorStruct{
+			Err:  err,
+			Code: fiber.StatusInternalServerError,
+		}
+	}
+
+	return "Kode OTP telah dikirim ke nomor telepon anda!", nil
+}
+
+func (a *AuthServiceImpl) LoginWithOTPService(c context.Context, data dto.LoginOTPVerifyReq) (res dto.UserRegistrationsResp, err *helper.ErrorStruct) {
+	if errValidate := helper.Validate.Struct(data); errValidate != nil {
+		return res, &helper.ErrorStruct{
+			Code:             fiber.StatusBadRequest,
+			ValidationErrors: helper.ValidationError(errValidate),
+		}
+	}
+
+	phone, _ := helper.NormalizePhone(data.PhoneNumber)
+	maxAttempts := helper.GetEnvInt("LOGIN_OTP_MAX_ATTEMPTS", 5)
+
+	resRepo, errRepo := a.AuthRepo.LoginWithOTP(c, phone, helper.HashToken(data.OTP), maxAttempts)
+
+	a.recordLogin(c, models.EventLoginOTP, resRepo.ID, phone, errRepo)
+
+	if errRepo != nil {
+		return res, helper.CheckError(errRepo)
+	}
+
+	return dto.UserRegistrationsResp{
+		ID:          resRepo.ID,
+		Email:       resRepo.Email,
+		PhoneNumber: phone,
+		Role:        resRepo.Role,
+	}, nil
+}
+
+func (a *AuthServiceImpl) GoogleLoginService(c context.Context, data dto.GoogleLoginReq) (res dto.UserRegistrationsResp, err *helper.ErrorStruct) {
+	if errValidate := helper.Validate.Struct(data); errValidate != nil {
+		return res, &helper.ErrorStruct{
+			Code:             fiber.StatusBadRequest,
+			ValidationErrors: helper.ValidationError(errValidate),
+		}
+	}
+
+	claims, errVerify := a.GoogleVerifier.Verify(c, data.IDToken)
+
+	if errVerify != nil {
+		return res, helper.CheckError(errVerify)
+	}
+
+	resRepo, errRepo := a.AuthRepo.LoginWithIdentity(c, models.ProviderGoogle, claims.Subject)
+
+	if errRepo == nil || !errors.Is(errRepo, helper.ErrNotFound) {
+		a.recordLogin(c, models.EventLoginGoogle, resRepo.ID, claims.Email, errRepo)
+	}
+
+	if errRepo == nil {
+		return dto.UserRegistrationsResp{
+			ID:    resRepo.ID,
+			Email: resRepo.Email,
+			Role:  resRepo.Role,
+		}, nil
+	}
+
+	if !errors.Is(errRepo, helper.ErrNotFound) {
+		return res, helper.CheckError(errRepo)
+	}
+
+	// First sign-in with this Google account: create a passenger. Accounts
+	// created this way have no password until the user resets one.
+	if !claims.EmailVerified {
+		a.recordLogin(c, models.EventLoginGoogle, "", claims.Email, helper.ErrEmailNotVerified)
+		return res, helper.CheckError(helper.ErrEmailNotVerified)
+	}
+
+	id := uuid.New().String()
+
+	user := models.User{
+		ID:     id,
+		Email:  claims.Email,
+		Role:   "user",
+		Locale: mailer.ResolveLocale(claims.Locale, data.Locale),
+		PassengerDetail: models.PassengerDetails{
+			ID:   id,
+			Name: claims.Name,
+		},
+		LinkedIdentities: []models.LinkedIdentity{
+			{
+				Provider: models.ProviderGoogle,
+				Subject:  claims.Subject,
+				Email:    claims.Email,
+			},
+		},
+	}
+
+	if _, errRepo := a.AuthRepo.CreateUser(c, user); errRepo != nil {
+		// The email already belongs to a password account, which has to link
+		// Google itself so nobody can take it over with a Google login.
+		if errors.Is(errRepo, helper.ErrDuplicateEntry) {
+			errRepo = helper.ErrAccountExists
+		}
+		a.recordLogin(c, models.EventLoginGoogle, "", claims.Email, errRepo)
+		return res, helper.CheckError(errRepo)
+	}
+
+	a.recordLogin(c, models.EventLoginGoogle, user.ID, claims.Email, nil)
+
+	return dto.UserRegistrationsResp{
+		ID:    user.ID,
+		Email: user.Email,
+		Role:  user.Role,
+	}, nil
+}
+
+func (a *AuthServiceImpl) LinkGoogleService(c context.Context, id string, data dto.LinkGoogleReq) (res string, err *helper.ErrorStruct) {
+	if errValidate := helper.Validate.Struct(data); errValidate != nil {
+		return res, &helper.ErrorStruct{
+			Code:             fiber.StatusBadRequest,
+			ValidationErrors: helper.ValidationError(errValidate),
+		}
+	}
+
+	claims, errVerify := a.GoogleVerifier.Verify(c, data.IDToken)
+
+	if errVerify != nil {
+		return res, helper.CheckError(errVerify)
+	}
+
+	if _, errRepo := a.AuthRepo.LinkIdentity(c, id, data.Password, models.LinkedIdentity{
+		Provider: models.ProviderGoogle,
+		Subject:  claims.Subject,
+		Email:    claims.Email,
+	}); errRepo != nil {
+		return res, helper.CheckError(errRepo)
+	}
+
+	return "Akun google berhasil dihubungkan!", nil
+}
+
+func (a *AuthServiceImpl) UnlinkGoogleService(c context.Context, id string, data dto.UnlinkGoogleReq) (res string, err *helper.ErrorStruct) {
+	if errValidate := helper.Validate.Struct(data); errValidate != nil {
+		return res, &helper.ErrorStruct{
+			Code:             fiber.StatusBadRequest,
+			ValidationErrors: helper.ValidationError(errValidate),
+		}
+	}
+
+	if errRepo := a.AuthRepo.UnlinkIdentity(c, id, data.Password, models.ProviderGoogle); errRepo != nil {
+		return res, helper.CheckError(errRepo)
+	}
+
+	return "Akun google berhasil diputuskan!", nil
+}
+
+func (a *AuthServiceImpl) SendResetPasswordService(c context.Context, email dto.ForgotPasswordReq) (res string, err *helper.ErrorStruct) {
+	if err := helper.Validate.Struct(email); err != nil {
+		return res, &helper.ErrorStruct{
+			Err:  err,
+			Code: fiber.StatusBadRequest,
+		}
+	}
+
+	code, errCode := helper.GenerateToken(32)
+
+	if errCode != nil {
+		return res, &helper.ErrorStruct{
+			Err:  errCode,
+			Code: fiber.StatusInternalServerError,
+		}
+	}
+
+	ttl := helper.GetEnvDuration("RESET_PASSWORD_TTL", time.Hour)
+	limit := helper.GetEnvInt("RESET_PASSWORD_MAX_PER_HOUR", 3)
+
+	resRepo, errRepo := a.AuthRepo.SendResetPassword(c, email.Email, helper.HashToken(code), time.Now().Add(ttl), limit)
+
+	recordResult(c, a.AuditService, models.AuthEvent{
+		Type:       models.EventPasswordResetRequest,
+		TargetID:   resRepo.UserID,
+		Identifier: email.Email,
+	}, errRepo)
+
+	if errRepo != nil {
+		return res, helper.CheckError(errRepo)
+	}
+
+	locale := mailer.ResolveLocale(resRepo.User.Locale, email.Locale)
+
+	if err := a.sendMail(c, "reset_password", locale, email.Email, fiber.Map{
+		"Link":             fmt.Sprintf("%s/reset-password/%s", publicBaseURL(), code),
+		"ExpiresInMinutes": int(ttl.Minutes()),
+	}); err != nil {
+		return res, &helper.ErrorStruct{
+			Err:  err,
+			Code: fiber.StatusInternalServerError,
+		}
+	}
+
+	return "Link reset password telah dikirim ke email anda!", nil
+}
+
+func (a *AuthServiceImpl) ResetPassword(c context.Context, data dto.ResetPasswordReq, code string) (res string, err *helper.ErrorStruct) {
+	if err := helper.Validate.Struct(data); err != nil {
+		return "", &helper.ErrorStruct{
+			Err:  err,
+			Code: fiber.StatusBadRequest,
+		}
+	}
+
+	password, _ := bcrypt.GenerateFromPassword([]byte(data.Password), bcrypt.DefaultCost)
+
+	resRepo, errRepo := a.AuthRepo.ResetPassword(c, string(password), helper.HashToken(code))
+
+	event := models.AuthEvent{Type: models.EventPasswordReset}
+	if errRepo == nil {
+		event.TargetID = resRepo
+	}
+	recordResult(c, a.AuditService, event, errRepo)
+
+	if errRepo != nil {
+		var code int
+		switch {
+		case errors.Is(errRepo, helper.ErrExpired):
+			code = fiber.StatusGone
+		default:
+			code = fiber.StatusInternalServerError
+		}
+
+		return res, &helper.ErrorStruct{
+			Err:  errRepo,
+			Code: code,
+		}
+	}
+
+	// Whoever knew the old password must not stay signed in.
+	if _, errLogout := a.TokenService.LogoutAllService(c, resRepo); errLogout != nil {
+		return res, errLogout
+	}
+
+	a.sendSecurityAlert(c, resRepo, "password_reset")
+
+	return resRepo, nil
+}
+
+func (a *AuthServiceImpl) SendResetOTPService(c context.Context, data dto.ForgotPasswordOTPReq) (res string, err *helper.ErrorStruct) {
+	if errValidate := helper.Validate.Struct(data); errValidate != nil {
+		return res, &helper.ErrorStruct{
+			Code:             fiber.StatusBadRequest,
+			ValidationErrors: helper.ValidationError(errValidate),
+		}
+	}
+
+	code, errCode := helper.GenerateOTP(6)
+
+	if errCode != nil {
+		return res, &helper.ErrorStruct{
+			Err:  errCode,
+			Code: fiber.StatusInternalServerError,
+		}
+	}
+
+	phone, _ := helper.NormalizePhone(data.PhoneNumber)
+
+	ttl := helper.GetEnvDuration("RESET_OTP_TTL", 5*time.Minute)
+	limit := helper.GetEnvInt("RESET_OTP_MAX_PER_HOUR", 3)
+
+	resRepo, errRepo := a.AuthRepo.SendResetOTP(c, phone, helper.HashToken(code), time.Now().Add(ttl), limit)
+
+	recordResult(c, a.AuditService, models.AuthEvent{
+		Type:       models.EventPasswordResetRequest,
+		TargetID:   resRepo.UserID,
+		Identifier: phone,
+	}, errRepo)
+
+	if errRepo != nil {
+		return res, helper.CheckError(errRepo)
+	}
+
+	body := fmt.Sprintf("Kode reset password Mikronet anda: %s. Berlaku %d menit. Jangan berikan kode ini kepada siapapun.", code, int(ttl.Minutes()))
+
+	if err := a.SMSSender.Send(c, phone, body); err != nil {
+		return res, &helper.ErrorStruct{
+			Err:  err,
+			Code: fiber.StatusInternalServerError,
+		}
+	}
+
+	return "Kode OTP telah dikirim ke nomor telepon anda!", nil
+}
+
+func (a *AuthServiceImpl) ResetPasswordWithOTPService(c context.Context, data dto.ResetPasswordOTPReq) (res string, err *helper.ErrorStruct) {
+	if errValidate := helper.Validate.Struct(data); errValidate != nil {
+		return res, &helper.ErrorStruct{
+			Code:             fiber.StatusBadRequest,
+			ValidationErrors: helper.ValidationError(errValidate),
+		}
+	}
+
+	phone, _ := helper.NormalizePhone(data.PhoneNumber)
+	password, _ := bcrypt.GenerateFromPassword([]byte(data.Password), bcrypt.DefaultCost)
+	maxAttempts := helper.GetEnvInt("RESET_OTP_MAX_ATTEMPTS", 5)
+
+	resRepo, errRepo := a.AuthRepo.ResetPasswordWithOTP(c, phone, helper.HashToken(data.OTP), string(password), maxAttempts)
+
+	recordResult(c, a.AuditService, models.AuthEvent{
+		Type:       models.EventPasswordReset,
+		TargetID:   resRepo,
+		Identifier: phone,
+	}, errRepo)
+
+	if errRepo != nil {
+		return res, helper.CheckError(errRepo)
+	}
+
+	if _, errLogout := a.TokenService.LogoutAllService(c, resRepo); errLogout != nil {
+		return res, errLogout
+	}
+
+	a.sendSecurityAlert(c, resRepo, "password_reset")
+
+	return resRepo, nil
+}
+
+func (a *AuthServiceImpl) GetUserService(c context.Context, id string) (res dto.UserStatusResp, err *helper.ErrorStruct) {
+	user, errRepo := a.AuthRepo.GetUserByID(c, id)
+
+	if errRepo != nil {
+		return res, helper.CheckError(errRepo)
+	}
+
+	blocked, errRepo := a.AuthRepo.IsBlocked(c, id)
+
+	if errRepo != nil {
+		return res, helper.CheckError(errRepo)
+	}
+
+	verified := true
+	switch user.Role {
+	case "driver":
+		verified, errRepo = a.AuthRepo.IsVerified(c, id)
+	case "government":
+		verified, errRepo = a.AuthRepo.IsGovVerified(c, id)
+	}
+
+	if errRepo != nil {
+		return res, helper.CheckError(errRepo)
+	}
+
+	return dto.UserStatusResp{
+		ID:        user.ID,
+		Email:     user.Email,
+		Role:      user.Role,
+		Blocked:   blocked,
+		Verified:  verified,
+		CreatedAt: user.CreatedAt,
+	}, nil
+}
+
+func (a *AuthServiceImpl) IsBlockedService(c context.Context, id string) (res bool, err *helper.ErrorStruct) {
+	resRepo, errRepo := a.AuthRepo.IsBlocked(c, id)
+
+	if errRepo != nil {
+		return res, helper.CheckError(errRepo)
+	}
+
+	return resRepo, nil
+}
+
+func (a *AuthServiceImpl) IsDriverVerifiedService(c context.Context, id string) (res bool, err *helper.ErrorStruct) {
+	resRepo, errRepo := a.AuthRepo.IsVerified(c, id)
+
+	if errRepo != nil {
+		return res, helper.CheckError(errRepo)
+	}
+
+	return resRepo, nil
+}
+
+// reauthenticate confirms a deletion with the password or, for accounts
+// created through Google sign-in that have none, a fresh ID token of the
+// linked Google account.
+func (a *AuthServiceImpl) reauthenticate(c context.Context, id string, data dto.DeleteAccountReq) error {
+	if data.IDToken == "" {
+		return a.AuthRepo.CheckPassword(c, id, data.Password)
+	}
+
+	claims, err := a.GoogleVerifier.Verify(c, data.IDToken)
+	if err != nil {
+		return err
+	}
+
+	identity, err := a.AuthRepo.GetIdentity(c, models.ProviderGoogle, claims.Subject)
+	if errors.Is(err, helper.ErrNotFound) || (err == nil && identity.UserID != id) {
+		return fmt.Errorf("%w: google account is not linked to this user", helper.ErrInvalidToken)
+	}
+
+	return err
+}
+
+func (a *AuthServiceImpl) DeleteAccountService(c context.Context, id string, data dto.DeleteAccountReq) (res time.Time, err *helper.ErrorStruct) {
+	if errValidate := helper.Validate.Struct(data); errValidate != nil {
+		return res, &helper.ErrorStruct{
+			Code:             fiber.StatusBadRequest,
+			ValidationErrors: helper.ValidationError(errValidate),
+		}
+	}
+
+	purgeAt := time.Now().Add(helper.GetEnvDuration("ACCOUNT_DELETION_GRACE_PERIOD", time.Hour*24*30))
+
+	var resRepo models.AccountDeletion
+	errRepo := a.reauthenticate(c, id, data)
+	if errRepo == nil {
+		resRepo, errRepo = a.AuthRepo.ScheduleDeletion(c, id, purgeAt)
+	}
+
+	recordResult(c, a.AuditService, models.AuthEvent{
+		Type:     models.EventAccountDeletionRequest,
+		ActorID:  id,
+		TargetID: id,
+	}, errRepo)
+
+	if errRepo != nil {
+		return res, helper.CheckError(errRepo)
+	}
+
+	// The account must be signed in again to cancel the deletion.
+	if _, errLogout := a.TokenService.LogoutAllService(c, id); errLogout != nil {
+		return res, errLogout
+	}
+
+	a.sendSecurityAlert(c, id, "account_deletion_scheduled")
+
+	return resRepo.PurgeAt, nil
+}
+
+func (a *AuthServiceImpl) CancelDeletionService(c context.Context, id string) (res string, err *helper.ErrorStruct) {
+	errRepo := a.AuthRepo.CancelDeletion(c, id)
+
+	recordResult(c, a.AuditService, models.AuthEvent{
+		Type:     models.EventAccountDeletionCancel,
+		ActorID:  id,
+		TargetID: id,
+	}, errRepo)
+
+	if errRepo != nil {
+		return res, helper.CheckError(errRepo)
+	}
+
+	return "Penghapusan akun dibatalkan", nil
+}
+
+// removeUpload deletes a file uploaded for the account id. Paths are named
+// uploads/<id>_..., so anything else was not written for this account and
+// is left alone.
+func removeUpload(id, path string) {
+	if path == "" || !strings.HasPrefix(filepath.Clean(path), filepath.Join("uploads", id)+"_") {
+		return
+	}
+
+	if err := os.Remove(path); err != nil && !errors.Is(err, os.ErrNotExist) {
+		log.Printf("error while removing %s: %v", path, err)
+	}
+}
+
+func (a *AuthServiceImpl) PurgeAccountsService(c context.Context) (res int, err *helper.ErrorStruct) {
+	users, errRepo := a.AuthRepo.GetDueDeletions(c, time.Now())
+
+	if errRepo != nil {
+		return res, helper.CheckError(errRepo)
+	}
+
+	for _, user := range users {
+		_, errRepo := a.AuthRepo.DeleteUser(c, user.ID)
+
+		recordResult(c, a.AuditService, models.AuthEvent{
+			Type:       models.EventAccountPurge,
+			TargetID:   user.ID,
+			Identifier: user.Email,
+		}, errRepo)
+
+		if errRepo != nil {
+			return res, helper.CheckError(errRepo)
+		}
+
+		removeUpload(user.ID, user.DriverDetail.ProfilePicture)
+		removeUpload(user.ID, user.DriverDetail.KTP)
+		removeUpload(user.ID, user.DriverDetail.SIM)
+		removeUpload(user.ID, user.OwnerDetail.ProfilePicture)
+		removeUpload(user.ID, user.GovDetail.ProfilePicture)
+
+		res++
+	}
+
+	return res, nil
+}
+
+// RunAccountPurger hard-deletes accounts whose grace period has passed and
+// prunes expired token revocations, once per interval, until ctx is
+// cancelled.
+func RunAccountPurger(ctx context.Context, authService AuthService, tokenService TokenService, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		res, err := authService.PurgeAccountsService(ctx)
+		if err != nil {
+			log.Printf("error while purging accounts: %v", err.Err)
+		} else if res > 0 {
+			log.Printf("purged %d account(s)", res)
+		}
+
+		if res, err := tokenService.PruneRevocationsService(ctx); err != nil {
+			log.Printf("error while pruning revocations: %v", err.Err)
+		} else if res > 0 {
+			log.Printf("pruned %d revocation(s)", res)
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+func (a *AuthServiceImpl) sendVerificationMail(c context.Context, id, email, locale string) error {
+	token, err := helper.SignJWT(jwt.MapClaims{
+		"sub":   id,
+		"email": email,
+		"typ":   helper.TokenTypeEmail,
+		"exp":   time.Now().Add(helper.EmailTokenTTL).Unix(),
+		"iss":   os.Getenv("JWT_ISS"),
+	})
+
+	if err != nil {
+		return err
+	}
+
+	return a.sendMail(c, "verify_email", locale, email, fiber.Map{
+		"Link":           fmt.Sprintf("%s/verify-email/%s", publicBaseURL(), token),
+		"ExpiresInHours": int(helper.EmailTokenTTL.Hours()),
+	})
+}
+
+func (a *AuthServiceImpl) VerifyEmailService(c context.Context, token string) (res string, err *helper.ErrorStruct) {
+	claims, errParse := helper.ParseJWT(token)
+
+	if errParse != nil || claims["typ"] != helper.TokenTypeEmail {
+		return res, &helper.ErrorStruct{
+			Err:  helper.ErrExpired,
+			Code: fiber.StatusGone,
+		}
+	}
+
+	id, _ := claims["sub"].(string)
+
+	user, errRepo := a.AuthRepo.GetUserByID(c, id)
+
+	if errRepo != nil {
+		return res, helper.CheckError(errRepo)
+	}
+
+	if user.Email != claims["email"] {
+		return res, helper.CheckError(helper.ErrInvalidToken)
+	}
+
+	errRepo = a.AuthRepo.VerifyEmail(c, id)
+
+	recordResult(c, a.AuditService, models.AuthEvent{
+		Type:       models.EventEmailVerify,
+		ActorID:    id,
+		TargetID:   id,
+		Identifier: user.Email,
+	}, errRepo)
+
+	if errRepo != nil {
+		return res, helper.CheckError(errRepo)
+	}
+
+	return "Email berhasil diverifikasi!", nil
+}
+
+func (a *AuthServiceImpl) ResendVerificationService(c context.Context, data dto.ResendVerificationReq) (res string, err *helper.ErrorStruct) {
+	if errValidate := helper.Validate.Struct(data); errValidate != nil {
+		return res, &helper.ErrorStruct{
+			Code:             fiber.StatusBadRequest,
+			ValidationErrors: helper.ValidationError(errValidate),
+		}
+	}
+
+	pending, errRepo := a.AuthRepo.GetEmailVerification(c, data.Email)
+
+	if errRepo != nil {
+		return res, helper.CheckError(errRepo)
+	}
+
+	if wait := time.Minute - time.Since(pending.SentAt); wait > 0 {
+		return res, helper.CheckError(&helper.TooManyAttemptsError{RetryAfter: wait})
+	}
+
+	if _, errRepo := a.AuthRepo.CreateEmailVerification(c, pending.UserID); errRepo != nil {
+		return res, helper.CheckError(errRepo)
+	}
+
+	if err := a.sendVerificationMail(c, pending.UserID, data.Email, mailer.ResolveLocale(pending.User.Locale, data.Locale)); err != nil {
+		return res, &helper.ErrorStruct{
+			Err:  err,
+			Code: fiber.StatusInternalServerError,
+		}
+	}
+
+	return "Link verifikasi telah dikirim ke email anda!", nil
 }
 
-func NewAuthService(authRepo repository.AuthRepo) AuthService {
+func NewAuthService(authRepo repository.AuthRepo, loginAttemptStore repository.LoginAttemptStore, mail mailer.Mailer, templates *mailer.Templates, smsSender sms.SMSSender, googleVerifier google.Verifier, tokenService TokenService, auditService AuditService) AuthService {
 	return &AuthServiceImpl{
-		AuthRepo: authRepo,
+		AuthRepo:          authRepo,
+		LoginAttemptStore: loginAttemptStore,
+		Mailer:            mail,
+		Templates:         templates,
+		SMSSender:         smsSender,
+		GoogleVerifier:    googleVerifier,
+		TokenService:      tokenService,
+		AuditService:      auditService,
 	}
 }
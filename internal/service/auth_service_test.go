@@ -0,0 +1,508 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"regexp"
+	"testing"
+	"time"
+
+	"github.com/GabrielMoody/mikronet-auth-service/internal/dto"
+	"github.com/GabrielMoody/mikronet-auth-service/internal/google"
+	"github.com/GabrielMoody/mikronet-auth-service/internal/helper"
+	"github.com/GabrielMoody/mikronet-auth-service/internal/mailer"
+	"github.com/GabrielMoody/mikronet-auth-service/internal/models"
+	"github.com/GabrielMoody/mikronet-auth-service/internal/repository"
+	"github.com/GabrielMoody/mikronet-auth-service/internal/sms"
+	"github.com/GabrielMoody/mikronet-auth-service/internal/testdb"
+	"golang.org/x/crypto/bcrypt"
+	"gorm.io/gorm"
+)
+
+// fakeGoogleVerifier accepts the ID tokens it was given, each standing for
+// one Google account.
+type fakeGoogleVerifier map[string]google.Claims
+
+func (f fakeGoogleVerifier) Verify(c context.Context, idToken string) (google.Claims, error) {
+	claims, ok := f[idToken]
+	if !ok {
+		return claims, helper.ErrInvalidToken
+	}
+
+	return claims, nil
+}
+
+// templatesDir is resolved before any test changes directory.
+var templatesDir, _ = filepath.Abs(filepath.Join("..", "..", "views", "email"))
+
+// newTestAuthService wires an AuthService to db with in-memory mail and SMS
+// backends and the real email templates.
+func newTestAuthService(t *testing.T, db *gorm.DB) *AuthServiceImpl {
+	t.Helper()
+
+	templates, err := mailer.LoadTemplates(templatesDir)
+	if err != nil {
+		t.Fatalf("error while loading email templates: %v", err)
+	}
+
+	return &AuthServiceImpl{
+		AuthRepo:          repository.NewAuthRepo(db),
+		LoginAttemptStore: repository.NewMemoryLoginAttemptStore(),
+		Mailer:            mailer.NewMemoryMailer(),
+		Templates:         templates,
+		SMSSender:         sms.NewMemorySender(),
+		GoogleVerifier:    fakeGoogleVerifier{},
+		TokenService:      newTestTokenService(t, db),
+		AuditService:      NewAuditService(repository.NewAuditRepo(db)),
+	}
+}
+
+// createUser stores a user with the given password; an empty password makes
+// a Google-only account.
+func createUser(t *testing.T, db *gorm.DB, user models.User, password string) models.User {
+	t.Helper()
+
+	if user.Email == "" {
+		user.Email = user.ID + "@example.com"
+	}
+
+	if password != "" {
+		hashed, _ := bcrypt.GenerateFromPassword([]byte(password), bcrypt.MinCost)
+		user.Password = string(hashed)
+	}
+
+	if err := db.Create(&user).Error; err != nil {
+		t.Fatalf("error while creating user %s: %v", user.ID, err)
+	}
+
+	return user
+}
+
+func TestCreateOwnerService(t *testing.T) {
+	ctx := context.Background()
+
+	tests := []struct {
+		name      string
+		phone     string
+		wantField string
+		wantPhone *string
+	}{
+		{name: "local number is normalized", phone: "081234567890", wantPhone: ptr("+6281234567890")},
+		{name: "phone is optional", phone: ""},
+		{name: "invalid phone", phone: "12345", wantField: "PhoneNumber"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			db := testdb.New(t)
+			s := &AuthServiceImpl{AuthRepo: repository.NewAuthRepo(db)}
+
+			id, err := s.CreateOwnerService(ctx, dto.OwnerRegistrationsReq{
+				Name:                 "Owner",
+				Email:                "owner@example.com",
+				PhoneNumber:          tt.phone,
+				NIK:                  "7171014501900001",
+				Password:             "password123",
+				PasswordConfirmation: "password123",
+			}, "owner", nil)
+
+			if tt.wantField != "" {
+				if err == nil || err.Code != 400 || err.ValidationErrors[tt.wantField] == "" {
+					t.Fatalf("expected a validation error on %s, got %+v", tt.wantField, err)
+				}
+				return
+			}
+
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err.Err)
+			}
+
+			var user models.User
+			if errDB := db.Preload("OwnerDetail").First(&user, "id = ?", id).Error; errDB != nil {
+				t.Fatalf("owner was not stored: %v", errDB)
+			}
+
+			if !equalPtr(user.PhoneNumber, tt.wantPhone) {
+				t.Errorf("users.phone_number = %v, want %v", deref(user.PhoneNumber), deref(tt.wantPhone))
+			}
+			if user.OwnerDetail.PhoneNumber != deref(tt.wantPhone) {
+				t.Errorf("owner_details.phone_number = %q, want %q", user.OwnerDetail.PhoneNumber, deref(tt.wantPhone))
+			}
+		})
+	}
+}
+
+func ptr[T any](v T) *T {
+	return &v
+}
+
+func deref(s *string) string {
+	if s == nil {
+		return ""
+	}
+
+	return *s
+}
+
+func equalPtr(a, b *string) bool {
+	if a == nil || b == nil {
+		return a == b
+	}
+
+	return *a == *b
+}
+
+type failingMailer struct{}
+
+func (failingMailer) Send(c context.Context, msg mailer.Message) error {
+	return errors.New("smtp unavailable")
+}
+
+func TestInviteAdminService(t *testing.T) {
+	ctx := context.Background()
+	db := testdb.New(t)
+	s := newTestAuthService(t, db)
+	s.Mailer = failingMailer{}
+
+	// The admin exists even though the invite could not be mailed.
+	id, err := s.InviteAdminService(ctx, "admin-1", dto.InviteAdminReq{Email: "new@example.com", Name: "New"})
+	if err != nil {
+		t.Fatalf("invite failed: %+v", err)
+	}
+
+	var rp models.ResetPassword
+	if err := db.First(&rp, "user_id = ?", id).Error; err != nil {
+		t.Fatalf("invite code was not stored: %v", err)
+	}
+	if rp.RequestCount != 0 {
+		t.Errorf("invite used %d of the reset requests, want 0", rp.RequestCount)
+	}
+
+	mail := mailer.NewMemoryMailer()
+	s.Mailer = mail
+
+	if _, err := s.ResendAdminInviteService(ctx, "admin-1", id); err != nil {
+		t.Fatalf("resend failed: %+v", err)
+	}
+
+	msgs := mail.Messages()
+	if len(msgs) != 1 || msgs[0].To != "new@example.com" {
+		t.Fatalf("expected one invite to the admin, got %+v", msgs)
+	}
+
+	code := regexp.MustCompile(`/reset-password/([A-Za-z0-9_-]+)`).FindStringSubmatch(msgs[0].Text)
+	if code == nil {
+		t.Fatalf("no link in the invite: %s", msgs[0].Text)
+	}
+
+	if _, err := s.ResetPassword(ctx, dto.ResetPasswordReq{Password: "password123", PasswordConfirmation: "password123"}, code[1]); err != nil {
+		t.Fatalf("setting the password from the invite failed: %+v", err)
+	}
+
+	// Once the password is set there is nothing left to resend.
+	if _, err := s.ResendAdminInviteService(ctx, "admin-1", id); err == nil || !errors.Is(err.Err, helper.ErrNotFound) {
+		t.Fatalf("resending to an active admin = %+v, want %v", err, helper.ErrNotFound)
+	}
+}
+
+func TestDeleteAccountService(t *testing.T) {
+	ctx := context.Background()
+
+	tests := []struct {
+		name    string
+		user    string
+		req     dto.DeleteAccountReq
+		wantErr error
+		invalid bool
+	}{
+		{name: "password", user: "password-user", req: dto.DeleteAccountReq{Password: "password123"}},
+		{name: "wrong password", user: "password-user", req: dto.DeleteAccountReq{Password: "wrong-password"}, wantErr: helper.ErrPasswordIncorrect},
+		{name: "google-only account with its google token", user: "google-user", req: dto.DeleteAccountReq{IDToken: "google-user-token"}},
+		{name: "google token of another account", user: "password-user", req: dto.DeleteAccountReq{IDToken: "google-user-token"}, wantErr: helper.ErrInvalidToken},
+		{name: "google token that does not verify", user: "google-user", req: dto.DeleteAccountReq{IDToken: "forged"}, wantErr: helper.ErrInvalidToken},
+		{name: "no credentials", user: "google-user", req: dto.DeleteAccountReq{}, invalid: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			db := testdb.New(t)
+			s := newTestAuthService(t, db)
+			s.GoogleVerifier = fakeGoogleVerifier{
+				"google-user-token": {Subject: "google-sub", Email: "google-user@example.com", EmailVerified: true},
+			}
+
+			createUser(t, db, models.User{ID: "password-user", Role: "user"}, "password123")
+			createUser(t, db, models.User{
+				ID:               "google-user",
+				Role:             "user",
+				LinkedIdentities: []models.LinkedIdentity{{Provider: models.ProviderGoogle, Subject: "google-sub"}},
+			}, "")
+
+			tokens, errIssue := s.TokenService.IssueTokenService(ctx, dto.UserRegistrationsResp{ID: tt.user, Role: "user"}, dto.DeviceInfo{})
+			if errIssue != nil {
+				t.Fatalf("issue failed: %v", errIssue.Err)
+			}
+
+			purgeAt, err := s.DeleteAccountService(ctx, tt.user, tt.req)
+			_, errRefresh := s.TokenService.RefreshTokenService(ctx, dto.RefreshTokenReq{RefreshToken: tokens.RefreshToken}, dto.DeviceInfo{})
+
+			var count int64
+			db.Model(&models.AccountDeletion{}).Where("user_id = ?", tt.user).Count(&count)
+
+			switch {
+			case tt.invalid:
+				if err == nil || err.Code != 400 {
+					t.Fatalf("expected a validation error, got %+v", err)
+				}
+			case tt.wantErr != nil:
+				if err == nil || !errors.Is(err.Err, tt.wantErr) {
+					t.Fatalf("expected %v, got %+v", tt.wantErr, err)
+				}
+			default:
+				if err != nil {
+					t.Fatalf("unexpected error: %v", err.Err)
+				}
+				if purgeAt.Before(time.Now()) {
+					t.Errorf("purge date %v is not in the future", purgeAt)
+				}
+
+				msgs := s.Mailer.(*mailer.MemoryMailer).Messages()
+				if len(msgs) != 1 || msgs[0].To != tt.user+"@example.com" {
+					t.Errorf("expected one security alert to the user, got %+v", msgs)
+				}
+			}
+
+			if signedOut := errRefresh != nil; signedOut != (err == nil) {
+				t.Errorf("signed out = %v with error %+v", signedOut, err)
+			}
+
+			if scheduled := count == 1; scheduled != (err == nil) {
+				t.Errorf("deletion scheduled = %v with error %+v", scheduled, err)
+			}
+		})
+	}
+}
+
+func TestPurgeRemovesOnlyOwnUploads(t *testing.T) {
+	ctx := context.Background()
+	chdir(t, t.TempDir())
+
+	if err := os.Mkdir("uploads", 0755); err != nil {
+		t.Fatalf("error while creating uploads: %v", err)
+	}
+
+	own := filepath.Join("uploads", "driver-1_20240101_000000_ktp")
+	victim := filepath.Join("uploads", "driver-2_20240101_000000_ktp")
+
+	for _, name := range []string{own, victim} {
+		if err := os.WriteFile(name, []byte("x"), 0644); err != nil {
+			t.Fatalf("error while writing %s: %v", name, err)
+		}
+	}
+
+	db := testdb.New(t)
+	s := newTestAuthService(t, db)
+
+	// Another account's upload path planted in the driver's own row.
+	createUser(t, db, models.User{
+		ID:           "driver-1",
+		Role:         "driver",
+		DriverDetail: models.DriverDetails{ID: "driver-1", KTP: own, SIM: victim},
+	}, "password123")
+
+	if err := db.Create(&models.AccountDeletion{UserID: "driver-1", PurgeAt: time.Now().Add(-time.Minute)}).Error; err != nil {
+		t.Fatalf("error while scheduling deletion: %v", err)
+	}
+
+	res, err := s.PurgeAccountsService(ctx)
+	if err != nil {
+		t.Fatalf("purge failed: %v", err.Err)
+	}
+	if res != 1 {
+		t.Fatalf("purged %d accounts, want 1", res)
+	}
+
+	if _, errStat := os.Stat(own); !errors.Is(errStat, os.ErrNotExist) {
+		t.Errorf("own upload was kept: %v", errStat)
+	}
+	if _, errStat := os.Stat(victim); errStat != nil {
+		t.Errorf("another account's upload was removed: %v", errStat)
+	}
+}
+
+var otpPattern = regexp.MustCompile(`\b\d{6}\b`)
+
+// lastOTP returns the code in the last SMS sent to phone.
+func lastOTP(t *testing.T, sender sms.SMSSender, phone string) string {
+	t.Helper()
+
+	msgs := sender.(*sms.MemorySender).Messages()
+	for i := len(msgs) - 1; i >= 0; i-- {
+		if msgs[i].To == phone {
+			return otpPattern.FindString(msgs[i].Body)
+		}
+	}
+
+	t.Fatalf("no SMS was sent to %s", phone)
+	return ""
+}
+
+func TestResetPasswordWithOTPService(t *testing.T) {
+	ctx := context.Background()
+	t.Setenv("RESET_OTP_MAX_ATTEMPTS", "3")
+
+	tests := []struct {
+		name string
+		// guess returns the codes to try before the last one, and the last.
+		guess   func(code string) (wrong []string, last string)
+		wantErr error
+	}{
+		{name: "right code", guess: func(code string) ([]string, string) { return nil, code }},
+		{name: "right code after a wrong one", guess: func(code string) ([]string, string) { return []string{wrongOTP(code)}, code }},
+		{name: "wrong code", guess: func(code string) ([]string, string) { return nil, wrongOTP(code) }, wantErr: helper.ErrInvalidOTP},
+		{
+			name: "right code after the attempts ran out",
+			guess: func(code string) ([]string, string) {
+				return []string{wrongOTP(code), wrongOTP(code), wrongOTP(code)}, code
+			},
+			wantErr: helper.ErrOTPExpired,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			db := testdb.New(t)
+			s := newTestAuthService(t, db)
+
+			createUser(t, db, models.User{
+				ID:           "driver-1",
+				PhoneNumber:  ptr("+6281234567890"),
+				Role:         "driver",
+				DriverDetail: models.DriverDetails{ID: "driver-1", Name: "Driver", PhoneNumber: "+6281234567890"},
+			}, "old-password")
+
+			if _, err := s.SendResetOTPService(ctx, dto.ForgotPasswordOTPReq{PhoneNumber: "0812-3456-7890"}); err != nil {
+				t.Fatalf("sending OTP failed: %v", err.Err)
+			}
+
+			wrong, last := tt.guess(lastOTP(t, s.SMSSender, "+6281234567890"))
+
+			reset := func(code string) *helper.ErrorStruct {
+				_, err := s.ResetPasswordWithOTPService(ctx, dto.ResetPasswordOTPReq{
+					PhoneNumber:          "081234567890",
+					OTP:                  code,
+					Password:             "new-password",
+					PasswordConfirmation: "new-password",
+				})
+				return err
+			}
+
+			for _, code := range wrong {
+				if err := reset(code); err == nil || !errors.Is(err.Err, helper.ErrInvalidOTP) {
+					t.Fatalf("expected %v for a wrong code, got %+v", helper.ErrInvalidOTP, err)
+				}
+			}
+
+			err := reset(last)
+
+			var user models.User
+			db.First(&user, "id = ?", "driver-1")
+			changed := bcrypt.CompareHashAndPassword([]byte(user.Password), []byte("new-password")) == nil
+
+			if tt.wantErr != nil {
+				if err == nil || !errors.Is(err.Err, tt.wantErr) {
+					t.Fatalf("expected %v, got %+v", tt.wantErr, err)
+				}
+				if changed {
+					t.Fatal("password changed without a valid code")
+				}
+				return
+			}
+
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err.Err)
+			}
+			if !changed {
+				t.Fatal("password was not changed")
+			}
+
+			// A code works only once.
+			if err := reset(last); err == nil || !errors.Is(err.Err, helper.ErrOTPExpired) {
+				t.Fatalf("expected %v for a used code, got %+v", helper.ErrOTPExpired, err)
+			}
+		})
+	}
+}
+
+// wrongOTP returns a six digit code that differs from code.
+func wrongOTP(code string) string {
+	if code == "000000" {
+		return "111111"
+	}
+
+	return "000000"
+}
+
+func TestLoginWithOTPService(t *testing.T) {
+	ctx := context.Background()
+	t.Setenv("LOGIN_OTP_MAX_ATTEMPTS", "2")
+
+	tests := []struct {
+		name    string
+		wrong   int
+		reuse   bool
+		wantErr error
+	}{
+		{name: "right code"},
+		{name: "right code after a wrong one", wrong: 1},
+		{name: "right code after the attempts ran out", wrong: 2, wantErr: helper.ErrOTPExpired},
+		{name: "used code", reuse: true, wantErr: helper.ErrOTPExpired},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			db := testdb.New(t)
+			s := newTestAuthService(t, db)
+			createUser(t, db, models.User{ID: "user-1", Role: "user", PhoneNumber: ptr("+6281234567890")}, "password123")
+
+			if _, err := s.SendLoginOTPService(ctx, dto.LoginOTPReq{PhoneNumber: "+62 812 3456 7890"}); err != nil {
+				t.Fatalf("sending OTP failed: %v", err.Err)
+			}
+
+			code := lastOTP(t, s.SMSSender, "+6281234567890")
+			login := func(code string) (dto.UserRegistrationsResp, *helper.ErrorStruct) {
+				return s.LoginWithOTPService(ctx, dto.LoginOTPVerifyReq{PhoneNumber: "081234567890", OTP: code})
+			}
+
+			for i := 0; i < tt.wrong; i++ {
+				if _, err := login(wrongOTP(code)); err == nil || !errors.Is(err.Err, helper.ErrInvalidOTP) {
+					t.Fatalf("expected %v for a wrong code, got %+v", helper.ErrInvalidOTP, err)
+				}
+			}
+
+			if tt.reuse {
+				if _, err := login(code); err != nil {
+					t.Fatalf("first login failed: %v", err.Err)
+				}
+			}
+
+			res, err := login(code)
+
+			if tt.wantErr != nil {
+				if err == nil || !errors.Is(err.Err, tt.wantErr) {
+					t.Fatalf("expected %v, got %+v", tt.wantErr, err)
+				}
+				return
+			}
+
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err.Err)
+			}
+			if res.ID != "user-1" || res.PhoneNumber != "+6281234567890" {
+				t.Errorf("logged in as %+v", res)
+			}
+		})
+	}
+}
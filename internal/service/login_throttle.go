@@ -0,0 +1,82 @@
+package service
+
+import (
+	"context"
+	"strings"
+	"time"
+
+	"github.com/GabrielMoody/mikronet-auth-service/internal/helper"
+	"github.com/GabrielMoody/mikronet-auth-service/internal/repository"
+)
+
+// Failed logins are counted per email and per client IP. Once a key reaches
+// its limit it is locked out, and every further failure doubles the lockout
+// up to LOGIN_LOCKOUT_MAX. Counters are forgotten after a quiet window.
+const loginAttemptWindow = time.Hour
+
+func emailAttemptKey(email string) string {
+	return "email:" + strings.ToLower(strings.TrimSpace(email))
+}
+
+func ipAttemptKey(ip string) string {
+	return "ip:" + ip
+}
+
+func lockoutDuration(failures, limit int) time.Duration {
+	if failures < limit {
+		return 0
+	}
+
+	base := helper.GetEnvDuration("LOGIN_LOCKOUT_BASE", time.Minute)
+	max := helper.GetEnvDuration("LOGIN_LOCKOUT_MAX", time.Hour)
+
+	d := base
+	for i := limit; i < failures && d < max; i++ {
+		d *= 2
+	}
+
+	if d > max {
+		d = max
+	}
+
+	return d
+}
+
+func checkLockout(c context.Context, store repository.LoginAttemptStore, keys ...string) error {
+	now := time.Now()
+	var retryAfter time.Duration
+
+	for _, key := range keys {
+		attempt, err := store.GetAttempt(c, key)
+		if err != nil {
+			return err
+		}
+
+		if attempt.LockedUntil != nil && attempt.LockedUntil.After(now) {
+			if d := attempt.LockedUntil.Sub(now); d > retryAfter {
+				retryAfter = d
+			}
+		}
+	}
+
+	if retryAfter > 0 {
+		return &helper.TooManyAttemptsError{RetryAfter: retryAfter}
+	}
+
+	return nil
+}
+
+// registerFailure decides on the count AddFailure returns, never on one read
+// earlier, so parallel guesses cannot all slip under the limit.
+func registerFailure(c context.Context, store repository.LoginAttemptStore, key string, limit int) error {
+	attempt, err := store.AddFailure(c, key, loginAttemptWindow)
+	if err != nil {
+		return err
+	}
+
+	if d := lockoutDuration(attempt.Failures, limit); d > 0 {
+		return store.LockAttempt(c, key, attempt.LastFailureAt.Add(d))
+	}
+
+	return nil
+}
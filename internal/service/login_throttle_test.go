@@ -0,0 +1,116 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/GabrielMoody/mikronet-auth-service/internal/dto"
+	"github.com/GabrielMoody/mikronet-auth-service/internal/helper"
+	"github.com/GabrielMoody/mikronet-auth-service/internal/models"
+	"github.com/GabrielMoody/mikronet-auth-service/internal/repository"
+	"github.com/GabrielMoody/mikronet-auth-service/internal/testdb"
+)
+
+func TestLockoutDuration(t *testing.T) {
+	t.Setenv("LOGIN_LOCKOUT_BASE", "1m")
+	t.Setenv("LOGIN_LOCKOUT_MAX", "10m")
+
+	tests := []struct {
+		failures int
+		want     time.Duration
+	}{
+		{4, 0},
+		{5, time.Minute},
+		{6, 2 * time.Minute},
+		{8, 8 * time.Minute},
+		{9, 10 * time.Minute},
+		{50, 10 * time.Minute},
+	}
+
+	for _, tt := range tests {
+		if got := lockoutDuration(tt.failures, 5); got != tt.want {
+			t.Errorf("lockoutDuration(%d, 5) = %v, want %v", tt.failures, got, tt.want)
+		}
+	}
+}
+
+func TestRegisterFailureIsAtomic(t *testing.T) {
+	ctx := context.Background()
+	const guesses = 25
+
+	stores := map[string]func(t *testing.T) repository.LoginAttemptStore{
+		"db": func(t *testing.T) repository.LoginAttemptStore {
+			db := testdb.New(t)
+			// One connection still interleaves the goroutines between
+			// statements, which is where a read-modify-write loses counts.
+			sqlDB, _ := db.DB()
+			sqlDB.SetMaxOpenConns(1)
+			return repository.NewLoginAttemptStore(db)
+		},
+		"memory": func(t *testing.T) repository.LoginAttemptStore {
+			return repository.NewMemoryLoginAttemptStore()
+		},
+	}
+
+	for name, newStore := range stores {
+		t.Run(name, func(t *testing.T) {
+			store := newStore(t)
+
+			var wg sync.WaitGroup
+			for i := 0; i < guesses; i++ {
+				wg.Add(1)
+				go func() {
+					defer wg.Done()
+					if err := registerFailure(ctx, store, "email:a@example.com", 5); err != nil {
+						t.Errorf("registerFailure: %v", err)
+					}
+				}()
+			}
+			wg.Wait()
+
+			attempt, err := store.GetAttempt(ctx, "email:a@example.com")
+			if err != nil {
+				t.Fatalf("GetAttempt: %v", err)
+			}
+
+			if attempt.Failures != guesses {
+				t.Errorf("failures = %d, want %d", attempt.Failures, guesses)
+			}
+
+			if err := checkLockout(ctx, store, "email:a@example.com"); err == nil {
+				t.Error("key is not locked out")
+			}
+		})
+	}
+}
+
+func TestLoginLockout(t *testing.T) {
+	ctx := context.Background()
+	t.Setenv("LOGIN_MAX_ATTEMPTS_EMAIL", "3")
+
+	db := testdb.New(t)
+	s := newTestAuthService(t, db)
+	createUser(t, db, models.User{ID: "user-1", Email: "user@example.com", Role: "user"}, "password123")
+
+	login := func(password, ip string) *helper.ErrorStruct {
+		_, err := s.LoginUserService(ctx, dto.UserLoginReq{Email: "user@example.com", Password: password}, ip)
+		return err
+	}
+
+	for i := 0; i < 3; i++ {
+		if err := login("wrong-password", "10.0.0.1"); err == nil || !errors.Is(err.Err, helper.ErrPasswordIncorrect) {
+			t.Fatalf("attempt %d: expected a wrong password error, got %+v", i+1, err)
+		}
+	}
+
+	// The account is locked for every client, even with the right password.
+	err := login("password123", "10.0.0.2")
+
+	var tooMany *helper.TooManyAttemptsError
+	if err == nil || err.Code != 429 || !errors.As(err.Err, &tooMany) || tooMany.Seconds() <= 0 {
+		t.Fatalf("expected a lockout, got %+v", err)
+	}
+}
@@ -0,0 +1,444 @@
+package service
+
+import (
+	"context"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/base64"
+	"errors"
+	"net/url"
+	"os"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/GabrielMoody/mikronet-auth-service/internal/dto"
+	"github.com/GabrielMoody/mikronet-auth-service/internal/helper"
+	"github.com/GabrielMoody/mikronet-auth-service/internal/models"
+	"github.com/GabrielMoody/mikronet-auth-service/internal/repository"
+	"github.com/gofiber/fiber/v2"
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/google/uuid"
+)
+
+var oidcScopes = []string{"openid", "profile", "email", "phone"}
+
+type OAuthService interface {
+	CreateClientService(c context.Context, data dto.OAuthClientReq, adminID string) (res dto.OAuthClientResp, err *helper.ErrorStruct)
+	GetClientsService(c context.Context) (res []dto.OAuthClientResp, err *helper.ErrorStruct)
+	DeleteClientService(c context.Context, id string, adminID string) (res string, err *helper.ErrorStruct)
+	ValidateAuthorizeService(c context.Context, data dto.AuthorizeReq) (redirectURI string, err *helper.ErrorStruct)
+	AuthorizeService(c context.Context, userID string, data dto.AuthorizeReq) (res string, err *helper.ErrorStruct)
+	ExchangeTokenService(c context.Context, data dto.OAuthTokenReq, device dto.DeviceInfo) (res dto.OAuthTokenResp, err *helper.ErrorStruct)
+	UserInfoService(c context.Context, id string) (res dto.UserInfoResp, err *helper.ErrorStruct)
+	DiscoveryService() dto.OIDCDiscoveryResp
+}
+
+type OAuthServiceImpl struct {
+	OAuthRepo             repository.OAuthRepo
+	AuthRepo              repository.AuthRepo
+	TokenService          TokenService
+	ServiceAccountService ServiceAccountService
+	AuditService          AuditService
+}
+
+// oidcIssuer must match the iss of ID tokens and the issuer in discovery.
+func oidcIssuer() string {
+	if iss := os.Getenv("JWT_ISS"); iss != "" {
+		return iss
+	}
+
+	return publicBaseURL()
+}
+
+func toClientResp(client models.OAuthClient) dto.OAuthClientResp {
+	return dto.OAuthClientResp{
+		ClientID:     client.ID,
+		Name:         client.Name,
+		RedirectURIs: strings.Fields(client.RedirectURIs),
+		Scopes:       strings.Fields(client.Scopes),
+		Public:       client.SecretHash == "",
+		CreatedAt:    client.CreatedAt,
+	}
+}
+
+func (a *OAuthServiceImpl) CreateClientService(c context.Context, data dto.OAuthClientReq, adminID string) (res dto.OAuthClientResp, err *helper.ErrorStruct) {
+	if errValidate := helper.Validate.Struct(data); errValidate != nil {
+		return res, &helper.ErrorStruct{
+			Code:             fiber.StatusBadRequest,
+			ValidationErrors: helper.ValidationError(errValidate),
+		}
+	}
+
+	scopes := data.Scopes
+	if len(scopes) == 0 {
+		scopes = oidcScopes
+	}
+
+	client := models.OAuthClient{
+		ID:           uuid.NewString(),
+		Name:         data.Name,
+		RedirectURIs: strings.Join(data.RedirectURIs, " "),
+		Scopes:       strings.Join(scopes, " "),
+		CreatedBy:    adminID,
+	}
+
+	var secret string
+	if !data.Public {
+		var errSecret error
+		secret, errSecret = helper.GenerateToken(32)
+
+		if errSecret != nil {
+			return res, &helper.ErrorStruct{
+				Err:  errSecret,
+				Code: fiber.StatusInternalServerError,
+			}
+		}
+
+		client.SecretHash = helper.HashToken(secret)
+	}
+
+	resRepo, errRepo := a.OAuthRepo.CreateClient(c, client)
+
+	recordResult(c, a.AuditService, models.AuthEvent{
+		Type:     models.EventOAuthClientCreate,
+		ActorID:  adminID,
+		TargetID: client.ID,
+	}, errRepo)
+
+	if errRepo != nil {
+		return res, helper.CheckError(errRepo)
+	}
+
+	// The secret is only ever shown here.
+	res = toClientResp(resRepo)
+	res.ClientSecret = secret
+
+	return res, nil
+}
+
+func (a *OAuthServiceImpl) GetClientsService(c context.Context) (res []dto.OAuthClientResp, err *helper.ErrorStruct) {
+	clients, errRepo := a.OAuthRepo.GetClients(c)
+
+	if errRepo != nil {
+		return res, helper.CheckError(errRepo)
+	}
+
+	res = make([]dto.OAuthClientResp, 0, len(clients))
+	for _, client := range clients {
+		res = append(res, toClientResp(client))
+	}
+
+	return res, nil
+}
+
+func (a *OAuthServiceImpl) DeleteClientService(c context.Context, id string, adminID string) (res string, err *helper.ErrorStruct) {
+	errRepo := a.OAuthRepo.DeleteClient(c, id)
+
+	recordResult(c, a.AuditService, models.AuthEvent{
+		Type:     models.EventOAuthClientDelete,
+		ActorID:  adminID,
+		TargetID: id,
+	}, errRepo)
+
+	if errRepo != nil {
+		return res, helper.CheckError(errRepo)
+	}
+
+	return "client berhasil dihapus!", nil
+}
+
+// ValidateAuthorizeService checks an authorization request. The returned
+// redirect URI is only set once client_id and redirect_uri are known to be
+// valid, because errors must never be redirected to an unverified URI.
+func (a *OAuthServiceImpl) ValidateAuthorizeService(c context.Context, data dto.AuthorizeReq) (redirectURI string, err *helper.ErrorStruct) {
+	client, errRepo := a.OAuthRepo.GetClient(c, data.ClientID)
+
+	if errRepo != nil {
+		if errors.Is(errRepo, helper.ErrNotFound) {
+			return "", helper.CheckError(helper.ErrInvalidClient)
+		}
+		return "", helper.CheckError(errRepo)
+	}
+
+	if !slices.Contains(strings.Fields(client.RedirectURIs), data.RedirectURI) {
+		return "", helper.CheckError(helper.ErrInvalidRequest)
+	}
+
+	if data.ResponseType != "code" || data.CodeChallenge == "" || data.CodeChallengeMethod != "S256" {
+		return data.RedirectURI, helper.CheckError(helper.ErrInvalidRequest)
+	}
+
+	allowed := strings.Fields(client.Scopes)
+	for _, scope := range strings.Fields(data.Scope) {
+		if !slices.Contains(allowed, scope) {
+			return data.RedirectURI, helper.CheckError(helper.ErrInvalidScope)
+		}
+	}
+
+	return data.RedirectURI, nil
+}
+
+// AuthorizeService is called once the user has signed in on the authorize
+// page. It returns the URL to send the browser back to with the code.
+func (a *OAuthServiceImpl) AuthorizeService(c context.Context, userID string, data dto.AuthorizeReq) (res string, err *helper.ErrorStruct) {
+	if _, errValidate := a.ValidateAuthorizeService(c, data); errValidate != nil {
+		return res, errValidate
+	}
+
+	code, errCode := helper.GenerateToken(32)
+
+	if errCode != nil {
+		return res, &helper.ErrorStruct{
+			Err:  errCode,
+			Code: fiber.StatusInternalServerError,
+		}
+	}
+
+	if errRepo := a.OAuthRepo.CreateAuthorizationCode(c, models.AuthorizationCode{
+		ID:            helper.HashToken(code),
+		ClientID:      data.ClientID,
+		UserID:        userID,
+		RedirectURI:   data.RedirectURI,
+		Scope:         data.Scope,
+		Nonce:         data.Nonce,
+		CodeChallenge: data.CodeChallenge,
+		ExpiresAt:     time.Now().Add(helper.GetEnvDuration("OAUTH_CODE_TTL", time.Minute)),
+	}); errRepo != nil {
+		return res, helper.CheckError(errRepo)
+	}
+
+	q := url.Values{}
+	q.Set("code", code)
+	if data.State != "" {
+		q.Set("state", data.State)
+	}
+
+	return appendQuery(data.RedirectURI, q), nil
+}
+
+func appendQuery(uri string, q url.Values) string {
+	if strings.Contains(uri, "?") {
+		return uri + "&" + q.Encode()
+	}
+
+	return uri + "?" + q.Encode()
+}
+
+// authenticateClient accepts the secret of a confidential client; public
+// clients have none and are bound by PKCE instead.
+func (a *OAuthServiceImpl) authenticateClient(c context.Context, id, secret string) (models.OAuthClient, error) {
+	client, errRepo := a.OAuthRepo.GetClient(c, id)
+
+	if errRepo != nil {
+		if errors.Is(errRepo, helper.ErrNotFound) {
+			return client, helper.ErrInvalidClient
+		}
+		return client, errRepo
+	}
+
+	if client.SecretHash != "" && subtle.ConstantTimeCompare([]byte(client.SecretHash), []byte(helper.HashToken(secret))) != 1 {
+		return client, helper.ErrInvalidClient
+	}
+
+	return client, nil
+}
+
+func verifyPKCE(verifier, challenge string) bool {
+	sum := sha256.Sum256([]byte(verifier))
+	return subtle.ConstantTimeCompare([]byte(base64.RawURLEncoding.EncodeToString(sum[:])), []byte(challenge)) == 1
+}
+
+func (a *OAuthServiceImpl) ExchangeTokenService(c context.Context, data dto.OAuthTokenReq, device dto.DeviceInfo) (res dto.OAuthTokenResp, err *helper.ErrorStruct) {
+	// Service accounts are not OAuth clients and authenticate on their own.
+	if data.GrantType == "client_credentials" {
+		return a.ServiceAccountService.ClientCredentialsService(c, data)
+	}
+
+	client, errClient := a.authenticateClient(c, data.ClientID, data.ClientSecret)
+
+	if errClient != nil {
+		return res, helper.CheckError(errClient)
+	}
+
+	switch data.GrantType {
+	case "authorization_code":
+		return a.exchangeCode(c, client, data, device)
+	case "refresh_token":
+		device.ClientID = client.ID
+
+		tokens, errToken := a.TokenService.RefreshTokenService(c, dto.RefreshTokenReq{RefreshToken: data.RefreshToken}, device)
+
+		if errToken != nil {
+			if errToken.ValidationErrors != nil || errToken.Code == fiber.StatusUnauthorized {
+				return res, helper.CheckError(helper.ErrInvalidGrant)
+			}
+			return res, errToken
+		}
+
+		return dto.OAuthTokenResp{
+			AccessToken:  tokens.AccessToken,
+			TokenType:    "Bearer",
+			ExpiresIn:    int(helper.AccessTokenTTL.Seconds()),
+			RefreshToken: tokens.RefreshToken,
+		}, nil
+	default:
+		return res, helper.CheckError(helper.ErrUnsupportedGrantType)
+	}
+}
+
+func (a *OAuthServiceImpl) exchangeCode(c context.Context, client models.OAuthClient, data dto.OAuthTokenReq, device dto.DeviceInfo) (res dto.OAuthTokenResp, err *helper.ErrorStruct) {
+	if data.Code == "" || data.CodeVerifier == "" {
+		return res, helper.CheckError(helper.ErrInvalidRequest)
+	}
+
+	code, errRepo := a.OAuthRepo.ConsumeAuthorizationCode(c, helper.HashToken(data.Code))
+
+	if errRepo != nil {
+		return res, helper.CheckError(errRepo)
+	}
+
+	if code.ClientID != client.ID || code.RedirectURI != data.RedirectURI || !verifyPKCE(data.CodeVerifier, code.CodeChallenge) {
+		return res, helper.CheckError(helper.ErrInvalidGrant)
+	}
+
+	user, errRepo := a.AuthRepo.GetUserByID(c, code.UserID)
+
+	if errRepo != nil {
+		return res, helper.CheckError(errRepo)
+	}
+
+	blocked, errRepo := a.AuthRepo.IsBlocked(c, user.ID)
+
+	if errRepo != nil {
+		return res, helper.CheckError(errRepo)
+	}
+
+	if blocked {
+		return res, helper.CheckError(helper.ErrInvalidGrant)
+	}
+
+	// The session belongs to the client app, so name it after the client and
+	// only let that client refresh it. Its tokens carry the granted scope.
+	if device.DeviceName == "" {
+		device.DeviceName = client.Name
+	}
+	device.ClientID = client.ID
+	device.Scope = code.Scope
+
+	tokens, errToken := a.TokenService.IssueTokenService(c, dto.UserRegistrationsResp{
+		ID:    user.ID,
+		Email: user.Email,
+		Role:  user.Role,
+	}, device)
+
+	if errToken != nil {
+		return res, errToken
+	}
+
+	res = dto.OAuthTokenResp{
+		AccessToken:  tokens.AccessToken,
+		TokenType:    "Bearer",
+		ExpiresIn:    int(helper.AccessTokenTTL.Seconds()),
+		RefreshToken: tokens.RefreshToken,
+		Scope:        code.Scope,
+	}
+
+	if !slices.Contains(strings.Fields(code.Scope), "openid") {
+		return res, nil
+	}
+
+	verified, errRepo := a.AuthRepo.IsEmailVerified(c, user.ID)
+
+	if errRepo != nil {
+		return res, helper.CheckError(errRepo)
+	}
+
+	now := time.Now()
+
+	claims := jwt.MapClaims{
+		"iss":            oidcIssuer(),
+		"sub":            user.ID,
+		"aud":            client.ID,
+		"iat":            now.Unix(),
+		"exp":            now.Add(helper.IDTokenTTL).Unix(),
+		"auth_time":      code.CreatedAt.Unix(),
+		"email":          user.Email,
+		"email_verified": verified,
+		"role":           user.Role,
+	}
+
+	if code.Nonce != "" {
+		claims["nonce"] = code.Nonce
+	}
+
+	idToken, errSign := helper.SignJWT(claims)
+
+	if errSign != nil {
+		return res, &helper.ErrorStruct{
+			Err:  errSign,
+			Code: fiber.StatusInternalServerError,
+		}
+	}
+
+	res.IDToken = idToken
+
+	return res, nil
+}
+
+func (a *OAuthServiceImpl) UserInfoService(c context.Context, id string) (res dto.UserInfoResp, err *helper.ErrorStruct) {
+	user, errRepo := a.AuthRepo.GetUserByID(c, id)
+
+	if errRepo != nil {
+		return res, helper.CheckError(errRepo)
+	}
+
+	verified, errRepo := a.AuthRepo.IsEmailVerified(c, id)
+
+	if errRepo != nil {
+		return res, helper.CheckError(errRepo)
+	}
+
+	res = dto.UserInfoResp{
+		Sub:           user.ID,
+		Email:         user.Email,
+		EmailVerified: verified,
+		Role:          user.Role,
+	}
+
+	if user.PhoneNumber != nil {
+		res.PhoneNumber = *user.PhoneNumber
+	}
+
+	return res, nil
+}
+
+func (a *OAuthServiceImpl) DiscoveryService() dto.OIDCDiscoveryResp {
+	base := publicBaseURL()
+
+	return dto.OIDCDiscoveryResp{
+		Issuer:                            oidcIssuer(),
+		AuthorizationEndpoint:             base + "/oauth/authorize",
+		TokenEndpoint:                     base + "/oauth/token",
+		UserinfoEndpoint:                  base + "/oauth/userinfo",
+		JWKSURI:                           base + "/.well-known/jwks.json",
+		ResponseTypesSupported:            []string{"code"},
+		GrantTypesSupported:               []string{"authorization_code", "refresh_token", "client_credentials"},
+		SubjectTypesSupported:             []string{"public"},
+		IDTokenSigningAlgValuesSupported:  []string{helper.SigningAlg()},
+		ScopesSupported:                   oidcScopes,
+		TokenEndpointAuthMethodsSupported: []string{"client_secret_basic", "client_secret_post", "none"},
+		CodeChallengeMethodsSupported:     []string{"S256"},
+		ClaimsSupported:                   []string{"sub", "iss", "aud", "exp", "iat", "auth_time", "nonce", "email", "email_verified", "phone_number", "role"},
+	}
+}
+
+func NewOAuthService(oauthRepo repository.OAuthRepo, authRepo repository.AuthRepo, tokenService TokenService, serviceAccountService ServiceAccountService, auditService AuditService) OAuthService {
+	return &OAuthServiceImpl{
+		OAuthRepo:             oauthRepo,
+		AuthRepo:              authRepo,
+		TokenService:          tokenService,
+		ServiceAccountService: serviceAccountService,
+		AuditService:          auditService,
+	}
+}
@@ -0,0 +1,323 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"net/url"
+	"testing"
+
+	"github.com/GabrielMoody/mikronet-auth-service/internal/dto"
+	"github.com/GabrielMoody/mikronet-auth-service/internal/helper"
+	"github.com/GabrielMoody/mikronet-auth-service/internal/models"
+	"github.com/GabrielMoody/mikronet-auth-service/internal/repository"
+	"github.com/GabrielMoody/mikronet-auth-service/internal/testdb"
+)
+
+// The example verifier and challenge from RFC 7636 appendix B.
+const (
+	pkceVerifier  = "dBjftJeZ4CVP-mB92K27uhbUJU1p1r_wW1gFWFOEjXk"
+	pkceChallenge = "E9Melhoa2OwvFrEMTJguCHaoeK1t8URWbuGJSstw-cM"
+)
+
+func TestVerifyPKCE(t *testing.T) {
+	tests := []struct {
+		name      string
+		verifier  string
+		challenge string
+		want      bool
+	}{
+		{"rfc 7636 example", pkceVerifier, pkceChallenge, true},
+		{"wrong verifier", pkceVerifier + "x", pkceChallenge, false},
+		{"plain method", pkceVerifier, pkceVerifier, false},
+		{"padded challenge", pkceVerifier, pkceChallenge + "=", false},
+		{"empty verifier", "", pkceChallenge, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := verifyPKCE(tt.verifier, tt.challenge); got != tt.want {
+				t.Errorf("verifyPKCE = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func newTestOAuthService(t *testing.T) (*OAuthServiceImpl, dto.UserRegistrationsResp) {
+	t.Helper()
+
+	db := testdb.New(t)
+	s := &OAuthServiceImpl{
+		OAuthRepo:    repository.NewOAuthRepo(db),
+		AuthRepo:     repository.NewAuthRepo(db),
+		TokenService: newTestTokenService(t, db),
+		AuditService: NewAuditService(repository.NewAuditRepo(db)),
+	}
+
+	return s, newTestUser(t, db, "user-1", "user")
+}
+
+func createTestClient(t *testing.T, s *OAuthServiceImpl, name string, public bool) dto.OAuthClientResp {
+	t.Helper()
+
+	client, err := s.CreateClientService(context.Background(), dto.OAuthClientReq{
+		Name:         name,
+		RedirectURIs: []string{"https://" + name + ".example.com/callback"},
+		Public:       public,
+	}, "admin-1")
+	if err != nil {
+		t.Fatalf("error while creating client %s: %+v", name, err)
+	}
+
+	return client
+}
+
+// authorize runs the authorize step for user and returns the code.
+func authorize(t *testing.T, s *OAuthServiceImpl, client dto.OAuthClientResp, userID string) string {
+	t.Helper()
+
+	redirect, err := s.AuthorizeService(context.Background(), userID, dto.AuthorizeReq{
+		ResponseType:        "code",
+		ClientID:            client.ClientID,
+		RedirectURI:         client.RedirectURIs[0],
+		Scope:               "openid",
+		CodeChallenge:       pkceChallenge,
+		CodeChallengeMethod: "S256",
+	})
+	if err != nil {
+		t.Fatalf("authorize failed: %+v", err)
+	}
+
+	u, errParse := url.Parse(redirect)
+	if errParse != nil {
+		t.Fatalf("invalid redirect %q: %v", redirect, errParse)
+	}
+
+	return u.Query().Get("code")
+}
+
+func TestExchangeCode(t *testing.T) {
+	ctx := context.Background()
+
+	tests := []struct {
+		name    string
+		req     func(app, other dto.OAuthClientResp, code string) dto.OAuthTokenReq
+		wantErr error
+	}{
+		{
+			name: "confidential client with verifier",
+			req: func(app, other dto.OAuthClientResp, code string) dto.OAuthTokenReq {
+				return dto.OAuthTokenReq{GrantType: "authorization_code", Code: code, CodeVerifier: pkceVerifier, RedirectURI: app.RedirectURIs[0], ClientID: app.ClientID, ClientSecret: app.ClientSecret}
+			},
+		},
+		{
+			name: "wrong verifier",
+			req: func(app, other dto.OAuthClientResp, code string) dto.OAuthTokenReq {
+				return dto.OAuthTokenReq{GrantType: "authorization_code", Code: code, CodeVerifier: "wrong", RedirectURI: app.RedirectURIs[0], ClientID: app.ClientID, ClientSecret: app.ClientSecret}
+			},
+			wantErr: helper.ErrInvalidGrant,
+		},
+		{
+			name: "wrong client secret",
+			req: func(app, other dto.OAuthClientResp, code string) dto.OAuthTokenReq {
+				return dto.OAuthTokenReq{GrantType: "authorization_code", Code: code, CodeVerifier: pkceVerifier, RedirectURI: app.RedirectURIs[0], ClientID: app.ClientID, ClientSecret: "wrong"}
+			},
+			wantErr: helper.ErrInvalidClient,
+		},
+		{
+			name: "code of another client",
+			req: func(app, other dto.OAuthClientResp, code string) dto.OAuthTokenReq {
+				return dto.OAuthTokenReq{GrantType: "authorization_code", Code: code, CodeVerifier: pkceVerifier, RedirectURI: app.RedirectURIs[0], ClientID: other.ClientID}
+			},
+			wantErr: helper.ErrInvalidGrant,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s, user := newTestOAuthService(t)
+			app := createTestClient(t, s, "app", false)
+			other := createTestClient(t, s, "other", true)
+
+			res, err := s.ExchangeTokenService(ctx, tt.req(app, other, authorize(t, s, app, user.ID)), dto.DeviceInfo{})
+
+			if tt.wantErr != nil {
+				if err == nil || !errors.Is(err.Err, tt.wantErr) {
+					t.Fatalf("expected %v, got %+v", tt.wantErr, err)
+				}
+				return
+			}
+
+			if err != nil {
+				t.Fatalf("unexpected error: %+v", err)
+			}
+			if res.AccessToken == "" || res.RefreshToken == "" || res.IDToken == "" {
+				t.Fatalf("expected access, refresh and ID tokens, got %+v", res)
+			}
+		})
+	}
+}
+
+func TestOAuthRefreshIsBoundToClient(t *testing.T) {
+	ctx := context.Background()
+
+	tests := []struct {
+		name string
+		// refresh redeems token as some client, or through the first-party
+		// endpoint.
+		refresh func(s *OAuthServiceImpl, app, other dto.OAuthClientResp, token string) *helper.ErrorStruct
+		wantErr error
+	}{
+		{
+			name: "same client",
+			refresh: func(s *OAuthServiceImpl, app, other dto.OAuthClientResp, token string) *helper.ErrorStruct {
+				_, err := s.ExchangeTokenService(ctx, dto.OAuthTokenReq{GrantType: "refresh_token", RefreshToken: token, ClientID: app.ClientID, ClientSecret: app.ClientSecret}, dto.DeviceInfo{})
+				return err
+			},
+		},
+		{
+			name: "another public client",
+			refresh: func(s *OAuthServiceImpl, app, other dto.OAuthClientResp, token string) *helper.ErrorStruct {
+				_, err := s.ExchangeTokenService(ctx, dto.OAuthTokenReq{GrantType: "refresh_token", RefreshToken: token, ClientID: other.ClientID}, dto.DeviceInfo{})
+				return err
+			},
+			wantErr: helper.ErrInvalidGrant,
+		},
+		{
+			name: "first-party endpoint",
+			refresh: func(s *OAuthServiceImpl, app, other dto.OAuthClientResp, token string) *helper.ErrorStruct {
+				_, err := s.TokenService.RefreshTokenService(ctx, dto.RefreshTokenReq{RefreshToken: token}, dto.DeviceInfo{})
+				return err
+			},
+			wantErr: helper.ErrInvalidToken,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s, user := newTestOAuthService(t)
+			app := createTestClient(t, s, "app", false)
+			other := createTestClient(t, s, "other", true)
+
+			tokens, err := s.ExchangeTokenService(ctx, dto.OAuthTokenReq{
+				GrantType:    "authorization_code",
+				Code:         authorize(t, s, app, user.ID),
+				CodeVerifier: pkceVerifier,
+				RedirectURI:  app.RedirectURIs[0],
+				ClientID:     app.ClientID,
+				ClientSecret: app.ClientSecret,
+			}, dto.DeviceInfo{})
+			if err != nil {
+				t.Fatalf("exchange failed: %+v", err)
+			}
+
+			err = tt.refresh(s, app, other, tokens.RefreshToken)
+
+			if tt.wantErr == nil {
+				if err != nil {
+					t.Fatalf("unexpected error: %+v", err)
+				}
+				return
+			}
+
+			if err == nil || !errors.Is(err.Err, tt.wantErr) {
+				t.Fatalf("expected %v, got %+v", tt.wantErr, err)
+			}
+
+			// A refused attempt must not rotate the token away from its client.
+			if _, err := s.ExchangeTokenService(ctx, dto.OAuthTokenReq{GrantType: "refresh_token", RefreshToken: tokens.RefreshToken, ClientID: app.ClientID, ClientSecret: app.ClientSecret}, dto.DeviceInfo{}); err != nil {
+				t.Fatalf("owning client can no longer refresh: %+v", err)
+			}
+		})
+	}
+}
+
+func TestFirstPartyRefreshIsNotOAuth(t *testing.T) {
+	ctx := context.Background()
+	s, user := newTestOAuthService(t)
+	app := createTestClient(t, s, "app", true)
+
+	tokens, err := s.TokenService.IssueTokenService(ctx, user, dto.DeviceInfo{})
+	if err != nil {
+		t.Fatalf("issue failed: %+v", err)
+	}
+
+	_, err = s.ExchangeTokenService(ctx, dto.OAuthTokenReq{GrantType: "refresh_token", RefreshToken: tokens.RefreshToken, ClientID: app.ClientID}, dto.DeviceInfo{})
+	if err == nil || !errors.Is(err.Err, helper.ErrInvalidGrant) {
+		t.Fatalf("expected %v, got %+v", helper.ErrInvalidGrant, err)
+	}
+}
+
+func TestOAuthAccessTokensNameTheClient(t *testing.T) {
+	ctx := context.Background()
+	s, user := newTestOAuthService(t)
+	app := createTestClient(t, s, "app", false)
+
+	tokens, err := s.ExchangeTokenService(ctx, dto.OAuthTokenReq{
+		GrantType:    "authorization_code",
+		Code:         authorize(t, s, app, user.ID),
+		CodeVerifier: pkceVerifier,
+		RedirectURI:  app.RedirectURIs[0],
+		ClientID:     app.ClientID,
+		ClientSecret: app.ClientSecret,
+	}, dto.DeviceInfo{})
+	if err != nil {
+		t.Fatalf("exchange failed: %+v", err)
+	}
+
+	refreshed, err := s.ExchangeTokenService(ctx, dto.OAuthTokenReq{GrantType: "refresh_token", RefreshToken: tokens.RefreshToken, ClientID: app.ClientID, ClientSecret: app.ClientSecret}, dto.DeviceInfo{})
+	if err != nil {
+		t.Fatalf("refresh failed: %+v", err)
+	}
+
+	for name, token := range map[string]string{"exchanged": tokens.AccessToken, "refreshed": refreshed.AccessToken} {
+		claims := accessClaims(t, token)
+
+		if !claims.IssuedToClient() || claims.ClientID != app.ClientID || len(claims.Audience) != 1 || claims.Audience[0] != app.ClientID || len(claims.Scopes) != 1 || claims.Scopes[0] != "openid" {
+			t.Errorf("%s access token = %+v, want audience and client %s with scope openid", name, claims, app.ClientID)
+		}
+	}
+
+	firstParty, err := s.TokenService.IssueTokenService(ctx, user, dto.DeviceInfo{})
+	if err != nil {
+		t.Fatalf("issue failed: %+v", err)
+	}
+
+	if claims := accessClaims(t, firstParty.AccessToken); claims.IssuedToClient() {
+		t.Errorf("first-party access token = %+v, want no client", claims)
+	}
+}
+
+func TestClientManagementIsAudited(t *testing.T) {
+	ctx := context.Background()
+	s, _ := newTestOAuthService(t)
+	app := createTestClient(t, s, "app", false)
+
+	if _, err := s.DeleteClientService(ctx, app.ClientID, "admin-1"); err != nil {
+		t.Fatalf("delete failed: %+v", err)
+	}
+
+	if _, err := s.DeleteClientService(ctx, app.ClientID, "admin-1"); err == nil {
+		t.Fatalf("deleting the client twice succeeded")
+	}
+
+	events, _, err := s.AuditService.GetAuthEventsService(ctx, dto.AuthEventQuery{ActorID: "admin-1", TargetID: app.ClientID})
+	if err != nil {
+		t.Fatalf("error while reading events: %+v", err)
+	}
+
+	want := []struct{ typ, outcome string }{
+		{models.EventOAuthClientDelete, models.OutcomeFailure},
+		{models.EventOAuthClientDelete, models.OutcomeSuccess},
+		{models.EventOAuthClientCreate, models.OutcomeSuccess},
+	}
+
+	if len(events) != len(want) {
+		t.Fatalf("audit events = %+v, want %d", events, len(want))
+	}
+
+	for i, w := range want {
+		if events[i].Type != w.typ || events[i].Outcome != w.outcome {
+			t.Errorf("event %d = %s/%s, want %s/%s", i, events[i].Type, events[i].Outcome, w.typ, w.outcome)
+		}
+	}
+}
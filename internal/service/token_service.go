@@ -0,0 +1,368 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/GabrielMoody/mikronet-auth-service/internal/dto"
+	"github.com/GabrielMoody/mikronet-auth-service/internal/helper"
+	"github.com/GabrielMoody/mikronet-auth-service/internal/models"
+	"github.com/GabrielMoody/mikronet-auth-service/internal/repository"
+	"github.com/gofiber/fiber/v2"
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/google/uuid"
+)
+
+type TokenService interface {
+	IssueTokenService(c context.Context, user dto.UserRegistrationsResp, device dto.DeviceInfo) (res dto.TokenResp, err *helper.ErrorStruct)
+	RefreshTokenService(c context.Context, data dto.RefreshTokenReq, device dto.DeviceInfo) (res dto.TokenResp, err *helper.ErrorStruct)
+	LogoutService(c context.Context, claims helper.Claims, data dto.LogoutReq) (res string, err *helper.ErrorStruct)
+	LogoutAllService(c context.Context, id string) (res string, err *helper.ErrorStruct)
+	GetSessionsService(c context.Context, userID, currentSessionID string) (res []dto.SessionResp, err *helper.ErrorStruct)
+	RevokeSessionService(c context.Context, userID, sessionID string) (res string, err *helper.ErrorStruct)
+	PruneRevocationsService(c context.Context) (res int64, err *helper.ErrorStruct)
+}
+
+type TokenServiceImpl struct {
+	TokenRepo       repository.TokenRepo
+	RevocationStore repository.RevocationStore
+}
+
+// issueTokens signs a token pair for session. Access tokens of a session
+// issued to an OAuth client name the client as their audience, which keeps
+// them out of the first-party API.
+func (a *TokenServiceImpl) issueTokens(c context.Context, user dto.UserRegistrationsResp, session models.Session) (res dto.TokenResp, err *helper.ErrorStruct) {
+	now := time.Now()
+
+	claims := jwt.MapClaims{
+		"id":    user.ID,
+		"email": user.Email,
+		"role":  user.Role,
+		"sid":   session.ID,
+		"jti":   uuid.NewString(),
+		"typ":   helper.TokenTypeAccess,
+		"iat":   now.Unix(),
+		"exp":   now.Add(helper.AccessTokenTTL).Unix(),
+		"iss":   os.Getenv("JWT_ISS"),
+	}
+
+	if session.ClientID != "" {
+		claims["aud"] = session.ClientID
+		claims["client_id"] = session.ClientID
+		claims["scope"] = session.Scope
+	}
+
+	t, errToken := helper.SignJWT(claims)
+
+	if errToken != nil {
+		return res, &helper.ErrorStruct{
+			Err:  errToken,
+			Code: fiber.StatusInternalServerError,
+		}
+	}
+
+	rt := models.RefreshToken{
+		ID:        uuid.NewString(),
+		FamilyID:  session.ID,
+		UserID:    user.ID,
+		ExpiresAt: now.Add(helper.RefreshTokenTTL),
+	}
+
+	if _, errRepo := a.TokenRepo.CreateRefreshToken(c, rt); errRepo != nil {
+		return res, helper.CheckError(errRepo)
+	}
+
+	refreshClaims := jwt.MapClaims{
+		"id":  user.ID,
+		"jti": rt.ID,
+		"typ": helper.TokenTypeRefresh,
+		"iat": now.Unix(),
+		"exp": rt.ExpiresAt.Unix(),
+		"iss": os.Getenv("JWT_ISS"),
+	}
+
+	r, errRefreshToken := helper.SignJWT(refreshClaims)
+
+	if errRefreshToken != nil {
+		return res, &helper.ErrorStruct{
+			Err:  errRefreshToken,
+			Code: fiber.StatusInternalServerError,
+		}
+	}
+
+	return dto.TokenResp{
+		AccessToken:  t,
+		RefreshToken: r,
+	}, nil
+}
+
+// describeDevice is the fallback session name for clients that do not send
+// X-Device-Name.
+func describeDevice(userAgent string) string {
+	ua := strings.ToLower(userAgent)
+
+	switch {
+	case strings.Contains(ua, "android"):
+		return "Android"
+	case strings.Contains(ua, "iphone"):
+		return "iPhone"
+	case strings.Contains(ua, "ipad"):
+		return "iPad"
+	case strings.Contains(ua, "windows"):
+		return "Windows"
+	case strings.Contains(ua, "mac os"):
+		return "macOS"
+	case strings.Contains(ua, "linux"):
+		return "Linux"
+	default:
+		return "Unknown device"
+	}
+}
+
+// IssueTokenService starts a new session; every token refreshed from it
+// stays in the same session.
+func (a *TokenServiceImpl) IssueTokenService(c context.Context, user dto.UserRegistrationsResp, device dto.DeviceInfo) (res dto.TokenResp, err *helper.ErrorStruct) {
+	name := device.DeviceName
+	if name == "" {
+		name = describeDevice(device.UserAgent)
+	}
+
+	session, errRepo := a.TokenRepo.CreateSession(c, models.Session{
+		ID:         uuid.NewString(),
+		UserID:     user.ID,
+		DeviceName: name,
+		UserAgent:  device.UserAgent,
+		IP:         device.IP,
+		ClientID:   device.ClientID,
+		Scope:      device.Scope,
+		LastSeenAt: time.Now(),
+	})
+
+	if errRepo != nil {
+		return res, helper.CheckError(errRepo)
+	}
+
+	return a.issueTokens(c, user, session)
+}
+
+// tokenSession returns the session of a refresh token, refusing one issued
+// to a different OAuth client, including OAuth tokens sent to the
+// first-party refresh endpoint.
+func (a *TokenServiceImpl) tokenSession(c context.Context, jti, clientID string) (models.Session, error) {
+	rt, errRepo := a.TokenRepo.GetRefreshToken(c, jti)
+
+	if errRepo != nil {
+		return models.Session{}, errRepo
+	}
+
+	session, errRepo := a.TokenRepo.GetSession(c, rt.FamilyID)
+
+	// Tokens issued before sessions were tracked have none and are first-party.
+	if errors.Is(errRepo, helper.ErrNotFound) {
+		session, errRepo = models.Session{ID: rt.FamilyID}, nil
+	}
+
+	if errRepo != nil {
+		return session, errRepo
+	}
+
+	if session.ClientID != clientID {
+		return session, helper.ErrInvalidToken
+	}
+
+	return session, nil
+}
+
+func (a *TokenServiceImpl) RefreshTokenService(c context.Context, data dto.RefreshTokenReq, device dto.DeviceInfo) (res dto.TokenResp, err *helper.ErrorStruct) {
+	if errValidate := helper.Validate.Struct(data); errValidate != nil {
+		return res, &helper.ErrorStruct{
+			Code:             fiber.StatusBadRequest,
+			ValidationErrors: helper.ValidationError(errValidate),
+		}
+	}
+
+	payload, errParse := helper.ParseJWT(data.RefreshToken)
+
+	if errParse != nil || payload["typ"] != helper.TokenTypeRefresh {
+		return res, helper.CheckError(helper.ErrInvalidToken)
+	}
+
+	claims := helper.NewClaims(payload)
+
+	revoked, errRevoked := a.RevocationStore.IsRevoked(c, claims.RevocationIDs(), claims.ID, claims.IssuedAt)
+
+	if errRevoked != nil {
+		return res, helper.CheckError(errRevoked)
+	}
+
+	if revoked {
+		return res, helper.CheckError(helper.ErrInvalidToken)
+	}
+
+	jti := claims.JTI
+
+	// Checked before rotating, so another client cannot burn the token.
+	session, errSession := a.tokenSession(c, jti, device.ClientID)
+
+	if errSession != nil {
+		return res, helper.CheckError(errSession)
+	}
+
+	old, errRepo := a.TokenRepo.RotateRefreshToken(c, jti)
+
+	if errors.Is(errRepo, helper.ErrTokenReused) {
+		// A rotated token showing up again means it leaked; kill every token descended from the same login.
+		if errRevoke := a.TokenRepo.RevokeTokenFamily(c, old.FamilyID); errRevoke != nil {
+			return res, helper.CheckError(errRevoke)
+		}
+
+		// Access tokens carry the family as their sid.
+		if errRevoke := a.RevocationStore.RevokeToken(c, old.FamilyID, time.Now().Add(helper.AccessTokenTTL)); errRevoke != nil {
+			return res, helper.CheckError(errRevoke)
+		}
+	}
+
+	if errRepo != nil {
+		return res, helper.CheckError(errRepo)
+	}
+
+	user, errUser := a.TokenRepo.GetUserByID(c, old.UserID)
+
+	if errUser != nil {
+		return res, helper.CheckError(errUser)
+	}
+
+	if errRepo := a.TokenRepo.TouchSession(c, old.FamilyID, device.IP, device.UserAgent); errRepo != nil {
+		return res, helper.CheckError(errRepo)
+	}
+
+	return a.issueTokens(c, dto.UserRegistrationsResp{
+		ID:    user.ID,
+		Email: user.Email,
+		Role:  user.Role,
+	}, session)
+}
+
+func (a *TokenServiceImpl) LogoutService(c context.Context, claims helper.Claims, data dto.LogoutReq) (res string, err *helper.ErrorStruct) {
+	if claims.JTI == "" || claims.ExpiresAt.IsZero() {
+		return res, helper.CheckError(helper.ErrInvalidToken)
+	}
+
+	if errRevoke := a.RevocationStore.RevokeToken(c, claims.JTI, claims.ExpiresAt); errRevoke != nil {
+		return res, helper.CheckError(errRevoke)
+	}
+
+	// The access token names its session, so the session ends even when the
+	// client does not post its refresh token.
+	if claims.SessionID != "" {
+		if errRepo := a.TokenRepo.RevokeTokenFamily(c, claims.SessionID); errRepo != nil {
+			return res, helper.CheckError(errRepo)
+		}
+
+		if errRevoke := a.RevocationStore.RevokeToken(c, claims.SessionID, time.Now().Add(helper.AccessTokenTTL)); errRevoke != nil {
+			return res, helper.CheckError(errRevoke)
+		}
+	}
+
+	if data.RefreshToken != "" {
+		refreshClaims, errParse := helper.ParseJWT(data.RefreshToken)
+
+		if errParse == nil && refreshClaims["typ"] == helper.TokenTypeRefresh && refreshClaims["id"] == claims.ID {
+			refreshID, _ := refreshClaims["jti"].(string)
+
+			rt, errRepo := a.TokenRepo.GetRefreshToken(c, refreshID)
+			if errRepo == nil {
+				errRepo = a.TokenRepo.RevokeTokenFamily(c, rt.FamilyID)
+			}
+
+			if errRepo != nil && !errors.Is(errRepo, helper.ErrInvalidToken) {
+				return res, helper.CheckError(errRepo)
+			}
+		}
+	}
+
+	return "Berhasil logout", nil
+}
+
+func (a *TokenServiceImpl) LogoutAllService(c context.Context, id string) (res string, err *helper.ErrorStruct) {
+	if errRevoke := a.RevocationStore.RevokeUserTokens(c, id, time.Now()); errRevoke != nil {
+		return res, helper.CheckError(errRevoke)
+	}
+
+	if errRepo := a.TokenRepo.RevokeUserRefreshTokens(c, id); errRepo != nil {
+		return res, helper.CheckError(errRepo)
+	}
+
+	return "Berhasil logout dari semua perangkat", nil
+}
+
+func (a *TokenServiceImpl) GetSessionsService(c context.Context, userID, currentSessionID string) (res []dto.SessionResp, err *helper.ErrorStruct) {
+	sessions, errRepo := a.TokenRepo.GetActiveSessions(c, userID)
+
+	if errRepo != nil {
+		return res, helper.CheckError(errRepo)
+	}
+
+	res = make([]dto.SessionResp, 0, len(sessions))
+	for _, session := range sessions {
+		res = append(res, dto.SessionResp{
+			ID:         session.ID,
+			DeviceName: session.DeviceName,
+			UserAgent:  session.UserAgent,
+			IP:         session.IP,
+			LastSeenAt: session.LastSeenAt,
+			CreatedAt:  session.CreatedAt,
+			Current:    session.ID == currentSessionID,
+		})
+	}
+
+	return res, nil
+}
+
+// RevokeSessionService ends one session: its refresh tokens are revoked and
+// the session id is blacklisted, which rejects the access tokens carrying it
+// as their sid.
+func (a *TokenServiceImpl) RevokeSessionService(c context.Context, userID, sessionID string) (res string, err *helper.ErrorStruct) {
+	session, errRepo := a.TokenRepo.GetSession(c, sessionID)
+
+	if errRepo != nil {
+		return res, helper.CheckError(errRepo)
+	}
+
+	// Someone else's session is reported as missing, not forbidden.
+	if session.UserID != userID {
+		return res, helper.CheckError(helper.ErrNotFound)
+	}
+
+	if errRepo := a.TokenRepo.RevokeTokenFamily(c, session.ID); errRepo != nil {
+		return res, helper.CheckError(errRepo)
+	}
+
+	if errRevoke := a.RevocationStore.RevokeToken(c, session.ID, time.Now().Add(helper.AccessTokenTTL)); errRevoke != nil {
+		return res, helper.CheckError(errRevoke)
+	}
+
+	return "Sesi berhasil dihapus", nil
+}
+
+// PruneRevocationsService drops revocation entries that can no longer match
+// a live token.
+func (a *TokenServiceImpl) PruneRevocationsService(c context.Context) (res int64, err *helper.ErrorStruct) {
+	res, errStore := a.RevocationStore.PruneExpired(c)
+
+	if errStore != nil {
+		return res, helper.CheckError(errStore)
+	}
+
+	return res, nil
+}
+
+func NewTokenService(tokenRepo repository.TokenRepo, revocationStore repository.RevocationStore) TokenService {
+	return &TokenServiceImpl{
+		TokenRepo:       tokenRepo,
+		RevocationStore: revocationStore,
+	}
+}
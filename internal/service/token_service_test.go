@@ -0,0 +1,272 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/GabrielMoody/mikronet-auth-service/internal/dto"
+	"github.com/GabrielMoody/mikronet-auth-service/internal/helper"
+	"github.com/GabrielMoody/mikronet-auth-service/internal/models"
+	"github.com/GabrielMoody/mikronet-auth-service/internal/repository"
+	"github.com/GabrielMoody/mikronet-auth-service/internal/testdb"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+func newTestUser(t *testing.T, db *gorm.DB, id, role string) dto.UserRegistrationsResp {
+	t.Helper()
+
+	user := models.User{
+		ID:    id,
+		Email: id + "@example.com",
+		Role:  role,
+	}
+
+	if err := db.Omit(clause.Associations).Create(&user).Error; err != nil {
+		t.Fatalf("error while creating user: %v", err)
+	}
+
+	return dto.UserRegistrationsResp{
+		ID:    user.ID,
+		Email: user.Email,
+		Role:  user.Role,
+	}
+}
+
+func newTestTokenService(t *testing.T, db *gorm.DB) *TokenServiceImpl {
+	t.Helper()
+	t.Setenv("JWT_SECRET", "test-secret")
+
+	return &TokenServiceImpl{
+		TokenRepo:       repository.NewTokenRepo(db),
+		RevocationStore: repository.NewMemoryRevocationStore(),
+	}
+}
+
+func TestRefreshTokenRotation(t *testing.T) {
+	ctx := context.Background()
+
+	tests := []struct {
+		name string
+		// refresh returns the token to redeem and the one that must stay usable
+		// afterwards, if any.
+		refresh  func(t *testing.T, s *TokenServiceImpl, first dto.TokenResp) (redeem, survivor string)
+		wantErr  error
+		wantCode int
+	}{
+		{
+			name: "fresh token rotates",
+			refresh: func(t *testing.T, s *TokenServiceImpl, first dto.TokenResp) (string, string) {
+				return first.RefreshToken, ""
+			},
+		},
+		{
+			name: "rotated token is reuse and kills the family",
+			refresh: func(t *testing.T, s *TokenServiceImpl, first dto.TokenResp) (string, string) {
+				second, err := s.RefreshTokenService(ctx, dto.RefreshTokenReq{RefreshToken: first.RefreshToken}, dto.DeviceInfo{})
+				if err != nil {
+					t.Fatalf("first rotation failed: %v", err.Err)
+				}
+				return first.RefreshToken, second.RefreshToken
+			},
+			wantErr:  helper.ErrTokenReused,
+			wantCode: 401,
+		},
+		{
+			name: "access token is not a refresh token",
+			refresh: func(t *testing.T, s *TokenServiceImpl, first dto.TokenResp) (string, string) {
+				return first.AccessToken, ""
+			},
+			wantErr:  helper.ErrInvalidToken,
+			wantCode: 401,
+		},
+		{
+			name: "revoked family is rejected",
+			refresh: func(t *testing.T, s *TokenServiceImpl, first dto.TokenResp) (string, string) {
+				if _, err := s.LogoutAllService(ctx, "user-1"); err != nil {
+					t.Fatalf("logout all failed: %v", err.Err)
+				}
+				return first.RefreshToken, ""
+			},
+			wantErr:  helper.ErrInvalidToken,
+			wantCode: 401,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			db := testdb.New(t)
+			s := newTestTokenService(t, db)
+			user := newTestUser(t, db, "user-1", "user")
+
+			first, err := s.IssueTokenService(ctx, user, dto.DeviceInfo{UserAgent: "Mozilla/5.0 (Linux; Android 14)"})
+			if err != nil {
+				t.Fatalf("issue failed: %v", err.Err)
+			}
+
+			redeem, survivor := tt.refresh(t, s, first)
+
+			res, err := s.RefreshTokenService(ctx, dto.RefreshTokenReq{RefreshToken: redeem}, dto.DeviceInfo{})
+
+			if tt.wantErr == nil {
+				if err != nil {
+					t.Fatalf("unexpected error: %v", err.Err)
+				}
+				if res.AccessToken == "" || res.RefreshToken == "" || res.RefreshToken == redeem {
+					t.Fatalf("expected a new token pair, got %+v", res)
+				}
+				return
+			}
+
+			if err == nil || !errors.Is(err.Err, tt.wantErr) || err.Code != tt.wantCode {
+				t.Fatalf("expected %v (%d), got %+v", tt.wantErr, tt.wantCode, err)
+			}
+
+			if survivor != "" {
+				if _, err := s.RefreshTokenService(ctx, dto.RefreshTokenReq{RefreshToken: survivor}, dto.DeviceInfo{}); err == nil {
+					t.Fatal("token rotated from a reused one must be revoked")
+				}
+			}
+		})
+	}
+}
+
+func TestRefreshReuseRevokesAccessTokens(t *testing.T) {
+	ctx := context.Background()
+	db := testdb.New(t)
+	s := newTestTokenService(t, db)
+	user := newTestUser(t, db, "user-1", "user")
+
+	first, err := s.IssueTokenService(ctx, user, dto.DeviceInfo{})
+	if err != nil {
+		t.Fatalf("issue failed: %v", err.Err)
+	}
+
+	second, err := s.RefreshTokenService(ctx, dto.RefreshTokenReq{RefreshToken: first.RefreshToken}, dto.DeviceInfo{})
+	if err != nil {
+		t.Fatalf("first rotation failed: %v", err.Err)
+	}
+
+	if _, err := s.RefreshTokenService(ctx, dto.RefreshTokenReq{RefreshToken: first.RefreshToken}, dto.DeviceInfo{}); err == nil || !errors.Is(err.Err, helper.ErrTokenReused) {
+		t.Fatalf("expected reuse to be detected, got %+v", err)
+	}
+
+	for _, token := range []string{first.AccessToken, second.AccessToken} {
+		claims := accessClaims(t, token)
+
+		revoked, errStore := s.RevocationStore.IsRevoked(ctx, claims.RevocationIDs(), claims.ID, claims.IssuedAt)
+		if errStore != nil || !revoked {
+			t.Fatalf("expected access tokens of the reused family to be revoked, got %v, %v", revoked, errStore)
+		}
+	}
+}
+
+func TestRefreshKeepsSession(t *testing.T) {
+	ctx := context.Background()
+	db := testdb.New(t)
+	s := newTestTokenService(t, db)
+	user := newTestUser(t, db, "user-1", "user")
+
+	first, err := s.IssueTokenService(ctx, user, dto.DeviceInfo{DeviceName: "Pixel"})
+	if err != nil {
+		t.Fatalf("issue failed: %v", err.Err)
+	}
+
+	if _, err := s.RefreshTokenService(ctx, dto.RefreshTokenReq{RefreshToken: first.RefreshToken}, dto.DeviceInfo{IP: "10.0.0.1"}); err != nil {
+		t.Fatalf("refresh failed: %v", err.Err)
+	}
+
+	sessions, errRepo := s.TokenRepo.GetActiveSessions(ctx, user.ID)
+	if errRepo != nil {
+		t.Fatalf("listing sessions failed: %v", errRepo)
+	}
+
+	if len(sessions) != 1 || sessions[0].DeviceName != "Pixel" || sessions[0].IP != "10.0.0.1" {
+		t.Fatalf("expected the one session to be kept and touched, got %+v", sessions)
+	}
+}
+
+func TestLogoutEndsSession(t *testing.T) {
+	ctx := context.Background()
+	db := testdb.New(t)
+	s := newTestTokenService(t, db)
+	user := newTestUser(t, db, "user-1", "user")
+
+	phone, err := s.IssueTokenService(ctx, user, dto.DeviceInfo{DeviceName: "Phone"})
+	if err != nil {
+		t.Fatalf("issue failed: %v", err.Err)
+	}
+
+	laptop, err := s.IssueTokenService(ctx, user, dto.DeviceInfo{DeviceName: "Laptop"})
+	if err != nil {
+		t.Fatalf("issue failed: %v", err.Err)
+	}
+
+	phoneClaims := accessClaims(t, phone.AccessToken)
+
+	// No refresh token is posted; the sid in the access token is enough.
+	if _, err := s.LogoutService(ctx, phoneClaims, dto.LogoutReq{}); err != nil {
+		t.Fatalf("logout failed: %v", err.Err)
+	}
+
+	if _, err := s.RefreshTokenService(ctx, dto.RefreshTokenReq{RefreshToken: phone.RefreshToken}, dto.DeviceInfo{}); err == nil || !errors.Is(err.Err, helper.ErrInvalidToken) {
+		t.Fatalf("expected the logged out session's refresh token to be invalid, got %+v", err)
+	}
+
+	revoked, errStore := s.RevocationStore.IsRevoked(ctx, []string{"unrelated-jti", phoneClaims.SessionID}, user.ID, phoneClaims.IssuedAt)
+	if errStore != nil || !revoked {
+		t.Fatalf("expected access tokens of the session to be revoked, got %v, %v", revoked, errStore)
+	}
+
+	if _, err := s.RefreshTokenService(ctx, dto.RefreshTokenReq{RefreshToken: laptop.RefreshToken}, dto.DeviceInfo{}); err != nil {
+		t.Fatalf("other sessions must stay usable: %v", err.Err)
+	}
+}
+
+// Signing in again right after "logout all" usually happens within the same
+// second as the cutoff, and the new tokens must not be caught by it.
+func TestLoginAfterLogoutAll(t *testing.T) {
+	ctx := context.Background()
+	db := testdb.New(t)
+	s := newTestTokenService(t, db)
+	user := newTestUser(t, db, "user-1", "user")
+
+	if _, err := s.IssueTokenService(ctx, user, dto.DeviceInfo{}); err != nil {
+		t.Fatalf("issue failed: %v", err.Err)
+	}
+
+	if _, err := s.LogoutAllService(ctx, user.ID); err != nil {
+		t.Fatalf("logout all failed: %v", err.Err)
+	}
+
+	tokens, err := s.IssueTokenService(ctx, user, dto.DeviceInfo{})
+	if err != nil {
+		t.Fatalf("issue failed: %v", err.Err)
+	}
+
+	claims := accessClaims(t, tokens.AccessToken)
+
+	revoked, errRevoked := s.RevocationStore.IsRevoked(ctx, claims.RevocationIDs(), claims.ID, claims.IssuedAt)
+	if errRevoked != nil {
+		t.Fatalf("unexpected error: %v", errRevoked)
+	}
+	if revoked {
+		t.Errorf("access token issued after logout all is revoked")
+	}
+
+	if _, err := s.RefreshTokenService(ctx, dto.RefreshTokenReq{RefreshToken: tokens.RefreshToken}, dto.DeviceInfo{}); err != nil {
+		t.Errorf("refresh token issued after logout all was refused: %v", err.Err)
+	}
+}
+
+func accessClaims(t *testing.T, token string) helper.Claims {
+	t.Helper()
+
+	payload, err := helper.ParseJWT(token)
+	if err != nil {
+		t.Fatalf("error while parsing access token: %v", err)
+	}
+
+	return helper.NewClaims(payload)
+}
@@ -0,0 +1,358 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"os"
+	"strconv"
+	"time"
+
+	"github.com/GabrielMoody/mikronet-auth-service/internal/dto"
+	"github.com/GabrielMoody/mikronet-auth-service/internal/helper"
+	"github.com/GabrielMoody/mikronet-auth-service/internal/models"
+	"github.com/GabrielMoody/mikronet-auth-service/internal/repository"
+	"github.com/gofiber/fiber/v2"
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/google/uuid"
+)
+
+const recoveryCodeCount = 10
+
+type TwoFactorService interface {
+	CheckLoginService(c context.Context, user dto.UserRegistrationsResp) (res dto.TwoFactorLoginResp, err *helper.ErrorStruct)
+	VerifyLoginService(c context.Context, data dto.TwoFactorLoginReq) (res dto.UserRegistrationsResp, err *helper.ErrorStruct)
+	EnrollService(c context.Context, id, email string) (res dto.TwoFactorEnrollResp, err *helper.ErrorStruct)
+	ConfirmService(c context.Context, id string, data dto.TwoFactorConfirmReq) (res dto.RecoveryCodesResp, err *helper.ErrorStruct)
+	SetPolicyService(c context.Context, role string, data dto.TwoFactorPolicyReq, adminID string) (res dto.TwoFactorPolicyResp, err *helper.ErrorStruct)
+	GetPoliciesService(c context.Context) (res []dto.TwoFactorPolicyResp, err *helper.ErrorStruct)
+}
+
+type TwoFactorServiceImpl struct {
+	TwoFactorRepo     repository.TwoFactorRepo
+	AuthRepo          repository.AuthRepo
+	LoginAttemptStore repository.LoginAttemptStore
+	RevocationStore   repository.RevocationStore
+	AuditService      AuditService
+}
+
+func signTwoFactorToken(user dto.UserRegistrationsResp, typ string) (string, error) {
+	now := time.Now()
+
+	return helper.SignJWT(jwt.MapClaims{
+		"id":    user.ID,
+		"email": user.Email,
+		"role":  user.Role,
+		"jti":   uuid.NewString(),
+		"typ":   typ,
+		"iat":   now.Unix(),
+		"exp":   now.Add(helper.TwoFactorTokenTTL).Unix(),
+		"iss":   os.Getenv("JWT_ISS"),
+	})
+}
+
+// CheckLoginService runs after the first factor succeeded. An empty response
+// means the caller may issue tokens right away.
+func (a *TwoFactorServiceImpl) CheckLoginService(c context.Context, user dto.UserRegistrationsResp) (res dto.TwoFactorLoginResp, err *helper.ErrorStruct) {
+	tf, errRepo := a.TwoFactorRepo.GetTwoFactor(c, user.ID)
+
+	if errRepo != nil && !errors.Is(errRepo, helper.ErrNotFound) {
+		return res, helper.CheckError(errRepo)
+	}
+
+	if errRepo == nil && tf.Enabled {
+		token, errToken := signTwoFactorToken(user, helper.TokenTypeTwoFactor)
+		if errToken != nil {
+			return res, &helper.ErrorStruct{
+				Err:  errToken,
+				Code: fiber.StatusInternalServerError,
+			}
+		}
+
+		return dto.TwoFactorLoginResp{
+			TwoFactorRequired: true,
+			ChallengeToken:    token,
+		}, nil
+	}
+
+	required, errPolicy := a.TwoFactorRepo.IsTwoFactorRequired(c, user.Role)
+
+	if errPolicy != nil {
+		return res, helper.CheckError(errPolicy)
+	}
+
+	if !required {
+		return res, nil
+	}
+
+	// Enrollment is enforced but not done yet: the setup token only unlocks
+	// the enroll and confirm endpoints.
+	token, errToken := signTwoFactorToken(user, helper.TokenTypeTwoFactorSetup)
+	if errToken != nil {
+		return res, &helper.ErrorStruct{
+			Err:  errToken,
+			Code: fiber.StatusInternalServerError,
+		}
+	}
+
+	return dto.TwoFactorLoginResp{
+		SetupRequired: true,
+		SetupToken:    token,
+	}, nil
+}
+
+func (a *TwoFactorServiceImpl) VerifyLoginService(c context.Context, data dto.TwoFactorLoginReq) (res dto.UserRegistrationsResp, err *helper.ErrorStruct) {
+	if errValidate := helper.Validate.Struct(data); errValidate != nil {
+		return res, &helper.ErrorStruct{
+			Code:             fiber.StatusBadRequest,
+			ValidationErrors: helper.ValidationError(errValidate),
+		}
+	}
+
+	claims, errParse := helper.ParseJWT(data.ChallengeToken)
+
+	if errParse != nil || claims["typ"] != helper.TokenTypeTwoFactor {
+		return res, helper.CheckError(helper.ErrInvalidToken)
+	}
+
+	challenge := helper.NewClaims(claims)
+	id, email, role := challenge.ID, challenge.Email, challenge.Role
+
+	// A challenge is spent once it let someone in.
+	revoked, errRevoked := a.RevocationStore.IsRevoked(c, challenge.RevocationIDs(), id, challenge.IssuedAt)
+
+	if errRevoked != nil {
+		return res, helper.CheckError(errRevoked)
+	}
+
+	if revoked {
+		return res, helper.CheckError(helper.ErrInvalidToken)
+	}
+
+	key := "2fa:" + id
+
+	if errLockout := checkLockout(c, a.LoginAttemptStore, key); errLockout != nil {
+		return res, helper.CheckError(errLockout)
+	}
+
+	tf, errRepo := a.TwoFactorRepo.GetTwoFactor(c, id)
+
+	if errRepo != nil {
+		return res, helper.CheckError(errRepo)
+	}
+
+	// Six digits is a TOTP code, anything else is treated as a recovery code.
+	var errUse error
+	if len(data.Code) == 6 {
+		if step, ok := helper.ValidateTOTP(tf.Secret, data.Code, time.Now()); ok {
+			errUse = a.TwoFactorRepo.UseTOTPStep(c, id, step)
+		} else {
+			errUse = helper.ErrInvalidOTP
+		}
+	} else {
+		errUse = a.TwoFactorRepo.UseRecoveryCode(c, id, helper.HashToken(helper.NormalizeRecoveryCode(data.Code)))
+	}
+
+	if errors.Is(errUse, helper.ErrInvalidOTP) {
+		if errAttempt := registerFailure(c, a.LoginAttemptStore, key, helper.GetEnvInt("LOGIN_MAX_ATTEMPTS_2FA", 5)); errAttempt != nil {
+			return res, helper.CheckError(errAttempt)
+		}
+	}
+
+	if errUse != nil {
+		return res, helper.CheckError(errUse)
+	}
+
+	// The account may have been blocked since the password was checked.
+	blocked, errRepo := a.AuthRepo.IsBlocked(c, id)
+
+	if errRepo != nil {
+		return res, helper.CheckError(errRepo)
+	}
+
+	if blocked {
+		return res, helper.CheckError(helper.ErrBlockedAccount)
+	}
+
+	if errRevoke := a.RevocationStore.RevokeToken(c, challenge.JTI, challenge.ExpiresAt); errRevoke != nil {
+		return res, helper.CheckError(errRevoke)
+	}
+
+	if errAttempt := a.LoginAttemptStore.ResetAttempt(c, key); errAttempt != nil {
+		return res, helper.CheckError(errAttempt)
+	}
+
+	return dto.UserRegistrationsResp{
+		ID:    id,
+		Email: email,
+		Role:  role,
+	}, nil
+}
+
+func (a *TwoFactorServiceImpl) EnrollService(c context.Context, id, email string) (res dto.TwoFactorEnrollResp, err *helper.ErrorStruct) {
+	tf, errRepo := a.TwoFactorRepo.GetTwoFactor(c, id)
+
+	if errRepo != nil && !errors.Is(errRepo, helper.ErrNotFound) {
+		return res, helper.CheckError(errRepo)
+	}
+
+	if tf.Enabled {
+		return res, helper.CheckError(helper.ErrTwoFactorEnabled)
+	}
+
+	secret, errSecret := helper.GenerateTOTPSecret()
+
+	if errSecret != nil {
+		return res, &helper.ErrorStruct{
+			Err:  errSecret,
+			Code: fiber.StatusInternalServerError,
+		}
+	}
+
+	tf.UserID = id
+	tf.Secret = secret
+
+	if _, errRepo := a.TwoFactorRepo.SaveTwoFactor(c, tf); errRepo != nil {
+		return res, helper.CheckError(errRepo)
+	}
+
+	issuer := os.Getenv("TOTP_ISSUER")
+	if issuer == "" {
+		issuer = "Mikronet"
+	}
+
+	return dto.TwoFactorEnrollResp{
+		Secret:     secret,
+		OtpauthURI: helper.TOTPURI(issuer, email, secret),
+	}, nil
+}
+
+func (a *TwoFactorServiceImpl) ConfirmService(c context.Context, id string, data dto.TwoFactorConfirmReq) (res dto.RecoveryCodesResp, err *helper.ErrorStruct) {
+	if errValidate := helper.Validate.Struct(data); errValidate != nil {
+		return res, &helper.ErrorStruct{
+			Code:             fiber.StatusBadRequest,
+			ValidationErrors: helper.ValidationError(errValidate),
+		}
+	}
+
+	// Guesses here share the counter of the login step, so confirming
+	// cannot be used to try codes without limit.
+	key := "2fa:" + id
+
+	if errLockout := checkLockout(c, a.LoginAttemptStore, key); errLockout != nil {
+		return res, helper.CheckError(errLockout)
+	}
+
+	tf, errRepo := a.TwoFactorRepo.GetTwoFactor(c, id)
+
+	if errRepo != nil {
+		return res, helper.CheckError(errRepo)
+	}
+
+	if tf.Enabled {
+		return res, helper.CheckError(helper.ErrTwoFactorEnabled)
+	}
+
+	step, ok := helper.ValidateTOTP(tf.Secret, data.Code, time.Now())
+	if !ok {
+		if errAttempt := registerFailure(c, a.LoginAttemptStore, key, helper.GetEnvInt("LOGIN_MAX_ATTEMPTS_2FA", 5)); errAttempt != nil {
+			return res, helper.CheckError(errAttempt)
+		}
+
+		return res, helper.CheckError(helper.ErrInvalidOTP)
+	}
+
+	codes := make([]string, 0, recoveryCodeCount)
+	hashes := make([]string, 0, recoveryCodeCount)
+
+	for i := 0; i < recoveryCodeCount; i++ {
+		code, errCode := helper.GenerateRecoveryCode()
+		if errCode != nil {
+			return res, &helper.ErrorStruct{
+				Err:  errCode,
+				Code: fiber.StatusInternalServerError,
+			}
+		}
+
+		codes = append(codes, code)
+		hashes = append(hashes, helper.HashToken(code))
+	}
+
+	if errRepo := a.TwoFactorRepo.EnableTwoFactor(c, id, step, hashes); errRepo != nil {
+		return res, helper.CheckError(errRepo)
+	}
+
+	if errAttempt := a.LoginAttemptStore.ResetAttempt(c, key); errAttempt != nil {
+		return res, helper.CheckError(errAttempt)
+	}
+
+	return dto.RecoveryCodesResp{
+		RecoveryCodes: codes,
+	}, nil
+}
+
+func (a *TwoFactorServiceImpl) SetPolicyService(c context.Context, role string, data dto.TwoFactorPolicyReq, adminID string) (res dto.TwoFactorPolicyResp, err *helper.ErrorStruct) {
+	if errValidate := helper.Validate.Struct(data); errValidate != nil {
+		return res, &helper.ErrorStruct{
+			Code:             fiber.StatusBadRequest,
+			ValidationErrors: helper.ValidationError(errValidate),
+		}
+	}
+
+	if errRole := helper.Validate.Var(role, "oneof=admin user driver owner government"); errRole != nil {
+		return res, helper.CheckError(helper.ErrBadRequest)
+	}
+
+	policy, errRepo := a.TwoFactorRepo.SetTwoFactorPolicy(c, models.TwoFactorPolicy{
+		Role:      role,
+		Required:  *data.Required,
+		UpdatedBy: adminID,
+	})
+
+	recordResult(c, a.AuditService, models.AuthEvent{
+		Type:     models.EventTwoFactorPolicyUpdate,
+		ActorID:  adminID,
+		TargetID: role,
+		Reason:   "required=" + strconv.FormatBool(*data.Required),
+	}, errRepo)
+
+	if errRepo != nil {
+		return res, helper.CheckError(errRepo)
+	}
+
+	return dto.TwoFactorPolicyResp{
+		Role:      policy.Role,
+		Required:  policy.Required,
+		UpdatedBy: policy.UpdatedBy,
+		UpdatedAt: policy.UpdatedAt,
+	}, nil
+}
+
+func (a *TwoFactorServiceImpl) GetPoliciesService(c context.Context) (res []dto.TwoFactorPolicyResp, err *helper.ErrorStruct) {
+	policies, errRepo := a.TwoFactorRepo.GetTwoFactorPolicies(c)
+
+	if errRepo != nil {
+		return res, helper.CheckError(errRepo)
+	}
+
+	res = make([]dto.TwoFactorPolicyResp, 0, len(policies))
+	for _, p := range policies {
+		res = append(res, dto.TwoFactorPolicyResp{
+			Role:      p.Role,
+			Required:  p.Required,
+			UpdatedBy: p.UpdatedBy,
+			UpdatedAt: p.UpdatedAt,
+		})
+	}
+
+	return res, nil
+}
+
+func NewTwoFactorService(twoFactorRepo repository.TwoFactorRepo, authRepo repository.AuthRepo, loginAttemptStore repository.LoginAttemptStore, revocationStore repository.RevocationStore, auditService AuditService) TwoFactorService {
+	return &TwoFactorServiceImpl{
+		TwoFactorRepo:     twoFactorRepo,
+		AuthRepo:          authRepo,
+		LoginAttemptStore: loginAttemptStore,
+		RevocationStore:   revocationStore,
+		AuditService:      auditService,
+	}
+}
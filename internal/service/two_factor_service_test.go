@@ -0,0 +1,288 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/GabrielMoody/mikronet-auth-service/internal/dto"
+	"github.com/GabrielMoody/mikronet-auth-service/internal/helper"
+	"github.com/GabrielMoody/mikronet-auth-service/internal/models"
+	"github.com/GabrielMoody/mikronet-auth-service/internal/repository"
+	"github.com/GabrielMoody/mikronet-auth-service/internal/testdb"
+	"gorm.io/gorm"
+)
+
+func newTestTwoFactorService(db *gorm.DB) *TwoFactorServiceImpl {
+	return &TwoFactorServiceImpl{
+		TwoFactorRepo:     repository.NewTwoFactorRepo(db),
+		AuthRepo:          repository.NewAuthRepo(db),
+		LoginAttemptStore: repository.NewMemoryLoginAttemptStore(),
+		RevocationStore:   repository.NewMemoryRevocationStore(),
+		AuditService:      NewAuditService(repository.NewAuditRepo(db)),
+	}
+}
+
+func TestVerifyLoginService(t *testing.T) {
+	ctx := context.Background()
+	t.Setenv("JWT_SECRET", "test-secret")
+	t.Setenv("LOGIN_MAX_ATTEMPTS_2FA", "3")
+
+	type enrolled struct {
+		secret    string
+		confirmed string
+		recovery  []string
+	}
+
+	codeAt := func(t *testing.T, secret string, step int64) string {
+		code, err := helper.TOTPCode(secret, step)
+		if err != nil {
+			t.Fatalf("error while computing code: %v", err)
+		}
+		return code
+	}
+
+	tests := []struct {
+		name string
+		// before makes earlier attempts; code returns the one under test.
+		before  func(t *testing.T, e enrolled, verify func(string) *helper.ErrorStruct)
+		code    func(t *testing.T, e enrolled) string
+		wantErr error
+		locked  bool
+	}{
+		{
+			name: "fresh code",
+			code: func(t *testing.T, e enrolled) string { return codeAt(t, e.secret, helper.TOTPStep(time.Now())+1) },
+		},
+		{
+			name:    "code already used to confirm",
+			code:    func(t *testing.T, e enrolled) string { return e.confirmed },
+			wantErr: helper.ErrInvalidOTP,
+		},
+		{
+			name: "recovery code typed without the dash",
+			code: func(t *testing.T, e enrolled) string { return e.recovery[0][:5] + e.recovery[0][6:] },
+		},
+		{
+			name: "recovery code used twice",
+			before: func(t *testing.T, e enrolled, verify func(string) *helper.ErrorStruct) {
+				if err := verify(e.recovery[0]); err != nil {
+					t.Fatalf("first use failed: %v", err.Err)
+				}
+			},
+			code:    func(t *testing.T, e enrolled) string { return e.recovery[0] },
+			wantErr: helper.ErrInvalidOTP,
+		},
+		{
+			name: "right code after too many wrong ones",
+			before: func(t *testing.T, e enrolled, verify func(string) *helper.ErrorStruct) {
+				for i := 0; i < 3; i++ {
+					verify("000000")
+				}
+			},
+			code:   func(t *testing.T, e enrolled) string { return codeAt(t, e.secret, helper.TOTPStep(time.Now())+1) },
+			locked: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			db := testdb.New(t)
+			s := newTestTwoFactorService(db)
+			user := newTestUser(t, db, "admin-1", "admin")
+
+			enroll, err := s.EnrollService(ctx, user.ID, user.Email)
+			if err != nil {
+				t.Fatalf("enroll failed: %v", err.Err)
+			}
+
+			e := enrolled{secret: enroll.Secret, confirmed: codeAt(t, enroll.Secret, helper.TOTPStep(time.Now()))}
+
+			codes, err := s.ConfirmService(ctx, user.ID, dto.TwoFactorConfirmReq{Code: e.confirmed})
+			if err != nil {
+				t.Fatalf("confirm failed: %v", err.Err)
+			}
+			e.recovery = codes.RecoveryCodes
+
+			check, err := s.CheckLoginService(ctx, user)
+			if err != nil {
+				t.Fatalf("check failed: %v", err.Err)
+			}
+			if !check.TwoFactorRequired || check.ChallengeToken == "" {
+				t.Fatalf("expected a challenge, got %+v", check)
+			}
+
+			// Earlier attempts get their own challenge; a successful one spends it.
+			verify := func(code string) *helper.ErrorStruct {
+				challenge, err := s.CheckLoginService(ctx, user)
+				if err != nil {
+					t.Fatalf("check failed: %v", err.Err)
+				}
+
+				_, err = s.VerifyLoginService(ctx, dto.TwoFactorLoginReq{ChallengeToken: challenge.ChallengeToken, Code: code})
+				return err
+			}
+
+			if tt.before != nil {
+				tt.before(t, e, verify)
+			}
+
+			res, err := s.VerifyLoginService(ctx, dto.TwoFactorLoginReq{ChallengeToken: check.ChallengeToken, Code: tt.code(t, e)})
+
+			switch {
+			case tt.locked:
+				var tooMany *helper.TooManyAttemptsError
+				if err == nil || !errors.As(err.Err, &tooMany) {
+					t.Fatalf("expected a lockout, got %+v", err)
+				}
+			case tt.wantErr != nil:
+				if err == nil || !errors.Is(err.Err, tt.wantErr) {
+					t.Fatalf("expected %v, got %+v", tt.wantErr, err)
+				}
+			default:
+				if err != nil {
+					t.Fatalf("unexpected error: %v", err.Err)
+				}
+				if res.ID != user.ID || res.Role != user.Role {
+					t.Errorf("verified as %+v, want %+v", res, user)
+				}
+			}
+		})
+	}
+}
+
+func TestConfirmLockout(t *testing.T) {
+	ctx := context.Background()
+	t.Setenv("JWT_SECRET", "test-secret")
+	t.Setenv("LOGIN_MAX_ATTEMPTS_2FA", "3")
+
+	db := testdb.New(t)
+	s := newTestTwoFactorService(db)
+	user := newTestUser(t, db, "admin-1", "admin")
+
+	enroll, err := s.EnrollService(ctx, user.ID, user.Email)
+	if err != nil {
+		t.Fatalf("enroll failed: %v", err.Err)
+	}
+
+	for i := 0; i < 3; i++ {
+		if _, err := s.ConfirmService(ctx, user.ID, dto.TwoFactorConfirmReq{Code: "000000"}); err == nil || !errors.Is(err.Err, helper.ErrInvalidOTP) {
+			t.Fatalf("wrong code %d = %+v, want %v", i, err, helper.ErrInvalidOTP)
+		}
+	}
+
+	code, errCode := helper.TOTPCode(enroll.Secret, helper.TOTPStep(time.Now()))
+	if errCode != nil {
+		t.Fatalf("error while computing code: %v", errCode)
+	}
+
+	var tooMany *helper.TooManyAttemptsError
+	if _, err := s.ConfirmService(ctx, user.ID, dto.TwoFactorConfirmReq{Code: code}); err == nil || !errors.As(err.Err, &tooMany) {
+		t.Fatalf("expected a lockout, got %+v", err)
+	}
+}
+
+func TestVerifyLoginRejectsOtherTokens(t *testing.T) {
+	ctx := context.Background()
+	t.Setenv("JWT_SECRET", "test-secret")
+
+	db := testdb.New(t)
+	s := newTestTwoFactorService(db)
+	user := newTestUser(t, db, "admin-1", "admin")
+
+	// A setup token proves only the first factor.
+	setup, errToken := signTwoFactorToken(user, helper.TokenTypeTwoFactorSetup)
+	if errToken != nil {
+		t.Fatalf("error while signing token: %v", errToken)
+	}
+
+	for name, token := range map[string]string{"setup token": setup, "garbage": "not-a-jwt"} {
+		t.Run(name, func(t *testing.T) {
+			_, err := s.VerifyLoginService(ctx, dto.TwoFactorLoginReq{ChallengeToken: token, Code: "123456"})
+			if err == nil || !errors.Is(err.Err, helper.ErrInvalidToken) {
+				t.Fatalf("expected %v, got %+v", helper.ErrInvalidToken, err)
+			}
+		})
+	}
+}
+
+func TestVerifyLoginChallenge(t *testing.T) {
+	ctx := context.Background()
+	t.Setenv("JWT_SECRET", "test-secret")
+
+	tests := []struct {
+		name string
+		// before runs between the challenge being issued and the code under
+		// test being sent with it.
+		before  func(t *testing.T, s *TwoFactorServiceImpl, db *gorm.DB, challenge string, recovery []string)
+		wantErr error
+	}{
+		{
+			name: "unused challenge",
+		},
+		{
+			name: "challenge already used",
+			before: func(t *testing.T, s *TwoFactorServiceImpl, db *gorm.DB, challenge string, recovery []string) {
+				if _, err := s.VerifyLoginService(ctx, dto.TwoFactorLoginReq{ChallengeToken: challenge, Code: recovery[1]}); err != nil {
+					t.Fatalf("first use failed: %v", err.Err)
+				}
+			},
+			wantErr: helper.ErrInvalidToken,
+		},
+		{
+			name: "account blocked after the password step",
+			before: func(t *testing.T, s *TwoFactorServiceImpl, db *gorm.DB, challenge string, recovery []string) {
+				if err := db.Omit("User").Create(&models.BlockedAccount{UserID: "admin-1", Reason: "abuse", BlockedBy: "admin-2"}).Error; err != nil {
+					t.Fatalf("error while blocking account: %v", err)
+				}
+			},
+			wantErr: helper.ErrBlockedAccount,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			db := testdb.New(t)
+			s := newTestTwoFactorService(db)
+			user := newTestUser(t, db, "admin-1", "admin")
+
+			enroll, err := s.EnrollService(ctx, user.ID, user.Email)
+			if err != nil {
+				t.Fatalf("enroll failed: %v", err.Err)
+			}
+
+			code, errCode := helper.TOTPCode(enroll.Secret, helper.TOTPStep(time.Now()))
+			if errCode != nil {
+				t.Fatalf("error while computing code: %v", errCode)
+			}
+
+			codes, err := s.ConfirmService(ctx, user.ID, dto.TwoFactorConfirmReq{Code: code})
+			if err != nil {
+				t.Fatalf("confirm failed: %v", err.Err)
+			}
+
+			check, err := s.CheckLoginService(ctx, user)
+			if err != nil {
+				t.Fatalf("check failed: %v", err.Err)
+			}
+
+			if tt.before != nil {
+				tt.before(t, s, db, check.ChallengeToken, codes.RecoveryCodes)
+			}
+
+			_, err = s.VerifyLoginService(ctx, dto.TwoFactorLoginReq{ChallengeToken: check.ChallengeToken, Code: codes.RecoveryCodes[0]})
+
+			if tt.wantErr == nil {
+				if err != nil {
+					t.Fatalf("unexpected error: %v", err.Err)
+				}
+				return
+			}
+
+			if err == nil || !errors.Is(err.Err, tt.wantErr) {
+				t.Fatalf("expected %v, got %+v", tt.wantErr, err)
+			}
+		})
+	}
+}
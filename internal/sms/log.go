@@ -0,0 +1,48 @@
+package sms
+
+import (
+	"context"
+	"log"
+	"sync"
+)
+
+type LogSender struct{}
+
+func (s *LogSender) Send(c context.Context, to, body string) error {
+	log.Printf("sms to=%s\n%s", to, body)
+	return nil
+}
+
+func NewLogSender() SMSSender {
+	return &LogSender{}
+}
+
+type Message struct {
+	To   string
+	Body string
+}
+
+// MemorySender keeps sent messages so tests can assert on them.
+type MemorySender struct {
+	mu       sync.Mutex
+	messages []Message
+}
+
+func (s *MemorySender) Send(c context.Context, to, body string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.messages = append(s.messages, Message{To: to, Body: body})
+	return nil
+}
+
+func (s *MemorySender) Messages() []Message {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return append([]Message(nil), s.messages...)
+}
+
+func NewMemorySender() *MemorySender {
+	return &MemorySender{}
+}
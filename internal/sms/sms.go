@@ -0,0 +1,24 @@
+package sms
+
+import (
+	"context"
+	"fmt"
+	"os"
+)
+
+// SMSSender delivers short text messages such as OTP codes to a phone number,
+// either as an SMS or through a WhatsApp gateway.
+type SMSSender interface {
+	Send(c context.Context, to, body string) error
+}
+
+// NewFromEnv picks the backend from SMS_DRIVER. Only the "log" stub is
+// available until a gateway is contracted.
+func NewFromEnv() (SMSSender, error) {
+	switch os.Getenv("SMS_DRIVER") {
+	case "", "log":
+		return NewLogSender(), nil
+	default:
+		return nil, fmt.Errorf("unknown SMS_DRIVER %q", os.Getenv("SMS_DRIVER"))
+	}
+}
@@ -0,0 +1,56 @@
+// Package testdb opens a migrated in-memory SQLite database for tests.
+package testdb
+
+import (
+	"fmt"
+	"strings"
+	"sync/atomic"
+	"testing"
+
+	"github.com/GabrielMoody/mikronet-auth-service/internal/models"
+	"github.com/glebarez/sqlite"
+	"gorm.io/gorm"
+	"gorm.io/gorm/logger"
+)
+
+var counter atomic.Int64
+
+// New returns a fresh database with every table of models.Tables. SQLite has
+// no enum type, so enum columns are created as varchar instead.
+func New(t testing.TB) *gorm.DB {
+	t.Helper()
+
+	// Each test gets its own named database; shared cache lets the pool's
+	// connections see the same one.
+	dsn := fmt.Sprintf("file:testdb%d?mode=memory&cache=shared&_pragma=foreign_keys(1)", counter.Add(1))
+
+	db, err := gorm.Open(sqlite.Open(dsn), &gorm.Config{Logger: logger.Default.LogMode(logger.Silent)})
+	if err != nil {
+		t.Fatalf("error while opening test database: %v", err)
+	}
+
+	for _, table := range models.Tables() {
+		stmt := &gorm.Statement{DB: db}
+		if err := stmt.Parse(table); err != nil {
+			t.Fatalf("error while parsing %T: %v", table, err)
+		}
+
+		for _, field := range stmt.Schema.Fields {
+			if strings.HasPrefix(string(field.DataType), "enum") {
+				field.DataType = "varchar(32)"
+			}
+		}
+	}
+
+	if err := models.Migrate(db); err != nil {
+		t.Fatalf("error while migrating test database: %v", err)
+	}
+
+	t.Cleanup(func() {
+		if sqlDB, err := db.DB(); err == nil {
+			sqlDB.Close()
+		}
+	})
+
+	return db
+}
@@ -0,0 +1,105 @@
+const params = Object.fromEntries(new URLSearchParams(window.location.search));
+const message = document.getElementById('message');
+let challengeToken = null;
+
+// The page lives at .../oauth/authorize, so the login and logout endpoints
+// are one level up whatever prefix the gateway adds.
+const loginURL = '../login';
+const twoFactorURL = '../login/2fa';
+const logoutURL = '../logout';
+
+function showError(result) {
+    const errors = result && result.errors;
+    message.textContent = typeof errors === 'string' ? errors : 'Sign in failed.';
+    message.classList.add('danger');
+}
+
+async function post(url, body, token) {
+    const headers = { 'Content-Type': 'application/json' };
+    if (token) {
+        headers['Authorization'] = 'Bearer ' + token;
+    }
+
+    const response = await fetch(url, {
+        method: 'POST',
+        headers,
+        body: JSON.stringify(body),
+    });
+
+    return { ok: response.ok, result: await response.json() };
+}
+
+// Signing in here starts a first-party session that is only needed to issue
+// the code; the client gets its own session when it redeems the code, so this
+// one is ended right away.
+async function authorize(tokens) {
+    const { ok, result } = await post('authorize', params, tokens.access_token);
+
+    try {
+        await post(logoutURL, { refresh_token: tokens.refresh_token }, tokens.access_token);
+    } catch (error) {
+        // The session expires on its own; the sign in itself succeeded.
+    }
+
+    if (!ok) {
+        showError(result);
+        return;
+    }
+
+    window.location.assign(result.data.redirect_to);
+}
+
+async function handleLogin(data) {
+    if (data.two_factor_setup_required) {
+        message.textContent = 'Two-factor authentication must be set up in the app before signing in.';
+        return;
+    }
+
+    if (data.two_factor_required) {
+        challengeToken = data.challenge_token;
+        document.getElementById('loginForm').classList.add('d-none');
+        document.getElementById('twoFactorForm').classList.remove('d-none');
+        return;
+    }
+
+    await authorize(data);
+}
+
+document.getElementById('loginForm').addEventListener('submit', async function (e) {
+    e.preventDefault();
+
+    const email = document.getElementById('email').value;
+    const password = document.getElementById('password').value;
+
+    try {
+        const { ok, result } = await post(loginURL, { email, password });
+
+        if (!ok) {
+            showError(result);
+            return;
+        }
+
+        await handleLogin(result.data);
+    } catch (error) {
+        message.textContent = 'An error occurred. Please try again.';
+    }
+});
+
+document.getElementById('twoFactorForm').addEventListener('submit', async function (e) {
+    e.preventDefault();
+
+    const code = document.getElementById('code').value;
+
+    try {
+        const { ok, result } = await post(twoFactorURL, { challenge_token: challengeToken, code });
+
+        if (!ok) {
+            showError(result);
+            return;
+        }
+
+        await authorize(result.data);
+    } catch (error) {
+        message.textContent = 'An error occurred. Please try again.';
+    }
+});